@@ -1,40 +1,454 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
-	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 	"user-domain/infrastructure/config"
 	"user-domain/infrastructure/database"
+	"user-domain/infrastructure/database/migration"
+	"user-domain/infrastructure/encryption"
+	grpcserver "user-domain/infrastructure/grpc"
+	"user-domain/infrastructure/health"
 	router "user-domain/infrastructure/http"
-	"user-domain/infrastructure/logger"
-)
+	"user-domain/infrastructure/http/middleware"
+	"user-domain/infrastructure/idempotency"
+	"user-domain/infrastructure/lifecycle"
+	zaplogger "user-domain/infrastructure/logger"
+	"user-domain/infrastructure/metrics"
+	"user-domain/infrastructure/ratelimit"
+	"user-domain/infrastructure/tracing"
+	"user-domain/internal/application/outbound"
+	"user-domain/schemas"
 
-type Server struct {
-	httpServer *http.Server
-}
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
 
 func main() {
-	cfg := config.LoadConfig()
-	logger := logger.NewLogger()
-	gorm, err := database.NewGorm(cfg, logger)
+	logger := zaplogger.NewLogger()
+	err := run(logger)
 	if err != nil {
-		panic(err.Error())
+		logger.Error("server stopped: %s", err)
 	}
 	// flush buffer before exiting
-	defer logger.Sync()
-	r := router.BuildRouter(gorm, logger)
-	s := Server{
-		httpServer: &http.Server{
-			Handler:      r,
-			Addr:         fmt.Sprintf(":%s", cfg.ApiPort),
-			ReadTimeout:  5 * time.Second,
-			WriteTimeout: 10 * time.Second,
-			IdleTimeout:  120 * time.Second,
+	logger.Sync()
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+func run(logger outbound.Logger) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	cfg, err := config.LoadConfig(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	redactor, err := zaplogger.NewRedactor(cfg.LogRedactFields, cfg.LogRedactPatterns)
+	if err != nil {
+		return err
+	}
+	logger = zaplogger.WithRedactor(logger, redactor)
+	logger.Debug("configuration:\n%s", cfg)
+	lc := lifecycle.New(logger)
+	// Release what was set up so far if run returns before the components
+	// start; once they have, the shutdown below stops them.
+	started := false
+	defer func() {
+		if started {
+			return
+		}
+		stopCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+		if err := lc.Stop(stopCtx); err != nil {
+			logger.Error("release after failed startup: %s", err)
+		}
+	}()
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	lc.Append(lifecycle.Hook{Name: "tracing", OnStop: shutdownTracing})
+
+	secrets := config.NewSecretResolver(config.NewAWSSecretsManager(cfg), config.NewAWSSSM(cfg))
+	if err := secrets.Resolve(ctx, cfg); err != nil {
+		return err
+	}
+
+	proxies, err := middleware.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		return err
+	}
+	m := metrics.New()
+	routerOpts := []router.Option{router.WithMetrics(m), router.WithTrustedProxies(proxies)}
+	var (
+		db       *gorm.DB
+		sqlDB    *sql.DB
+		replicas []*sql.DB
+	)
+	if cfg.PersistenceBackend == "memory" {
+		logger.Warn("users are kept in memory and lost when the service stops")
+	} else {
+		var opts []router.Option
+		db, sqlDB, replicas, opts, err = openDatabase(ctx, cfg, logger, m, secrets, lc)
+		if err != nil {
+			return err
+		}
+		routerOpts = append(routerOpts, opts...)
+		envelope, err := encryption.FromConfig(cfg)
+		if err != nil {
+			return err
+		}
+		if envelope != nil {
+			routerOpts = append(routerOpts, router.WithEncryption(envelope))
+		}
+	}
+	if cfg.SecretRefreshInterval > 0 {
+		lc.Append(secretWatcherHook(secrets, cfg.SecretRefreshInterval, logger))
+	}
+
+	if store := newIdempotencyStore(cfg, db, logger); store != nil {
+		lc.Append(purgeHook("idempotency keys", time.Hour, store.DeleteExpired, logger))
+		routerOpts = append(routerOpts, router.WithIdempotency(store))
+	}
+
+	if cfg.OpenAPIValidateRequests {
+		spec, err := middleware.LoadOpenAPI(schemas.User, "/api/v1")
+		if err != nil {
+			return err
+		}
+		validateResponses := cfg.OpenAPIValidateResponses && !cfg.IsProduction()
+		routerOpts = append(routerOpts, router.WithOpenAPIValidation(spec, validateResponses))
+	}
+	if cfg.RateLimitEnabled {
+		opt, err := newRateLimit(cfg, lc)
+		if err != nil {
+			return err
+		}
+		routerOpts = append(routerOpts, opt)
+	}
+
+	h, err := newHealth(cfg, sqlDB, replicas)
+	if err != nil {
+		return err
+	}
+	// Both APIs share one service so that its cache and metrics are shared
+	// too.
+	userService := router.NewUserService(cfg, db, logger, routerOpts...)
+	r := router.BuildRouter(cfg, db, logger, append(routerOpts, router.WithHealth(h), router.WithUserService(userService))...)
+	srv := router.NewServer(cfg.ApiPort, r)
+	lc.Append(srv.Hook())
+
+	var grpcErr <-chan error
+	if cfg.GRPCEnabled {
+		grpcSrv := grpcserver.NewServer(cfg.GRPCPort, userService, logger, grpcOptions(cfg, logger)...)
+		lc.Append(grpcSrv.Hook())
+		grpcErr = grpcSrv.Err()
+	}
+
+	if err := lc.Start(ctx); err != nil {
+		return err
+	}
+	started = true
+	logger.Info("listening on :%s", cfg.ApiPort)
+	if cfg.GRPCEnabled {
+		logger.Info("grpc listening on :%s", cfg.GRPCPort)
+	}
+
+	var serveErr error
+	select {
+	case <-ctx.Done():
+		logger.Info("shutdown signal received")
+	case serveErr = <-srv.Err():
+	case serveErr = <-grpcErr:
+	}
+	stop()
+
+	h.SetShuttingDown()
+	// Give load balancers time to observe the failing readiness probe before
+	// the listener goes away.
+	time.Sleep(cfg.ShutdownDelay)
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := lc.Stop(stopCtx); err != nil {
+		return fmt.Errorf("shutdown: %w", err)
+	}
+	return serveErr
+}
+
+// openDatabase connects to the primary and the replicas and registers their
+// hooks and metrics. The options route reads to the replicas, if any.
+func openDatabase(ctx context.Context, cfg *config.Config, logger outbound.Logger, m *metrics.Metrics, secrets *config.SecretResolver, lc *lifecycle.Lifecycle) (*gorm.DB, *sql.DB, []*sql.DB, []router.Option, error) {
+	if cfg.DBDriver == database.DriverSQLite {
+		db, conn, err := openSQLite(ctx, cfg, logger, m, lc)
+		return db, conn, nil, nil, err
+	}
+	connector := database.NewConnector(cfg)
+	conn, err := database.Open(connector)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if cfg.MigrationsOnStartup {
+		if err := migrate(ctx, cfg, conn, logger); err != nil {
+			_ = conn.Close()
+			return nil, nil, nil, nil, err
+		}
+	}
+	db, err := database.NewGormFromConn(conn, logger, m.ObserveQuery)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	secrets.Subscribe(func(c *config.Config) {
+		connector.SetCredentials(c.PostgresUser, c.PostgresPassword)
+		logger.Info("database credentials refreshed")
+	})
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	lc.Append(lifecycle.Hook{Name: "postgres pool", OnStop: func(context.Context) error {
+		return sqlDB.Close()
+	}})
+	if err := db.Use(tracing.NewGormPlugin(database.DriverPostgres)); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	m.RegisterDBStats(sqlDB, cfg.PostgresDatabase)
+
+	replicas, err := openReplicas(cfg, connector, lc)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if len(replicas) == 0 {
+		return db, sqlDB, nil, nil, nil
+	}
+	writes, err := database.UseReplicas(db, replicas)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	for i, replica := range replicas {
+		m.RegisterDBStats(replica, fmt.Sprintf("%s_replica_%d", cfg.PostgresDatabase, i))
+	}
+	return db, sqlDB, replicas, []router.Option{router.WithReadYourWrites(writes)}, nil
+}
+
+func openSQLite(ctx context.Context, cfg *config.Config, logger outbound.Logger, m *metrics.Metrics, lc *lifecycle.Lifecycle) (*gorm.DB, *sql.DB, error) {
+	conn, err := database.OpenSQLite(cfg.SQLitePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	lc.Append(lifecycle.Hook{Name: "sqlite", OnStop: func(context.Context) error {
+		return conn.Close()
+	}})
+	if cfg.MigrationsOnStartup {
+		if err := migrate(ctx, cfg, conn, logger); err != nil {
+			return nil, nil, err
+		}
+	}
+	db, err := database.NewSQLiteGormFromConn(conn, logger, m.ObserveQuery)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := db.Use(tracing.NewGormPlugin(database.DriverSQLite)); err != nil {
+		return nil, nil, err
+	}
+	m.RegisterDBStats(conn, "sqlite")
+	return db, conn, nil
+}
+
+// migrate applies pending migrations. Instances starting together take turns,
+// see migration.Migrator.
+func migrate(ctx context.Context, cfg *config.Config, db *sql.DB, logger outbound.Logger) error {
+	m, err := migration.New(ctx, db, cfg.DBDriver, cfg.MigrationsSource, cfg.MigrationsLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	if err := m.Up(ctx); err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+	version, _, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	logger.Info("database migrated to version %d", version)
+	return nil
+}
+
+func openReplicas(cfg *config.Config, primary *database.Connector, lc *lifecycle.Lifecycle) ([]*sql.DB, error) {
+	connectors, err := database.ReplicaConnectors(cfg, primary)
+	if err != nil {
+		return nil, err
+	}
+	replicas := make([]*sql.DB, 0, len(connectors))
+	for _, connector := range connectors {
+		conn, err := database.Open(connector)
+		if err != nil {
+			return nil, err
+		}
+		lc.Append(lifecycle.Hook{Name: "postgres replica " + connector.String(), OnStop: func(context.Context) error {
+			return conn.Close()
+		}})
+		replicas = append(replicas, conn)
+	}
+	return replicas, nil
+}
+
+func grpcOptions(cfg *config.Config, logger outbound.Logger) []grpcserver.Option {
+	var opts []grpcserver.Option
+	if cfg.GRPCAPIKeys != "" {
+		opts = append(opts, grpcserver.WithAPIKeys(strings.Split(cfg.GRPCAPIKeys, ",")))
+	} else {
+		logger.Warn("grpc api keys are not set, the gRPC API accepts unauthenticated calls")
+	}
+	if cfg.GRPCReflection {
+		opts = append(opts, grpcserver.WithReflection())
+	}
+	return opts
+}
+
+func newIdempotencyStore(cfg *config.Config, db *gorm.DB, logger outbound.Logger) idempotency.Store {
+	switch cfg.IdempotencyStore {
+	case "memory":
+		return idempotency.NewMemoryStore(cfg.IdempotencyTTL, cfg.IdempotencyLockTimeout)
+	case "postgres":
+		if db == nil || cfg.DBDriver != database.DriverPostgres {
+			// There is no postgres to keep them in.
+			logger.Warn("idempotency.store is postgres but the %s backend has none, "+
+				"idempotency keys are kept in memory and not shared between instances", backendName(cfg))
+			return idempotency.NewMemoryStore(cfg.IdempotencyTTL, cfg.IdempotencyLockTimeout)
+		}
+		return idempotency.NewPostgresStore(db, cfg.IdempotencyTTL, cfg.IdempotencyLockTimeout)
+	}
+	return nil
+}
+
+func backendName(cfg *config.Config) string {
+	if cfg.PersistenceBackend == "memory" {
+		return "memory"
+	}
+	return cfg.DBDriver
+}
+
+func newRateLimit(cfg *config.Config, lc *lifecycle.Lifecycle) (router.Option, error) {
+	def, err := middleware.ParseLimit(cfg.RateLimitDefault)
+	if err != nil {
+		return nil, err
+	}
+	routes, err := middleware.ParseRouteLimits(cfg.RateLimitRoutes)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []middleware.KeyFunc
+	for _, name := range strings.Split(cfg.RateLimitKeys, ",") {
+		switch strings.TrimSpace(name) {
+		case "principal":
+			keys = append(keys, middleware.KeyByPrincipal)
+		case "api_key":
+			keys = append(keys, middleware.KeyByAPIKey)
+		case "ip":
+			keys = append(keys, middleware.KeyByIP)
+		default:
+			return nil, fmt.Errorf("rate limit key %q: use principal, api_key or ip", name)
+		}
+	}
+
+	store := ratelimit.NewMemoryStore()
+	if cfg.RateLimitStore == "redis" {
+		client := redis.NewClient(&redis.Options{Addr: cfg.RedisAddr, Password: cfg.RedisPassword, DB: cfg.RedisDB})
+		lc.Append(lifecycle.Hook{Name: "redis", OnStop: func(context.Context) error {
+			return client.Close()
+		}})
+		store = ratelimit.NewRedisStore(client, cfg.ServiceName+":ratelimit:")
+	}
+	return router.WithRateLimit(store, middleware.RateLimits{Default: def, Routes: routes}, middleware.FirstKey(keys...)), nil
+}
+
+// purgeHook runs purge every interval while the service is up.
+func purgeHook(name string, interval time.Duration, purge func(context.Context) (int64, error), logger outbound.Logger) lifecycle.Hook {
+	var cancel context.CancelFunc
+	return lifecycle.Hook{
+		Name: "purge " + name,
+		OnStart: func(context.Context) error {
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			go func() {
+				ticker := time.NewTicker(interval)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticker.C:
+						if n, err := purge(ctx); err != nil && ctx.Err() == nil {
+							logger.Error("purge %s: %s", name, err)
+						} else if n > 0 {
+							logger.Info("purged %d %s", n, name)
+						}
+					}
+				}
+			}()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			return nil
+		},
+	}
+}
+
+func secretWatcherHook(secrets *config.SecretResolver, interval time.Duration, logger outbound.Logger) lifecycle.Hook {
+	var cancel context.CancelFunc
+	return lifecycle.Hook{
+		Name: "secret refresh",
+		OnStart: func(context.Context) error {
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			go secrets.Watch(ctx, interval, func(err error) {
+				logger.Error("refresh secrets: %s", err)
+			})
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			return nil
 		},
 	}
-	err = s.httpServer.ListenAndServe()
+}
+
+func newHealth(cfg *config.Config, db *sql.DB, replicas []*sql.DB) (*health.Health, error) {
+	h := health.New(cfg.HealthCheckTimeout)
+	if db == nil {
+		return h, nil
+	}
+	h.AddReadinessCheck(cfg.DBDriver, health.PingChecker(db))
+	for i, replica := range replicas {
+		h.AddReadinessCheck(fmt.Sprintf("postgres_replica_%d", i), health.PingChecker(replica))
+	}
+
+	src, err := migration.Source(cfg.DBDriver, cfg.MigrationsSource)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	migrations, err := health.NewMigrationChecker(db, src)
 	if err != nil {
-		panic("error running server")
+		return nil, err
 	}
+	h.AddReadinessCheck("migrations", migrations)
+	return h, nil
 }
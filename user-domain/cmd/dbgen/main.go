@@ -1,6 +1,7 @@
 package main
 
 import (
+	"os"
 	"user-domain/infrastructure/config"
 	"user-domain/infrastructure/database"
 	"user-domain/infrastructure/logger"
@@ -17,7 +18,10 @@ func main() {
 	}
 	g := gen.NewGenerator(cfg)
 
-	dbConfig := config.LoadConfig()
+	dbConfig, err := config.LoadConfig(os.Args[1:])
+	if err != nil {
+		panic(err)
+	}
 	logger := logger.NewLogger()
 
 	gorm, err := database.NewGorm(dbConfig, logger)
@@ -1,31 +1,167 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
 	"user-domain/infrastructure/config"
 	"user-domain/infrastructure/database"
-
-	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"user-domain/infrastructure/database/migration"
 )
 
+const usage = `usage: migrate [flags] <command>
+
+commands:
+  up              apply every pending migration
+  down            revert every applied migration
+  steps <n>       apply the next n migrations, or revert the last -n
+  goto <version>  migrate up or down to version
+  version         print the current version
+  force <version> mark version as applied and clean, -1 for none
+  status          list migrations and whether they are applied
+
+Run with -h to list the flags.`
+
+var errUsage = errors.New(usage)
+
 func main() {
-	dbConfig := config.LoadConfig()
-	conn, err := database.NewDatabaseConection(dbConfig)
-	if err != nil {
-		panic("error connects database")
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	err := run(ctx, os.Args[1:], os.Stdout)
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintln(os.Stderr, usage)
+		return
 	}
-	driver, err := postgres.WithInstance(conn, &postgres.Config{})
 	if err != nil {
 		log.Fatal(err.Error())
 	}
-	m, err := migrate.NewWithDatabaseInstance(
-		"file://./cmd/migrate/ddl",
-		"postgres", driver)
+}
+
+func run(ctx context.Context, args []string, stdout io.Writer) error {
+	cfg, err := config.LoadConfig(args)
 	if err != nil {
-		log.Fatal(err.Error())
+		return err
+	}
+	cmd, err := parse(cfg.Args())
+	if err != nil {
+		return err
+	}
+	secrets := config.NewSecretResolver(config.NewAWSSecretsManager(cfg), config.NewAWSSSM(cfg))
+	if err := secrets.Resolve(ctx, cfg); err != nil {
+		return err
+	}
+	conn, err := database.Connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	m, err := migration.New(ctx, conn, cfg.DBDriver, cfg.MigrationsSource, cfg.MigrationsLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	return cmd(ctx, m, stdout)
+}
+
+type command func(ctx context.Context, m *migration.Migrator, stdout io.Writer) error
+
+// parse turns the arguments left after the flags into the command to run.
+func parse(args []string) (command, error) {
+	if len(args) == 0 {
+		return nil, errUsage
+	}
+	name, args := args[0], args[1:]
+	want := 0
+	if name == "steps" || name == "goto" || name == "force" {
+		want = 1
+	}
+	if len(args) != want {
+		return nil, fmt.Errorf("%s: wrong number of arguments\n\n%w", name, errUsage)
+	}
+
+	switch name {
+	case "up":
+		return then(func(ctx context.Context, m *migration.Migrator) error { return m.Up(ctx) }), nil
+	case "down":
+		return then(func(ctx context.Context, m *migration.Migrator) error { return m.Down(ctx) }), nil
+	case "steps", "force":
+		n, err := strconv.Atoi(args[0])
+		if err != nil || (name == "steps" && n == 0) || (name == "force" && n < -1) {
+			return nil, fmt.Errorf("%s: invalid number %q", name, args[0])
+		}
+		if name == "force" {
+			return then(func(ctx context.Context, m *migration.Migrator) error { return m.Force(ctx, n) }), nil
+		}
+		return then(func(ctx context.Context, m *migration.Migrator) error { return m.Steps(ctx, n) }), nil
+	case "goto":
+		v, err := strconv.ParseUint(args[0], 10, 0)
+		if err != nil {
+			return nil, fmt.Errorf("goto: invalid version %q", args[0])
+		}
+		return then(func(ctx context.Context, m *migration.Migrator) error { return m.Goto(ctx, uint(v)) }), nil
+	case "version":
+		return printVersion, nil
+	case "status":
+		return printStatus, nil
+	}
+	return nil, fmt.Errorf("unknown command %q\n\n%w", name, errUsage)
+}
+
+// then runs fn and reports the version the database ended up at.
+func then(fn func(ctx context.Context, m *migration.Migrator) error) command {
+	return func(ctx context.Context, m *migration.Migrator, stdout io.Writer) error {
+		if err := fn(ctx, m); err != nil {
+			return err
+		}
+		return printVersion(ctx, m, stdout)
+	}
+}
+
+func printVersion(ctx context.Context, m *migration.Migrator, stdout io.Writer) error {
+	version, dirty, err := m.Version(ctx)
+	if errors.Is(err, migration.ErrNoVersion) {
+		_, err = fmt.Fprintln(stdout, "no migration applied")
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if dirty {
+		_, err = fmt.Fprintf(stdout, "%d (dirty)\n", version)
+		return err
+	}
+	_, err = fmt.Fprintln(stdout, version)
+	return err
+}
+
+func printStatus(ctx context.Context, m *migration.Migrator, stdout io.Writer) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	return writeStatus(stdout, statuses)
+}
 
+func writeStatus(w io.Writer, statuses []migration.Status) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS")
+	for _, s := range statuses {
+		state := "pending"
+		switch {
+		case s.Dirty:
+			state = "dirty"
+		case s.Applied:
+			state = "applied"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", s.Version, s.Name, state)
 	}
-	m.Up()
+	return tw.Flush()
 }
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"user-domain/infrastructure/database/migration"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	for _, args := range [][]string{{"up"}, {"down"}, {"steps", "-2"}, {"goto", "1"}, {"version"}, {"force", "-1"}, {"status"}} {
+		cmd, err := parse(args)
+		require.NoError(t, err, args)
+		require.NotNil(t, cmd)
+	}
+
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{nil, "usage: migrate"},
+		{[]string{"sideways"}, `unknown command "sideways"`},
+		{[]string{"up", "2"}, "up: wrong number of arguments"},
+		{[]string{"goto"}, "goto: wrong number of arguments"},
+		{[]string{"goto", "-1"}, `goto: invalid version "-1"`},
+		{[]string{"steps", "0"}, `steps: invalid number "0"`},
+		{[]string{"force", "-2"}, `force: invalid number "-2"`},
+	} {
+		_, err := parse(tt.args)
+		require.ErrorContains(t, err, tt.want, tt.args)
+	}
+}
+
+func TestWriteStatus(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	require.NoError(t, writeStatus(&b, []migration.Status{
+		{Version: 1, Name: "init", Applied: true},
+		{Version: 2, Name: "idempotency_keys", Applied: true, Dirty: true},
+		{Version: 3, Name: "audit"},
+	}))
+	require.Equal(t, "VERSION  NAME              STATUS\n"+
+		"1        init              applied\n"+
+		"2        idempotency_keys  dirty\n"+
+		"3        audit             pending\n", b.String())
+}
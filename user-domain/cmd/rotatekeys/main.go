@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	"user-domain/infrastructure/config"
+	"user-domain/infrastructure/database"
+	"user-domain/infrastructure/encryption"
+	"user-domain/infrastructure/logger"
+	postgresuser "user-domain/infrastructure/persistence/postgres/user"
+)
+
+const usage = `usage: rotatekeys [flags]
+
+Re-encrypts the email and phone of every user not encrypted with the active
+master key yet, rows stored in plaintext included. Run it after adding or
+activating a master key and before removing the old one, or after turning
+encryption on. It can run while the API serves and be run again if stopped.
+
+flags:
+`
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	err := run(ctx, os.Args[1:], os.Stderr)
+	stop()
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "rotatekeys: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stderr io.Writer) error {
+	fs := flag.NewFlagSet("rotatekeys", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+	batch := fs.Int("batch", 500, "number of users read at a time")
+	configFile := fs.String("config", "", "config file of the database and the keys")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 || *batch <= 0 {
+		fs.Usage()
+		return fmt.Errorf("invalid arguments")
+	}
+
+	var cfgArgs []string
+	if *configFile != "" {
+		cfgArgs = []string{"--config", *configFile}
+	}
+	cfg, err := config.LoadConfig(cfgArgs)
+	if err != nil {
+		return err
+	}
+	secrets := config.NewSecretResolver(config.NewAWSSecretsManager(cfg), config.NewAWSSSM(cfg))
+	if err := secrets.Resolve(ctx, cfg); err != nil {
+		return err
+	}
+	envelope, err := encryption.FromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	if envelope == nil {
+		return errors.New("encryption.master_keys is not set")
+	}
+	db, err := database.NewGorm(cfg, logger.NewConsoleLogger(io.Discard))
+	if err != nil {
+		return err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+
+	start := time.Now()
+	stats, err := postgresuser.NewKeyRotator(db, envelope).Rotate(ctx, *batch, func(s postgresuser.RotateStats) {
+		fmt.Fprintf(stderr, "scanned %d users, re-encrypted %d\n", s.Scanned, s.Rotated)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stderr, "re-encrypted %d of %d users in %s\n", stats.Rotated, stats.Scanned, time.Since(start).Round(time.Millisecond))
+	return nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"user-domain/infrastructure/config"
+	"user-domain/infrastructure/database"
+	"user-domain/infrastructure/database/migration"
+	"user-domain/infrastructure/encryption"
+	"user-domain/infrastructure/logger"
+	postgresuser "user-domain/infrastructure/persistence/postgres/user"
+	"user-domain/internal/entity"
+
+	"github.com/stretchr/testify/require"
+)
+
+func key(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, 32))
+}
+
+// writeConfig writes a config of the sqlite database at path with the
+// master keys given.
+func writeConfig(t *testing.T, path, masterKeys, activeKey string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	cfg := fmt.Sprintf(`persistence:
+  backend: database
+database:
+  driver: sqlite
+sqlite:
+  path: %s
+encryption:
+  master_keys: %q
+  active_key: %q
+  index_key: %q
+`, path, masterKeys, activeKey, key(9))
+	require.NoError(t, os.WriteFile(file, []byte(cfg), 0o600))
+	return file
+}
+
+func TestRotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.db")
+	conn, err := database.OpenSQLite(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	m, err := migration.New(t.Context(), conn, database.DriverSQLite, "", time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, m.Up(t.Context()))
+	require.NoError(t, m.Close())
+	db, err := database.NewSQLiteGormFromConn(conn, logger.NewConsoleLogger(io.Discard))
+	require.NoError(t, err)
+
+	// Users stored before encryption was turned on.
+	plain := postgresuser.NewUserRepo(db)
+	var ids []string
+	for _, name := range []string{"an", "binh", "chi"} {
+		u := &entity.User{Name: name, Email: name + "@example.com", Phone: "0901234567"}
+		require.NoError(t, plain.CreateUser(t.Context(), u))
+		ids = append(ids, u.ID)
+	}
+	require.NoError(t, plain.DeleteUser(t.Context(), ids[1]))
+	require.NoError(t, plain.EraseUser(t.Context(), ids[2]))
+
+	rotate := func(cfgFile string) string {
+		var stderr bytes.Buffer
+		require.NoError(t, run(t.Context(), []string{"-config", cfgFile, "-batch", "1"}, &stderr))
+		return stderr.String()
+	}
+	k1 := writeConfig(t, path, "k1:"+key(1), "")
+	require.Contains(t, rotate(k1), "re-encrypted 2 of 2 users")
+	require.Contains(t, rotate(k1), "re-encrypted 0 of 2 users")
+
+	var stored []struct{ Email, EmailIndex string }
+	require.NoError(t, db.Table("users").Where("erased_at IS NULL").Order("id").Find(&stored).Error)
+	require.Len(t, stored, 2)
+	for _, s := range stored {
+		require.True(t, encryption.IsEncrypted(s.Email))
+		require.NotEmpty(t, s.EmailIndex)
+	}
+
+	k2 := writeConfig(t, path, "k1:"+key(1)+",k2:"+key(2), "k2")
+	require.Contains(t, rotate(k2), "re-encrypted 2 of 2 users")
+
+	// Only k2 is needed from now on.
+	cfg, err := config.LoadConfig([]string{"--config", writeConfig(t, path, "k2:"+key(2), "")})
+	require.NoError(t, err)
+	envelope, err := encryption.FromConfig(cfg)
+	require.NoError(t, err)
+	repo := postgresuser.NewUserRepo(db, postgresuser.WithEncryption(envelope))
+	u, err := repo.GetUserByEmail(t.Context(), "an@example.com")
+	require.NoError(t, err)
+	require.Equal(t, ids[0], u.ID)
+	require.Equal(t, "0901234567", u.Phone)
+	data, err := repo.GetPersonalData(t.Context(), ids[1])
+	require.NoError(t, err)
+	require.Equal(t, "binh@example.com", data.User.Email)
+
+	// Erased users hold nothing worth encrypting.
+	var email string
+	require.NoError(t, db.Table("users").Select("email").Where("id = ?", ids[2]).Scan(&email).Error)
+	require.Equal(t, entity.ErasedUser(ids[2]).Email, email)
+}
+
+func TestNoMasterKey(t *testing.T) {
+	var stderr bytes.Buffer
+	err := run(t.Context(), []string{"-config", writeConfig(t, filepath.Join(t.TempDir(), "users.db"), "", "")}, &stderr)
+	require.ErrorContains(t, err, "master_keys is not set")
+}
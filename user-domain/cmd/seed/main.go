@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	"user-domain/infrastructure/config"
+	"user-domain/infrastructure/database"
+	"user-domain/infrastructure/encryption"
+	"user-domain/infrastructure/persistence/postgres/model"
+	postgresuser "user-domain/infrastructure/persistence/postgres/user"
+	"user-domain/infrastructure/seed"
+	"user-domain/internal/entity"
+)
+
+const usage = `usage: seed [flags]
+
+Generates users and inserts them into the database, writes them to a fixture
+file, or both. The same -seed always generates the same users.
+
+flags:
+`
+
+type options struct {
+	count      int
+	seed       uint64
+	locales    string
+	fixture    string
+	insert     bool
+	configFile string
+}
+
+// inserter writes users to the database configured by configFile.
+type inserter func(ctx context.Context, configFile string, users iter.Seq[*entity.User]) (int64, error)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	err := run(ctx, os.Args[1:], os.Stdout, os.Stderr, insert)
+	stop()
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "seed: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer, insert inserter) error {
+	var o options
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+	fs.IntVar(&o.count, "n", 100, "number of users to generate")
+	fs.Uint64Var(&o.seed, "seed", 1, "seed of the generator")
+	fs.StringVar(&o.locales, "locales", "vi,en", "comma-separated locales to draw users from (vi, en)")
+	fs.StringVar(&o.fixture, "fixture", "", "also write the users to this JSON file, - for stdout")
+	fs.BoolVar(&o.insert, "insert", true, "insert the users into the database")
+	fs.StringVar(&o.configFile, "config", "", "config file of the database")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 || o.count < 0 {
+		fs.Usage()
+		return fmt.Errorf("invalid arguments")
+	}
+	if !o.insert && o.fixture == "" {
+		return errors.New("nothing to do, set -fixture or -insert")
+	}
+	locales, err := seed.ParseLocales(o.locales)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	users := seed.NewGenerator(o.seed, locales...).Users(o.count)
+	var fixture *seed.FixtureWriter
+	if o.fixture != "" {
+		w := stdout
+		if o.fixture != "-" {
+			f, err := os.Create(o.fixture)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+		fixture = seed.NewFixtureWriter(w)
+		users = tee(users, fixture, cancel)
+	}
+
+	start := time.Now()
+	if o.insert {
+		n, err := insert(ctx, o.configFile, users)
+		if cause := context.Cause(ctx); cause != nil {
+			return cause
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stderr, "inserted %d users in %s\n", n, time.Since(start).Round(time.Millisecond))
+	} else {
+		for range users {
+		}
+	}
+	if err := context.Cause(ctx); err != nil {
+		return err
+	}
+	if fixture != nil {
+		return fixture.Close()
+	}
+	return nil
+}
+
+// tee writes every user to f on the way through. A write error cancels the
+// insert, so that it is rolled back rather than leaving a fixture that does
+// not match the database.
+func tee(users iter.Seq[*entity.User], f *seed.FixtureWriter, cancel context.CancelCauseFunc) iter.Seq[*entity.User] {
+	return func(yield func(*entity.User) bool) {
+		for u := range users {
+			if err := f.Write(u); err != nil {
+				cancel(fmt.Errorf("write fixture: %w", err))
+				return
+			}
+			if !yield(u) {
+				return
+			}
+		}
+	}
+}
+
+func insert(ctx context.Context, configFile string, users iter.Seq[*entity.User]) (int64, error) {
+	var args []string
+	if configFile != "" {
+		args = []string{"--config", configFile}
+	}
+	cfg, err := config.LoadConfig(args)
+	if err != nil {
+		return 0, err
+	}
+	secrets := config.NewSecretResolver(config.NewAWSSecretsManager(cfg), config.NewAWSSSM(cfg))
+	if err := secrets.Resolve(ctx, cfg); err != nil {
+		return 0, err
+	}
+	if cfg.DBDriver != database.DriverPostgres {
+		return 0, fmt.Errorf("-insert needs postgres, load a -fixture into %s with userctl import", cfg.DBDriver)
+	}
+	db, err := database.NewDatabaseConection(cfg)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+	envelope, err := encryption.FromConfig(cfg)
+	if err != nil {
+		return 0, err
+	}
+	return seed.Copy(ctx, db, users, func(ctx context.Context, u *entity.User) (*model.User, error) {
+		return postgresuser.SealUser(ctx, envelope, u)
+	})
+}
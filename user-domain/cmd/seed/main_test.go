@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"iter"
+	"path/filepath"
+	"testing"
+	"user-domain/infrastructure/seed"
+	"user-domain/internal/entity"
+
+	"github.com/stretchr/testify/require"
+)
+
+func noInsert(t *testing.T) inserter {
+	return func(context.Context, string, iter.Seq[*entity.User]) (int64, error) {
+		t.Fatal("inserted into the database")
+		return 0, nil
+	}
+}
+
+func TestFixtureOnly(t *testing.T) {
+	t.Parallel()
+	var stdout, stderr bytes.Buffer
+	require.NoError(t, run(t.Context(), []string{"-n", "3", "-seed", "9", "-insert=false", "-fixture", "-"}, &stdout, &stderr, noInsert(t)))
+	users, err := seed.ReadFixture(&stdout)
+	require.NoError(t, err)
+	require.Len(t, users, 3)
+
+	path := filepath.Join(t.TempDir(), "users.json")
+	require.NoError(t, run(t.Context(), []string{"-n", "3", "-seed", "9", "-insert=false", "-fixture", path}, &stdout, &stderr, noInsert(t)))
+	again, err := seed.LoadFixture(path)
+	require.NoError(t, err)
+	require.Equal(t, users, again)
+}
+
+func TestInsertWritesFixture(t *testing.T) {
+	t.Parallel()
+	var inserted []*entity.User
+	insert := func(_ context.Context, configFile string, users iter.Seq[*entity.User]) (int64, error) {
+		require.Equal(t, "app.yaml", configFile)
+		for u := range users {
+			inserted = append(inserted, u)
+		}
+		return int64(len(inserted)), nil
+	}
+	var stdout, stderr bytes.Buffer
+	require.NoError(t, run(t.Context(), []string{"-n", "4", "-locales", "vi", "-config", "app.yaml", "-fixture", "-"}, &stdout, &stderr, insert))
+	require.Contains(t, stderr.String(), "inserted 4 users")
+	fixture, err := seed.ReadFixture(&stdout)
+	require.NoError(t, err)
+	require.Equal(t, inserted, fixture)
+}
+
+func TestInsertError(t *testing.T) {
+	t.Parallel()
+	boom := errors.New("boom")
+	insert := func(context.Context, string, iter.Seq[*entity.User]) (int64, error) { return 0, boom }
+	var stdout, stderr bytes.Buffer
+	require.ErrorIs(t, run(t.Context(), nil, &stdout, &stderr, insert), boom)
+}
+
+func TestArguments(t *testing.T) {
+	t.Parallel()
+	var stdout, stderr bytes.Buffer
+	require.ErrorContains(t, run(t.Context(), []string{"-insert=false"}, &stdout, &stderr, noInsert(t)), "nothing to do")
+	require.ErrorContains(t, run(t.Context(), []string{"-locales", "fr", "-insert=false", "-fixture", "-"}, &stdout, &stderr, noInsert(t)), "unknown locale")
+	require.Error(t, run(t.Context(), []string{"extra"}, &stdout, &stderr, noInsert(t)))
+	require.Contains(t, stderr.String(), "usage: seed")
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"user-domain/internal/domain/inport"
+	"user-domain/internal/entity"
+	"user-domain/pkg/userclient"
+
+	openapi_types "github.com/oapi-codegen/runtime/types"
+)
+
+// user is what userctl prints, imports and exports.
+type user struct {
+	ID      string `json:"id,omitempty" yaml:"id,omitempty"`
+	Name    string `json:"name" yaml:"name"`
+	Email   string `json:"email" yaml:"email"`
+	Phone   string `json:"phone,omitempty" yaml:"phone,omitempty"`
+	Address string `json:"address,omitempty" yaml:"address,omitempty"`
+}
+
+// change holds the fields update sets, nil ones are left alone.
+type change struct {
+	Name    *string
+	Phone   *string
+	Address *string
+}
+
+// backend runs the commands either in process or against a running API.
+type backend interface {
+	Get(ctx context.Context, id string) (*user, error)
+	List(ctx context.Context, offset, limit int) ([]user, error)
+	Create(ctx context.Context, u user) error
+	Update(ctx context.Context, id string, c change) error
+	Delete(ctx context.Context, id string) error
+	Restore(ctx context.Context, id string) error
+}
+
+// direct calls the domain service wired the way the API wires it.
+type direct struct {
+	sv inport.UserService
+}
+
+func (d direct) Get(ctx context.Context, id string) (*user, error) {
+	u, err := d.sv.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	out := fromEntity(u)
+	return &out, nil
+}
+
+func (d direct) List(ctx context.Context, offset, limit int) ([]user, error) {
+	users, err := d.sv.ListUsers(ctx, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]user, 0, len(users))
+	for _, u := range users {
+		out = append(out, fromEntity(u))
+	}
+	return out, nil
+}
+
+func (d direct) Create(ctx context.Context, u user) error {
+	return d.sv.CreateUser(ctx, &entity.User{Name: u.Name, Email: u.Email, Phone: u.Phone, Address: u.Address})
+}
+
+func (d direct) Update(ctx context.Context, id string, c change) error {
+	u := &entity.User{ID: id}
+	if c.Name != nil {
+		u.Name = *c.Name
+	}
+	if c.Phone != nil {
+		u.Phone = *c.Phone
+	}
+	if c.Address != nil {
+		u.Address = *c.Address
+	}
+	return d.sv.UpdateUser(ctx, u)
+}
+
+func (d direct) Delete(ctx context.Context, id string) error {
+	return d.sv.DeleteUser(ctx, id)
+}
+
+func (d direct) Restore(ctx context.Context, id string) error {
+	return d.sv.RestoreUser(ctx, id)
+}
+
+func fromEntity(u *entity.User) user {
+	return user{ID: u.ID, Name: u.Name, Email: u.Email, Phone: u.Phone, Address: u.Address}
+}
+
+// remote calls the HTTP API of a running instance.
+type remote struct {
+	c *userclient.UserClient
+}
+
+func (r remote) Get(ctx context.Context, id string) (*user, error) {
+	u, err := r.c.GetUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	out := fromResponse(*u)
+	return &out, nil
+}
+
+func (r remote) List(ctx context.Context, offset, limit int) ([]user, error) {
+	users, err := r.c.ListUsers(ctx, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]user, 0, len(users))
+	for _, u := range users {
+		out = append(out, fromResponse(u))
+	}
+	return out, nil
+}
+
+func (r remote) Create(ctx context.Context, u user) error {
+	return r.c.CreateUser(ctx, userclient.UserPost{
+		Name:    u.Name,
+		Email:   openapi_types.Email(u.Email),
+		Phone:   optional(u.Phone),
+		Address: optional(u.Address),
+	})
+}
+
+func (r remote) Update(ctx context.Context, id string, c change) error {
+	return r.c.UpdateUser(ctx, id, userclient.UserPut{Name: c.Name, Phone: c.Phone, Address: c.Address})
+}
+
+func (r remote) Delete(ctx context.Context, id string) error {
+	return r.c.DeleteUser(ctx, id)
+}
+
+func (r remote) Restore(ctx context.Context, id string) error {
+	return r.c.RestoreUser(ctx, id)
+}
+
+func fromResponse(u userclient.UserResponse) user {
+	out := user{ID: u.Id, Name: u.Name, Email: string(u.Email)}
+	if u.Phone != nil {
+		out.Phone = *u.Phone
+	}
+	if u.Address != nil {
+		out.Address = *u.Address
+	}
+	return out
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,380 @@
+// Command userctl operates on users, either straight against the database
+// the way the API does or through the HTTP API of a running instance.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	"user-domain/infrastructure/config"
+	"user-domain/infrastructure/database"
+	"user-domain/infrastructure/encryption"
+	router "user-domain/infrastructure/http"
+	"user-domain/infrastructure/logger"
+	"user-domain/pkg/userclient"
+
+	"gopkg.in/yaml.v3"
+)
+
+const usage = `usage: userctl [flags] <command> [args]
+
+Commands:
+  get <id>...                     print users
+  list [-offset n] [-limit n]     print a page of users
+  create -name n -email e [-phone p] [-address a]
+  update <id> [-name n] [-phone p] [-address a]
+  delete <id>...
+  restore <id>...                 undo delete
+  import [-f file]                create the users of a JSON or YAML list, ids are ignored
+  export [-page-size n]           print all users, as JSON unless -o says otherwise
+
+Without -server userctl connects to the database itself and is configured
+like the API: -config or CONFIG_FILE, then the environment.
+
+Flags:
+`
+
+type globals struct {
+	server     string
+	apiKey     string
+	configFile string
+	timeout    time.Duration
+	format     format
+	formatSet  bool
+}
+
+// connector opens the backend the globals ask for; close releases it.
+type connector func(ctx context.Context, g globals, stderr io.Writer) (b backend, close func(), err error)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	err := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr, connect)
+	stop()
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+	case errors.Is(err, errUsage):
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "userctl: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// errUsage is returned once the usage has been printed.
+var errUsage = errors.New("usage")
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer, connect connector) error {
+	g := globals{format: formatTable}
+	fs := flag.NewFlagSet("userctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&g.server, "server", os.Getenv("USERCTL_SERVER"), "base URL of the API, e.g. http://localhost:8080/api/v1 (env USERCTL_SERVER)")
+	fs.StringVar(&g.apiKey, "api-key", os.Getenv("USERCTL_API_KEY"), "API key sent to -server (env USERCTL_API_KEY)")
+	fs.StringVar(&g.configFile, "config", "", "config file used without -server")
+	fs.DurationVar(&g.timeout, "timeout", 30*time.Second, "timeout of each call")
+	fs.Var(&g.format, "o", "output format: table, json or yaml")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	fs.Visit(func(f *flag.Flag) {
+		g.formatSet = g.formatSet || f.Name == "o"
+	})
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	cmd, ok := commands[fs.Arg(0)]
+	if !ok {
+		fmt.Fprintf(stderr, "userctl: unknown command %q\n", fs.Arg(0))
+		fs.Usage()
+		return errUsage
+	}
+	cfs := flag.NewFlagSet("userctl "+fs.Arg(0), flag.ContinueOnError)
+	cfs.SetOutput(stderr)
+	run := cmd(cfs)
+	if err := cfs.Parse(fs.Args()[1:]); err != nil {
+		return err
+	}
+
+	b, closeBackend, err := connect(ctx, g, stderr)
+	if err != nil {
+		return err
+	}
+	defer closeBackend()
+	return run(ctx, &env{backend: b, globals: g, args: cfs.Args(), stdin: stdin, stdout: stdout, stderr: stderr})
+}
+
+func connect(ctx context.Context, g globals, stderr io.Writer) (backend, func(), error) {
+	if g.server != "" {
+		opts := []userclient.Option{userclient.WithTimeout(g.timeout)}
+		if g.apiKey != "" {
+			opts = append(opts, userclient.WithAPIKey(g.apiKey))
+		}
+		c, err := userclient.New(g.server, opts...)
+		if err != nil {
+			return nil, nil, err
+		}
+		return remote{c: c}, func() {}, nil
+	}
+
+	var args []string
+	if g.configFile != "" {
+		args = []string{"--config", g.configFile}
+	}
+	cfg, err := config.LoadConfig(args)
+	if err != nil {
+		return nil, nil, err
+	}
+	secrets := config.NewSecretResolver(config.NewAWSSecretsManager(cfg), config.NewAWSSSM(cfg))
+	if err := secrets.Resolve(ctx, cfg); err != nil {
+		return nil, nil, err
+	}
+	envelope, err := encryption.FromConfig(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	var opts []router.Option
+	if envelope != nil {
+		opts = append(opts, router.WithEncryption(envelope))
+	}
+	log := logger.NewConsoleLogger(stderr)
+	db, err := database.NewGorm(cfg, log)
+	if err != nil {
+		return nil, nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, nil, err
+	}
+	return direct{sv: router.NewUserService(cfg, db, log, opts...)}, func() { _ = sqlDB.Close() }, nil
+}
+
+// env is what a command runs with.
+type env struct {
+	backend
+	globals
+	args   []string
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// withTimeout bounds a single call; the HTTP client applies -timeout
+// itself.
+func (e *env) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, e.timeout)
+}
+
+// A command declares its flags on fs and returns what runs once they are
+// parsed.
+type command func(fs *flag.FlagSet) func(ctx context.Context, e *env) error
+
+var commands = map[string]command{
+	"get":     get,
+	"list":    list,
+	"create":  create,
+	"update":  update,
+	"delete":  each("deleted", backend.Delete),
+	"restore": each("restored", backend.Restore),
+	"import":  importUsers,
+	"export":  exportUsers,
+}
+
+func get(*flag.FlagSet) func(ctx context.Context, e *env) error {
+	return func(ctx context.Context, e *env) error {
+		if len(e.args) == 0 {
+			return errors.New("get: no user id given")
+		}
+		users := make([]user, 0, len(e.args))
+		for _, id := range e.args {
+			callCtx, cancel := e.withTimeout(ctx)
+			u, err := e.Get(callCtx, id)
+			cancel()
+			if err != nil {
+				return fmt.Errorf("get %s: %w", id, err)
+			}
+			users = append(users, *u)
+		}
+		return write(e.stdout, e.format, users, len(users) == 1)
+	}
+}
+
+func list(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	offset := fs.Int("offset", 0, "number of users to skip")
+	limit := fs.Int("limit", 20, "maximum number of users to print")
+	return func(ctx context.Context, e *env) error {
+		ctx, cancel := e.withTimeout(ctx)
+		defer cancel()
+		users, err := e.List(ctx, *offset, *limit)
+		if err != nil {
+			return fmt.Errorf("list: %w", err)
+		}
+		return write(e.stdout, e.format, users, false)
+	}
+}
+
+func create(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	var u user
+	fs.StringVar(&u.Name, "name", "", "name, required")
+	fs.StringVar(&u.Email, "email", "", "email address, required")
+	fs.StringVar(&u.Phone, "phone", "", "phone number")
+	fs.StringVar(&u.Address, "address", "", "address")
+	return func(ctx context.Context, e *env) error {
+		if u.Name == "" || u.Email == "" {
+			return errors.New("create: -name and -email are required")
+		}
+		ctx, cancel := e.withTimeout(ctx)
+		defer cancel()
+		if err := e.Create(ctx, u); err != nil {
+			return fmt.Errorf("create %s: %w", u.Email, err)
+		}
+		fmt.Fprintf(e.stdout, "created %s\n", u.Email)
+		return nil
+	}
+}
+
+func update(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	var c change
+	name := fs.String("name", "", "new name")
+	phone := fs.String("phone", "", "new phone number")
+	address := fs.String("address", "", "new address")
+	return func(ctx context.Context, e *env) error {
+		// flag stops at the id, the flags may follow it as the usage shows.
+		if len(e.args) > 1 {
+			if err := fs.Parse(e.args[1:]); err != nil {
+				return err
+			}
+			e.args = append(e.args[:1], fs.Args()...)
+		}
+		if len(e.args) != 1 {
+			return errors.New("update: give exactly one user id")
+		}
+		// Only the flags given are changed. An update never clears a field,
+		// so an empty value is refused rather than silently ignored.
+		var err error
+		fs.Visit(func(f *flag.Flag) {
+			if f.Value.String() == "" {
+				err = fmt.Errorf("update: -%s cannot be empty, fields cannot be cleared", f.Name)
+			}
+			switch f.Name {
+			case "name":
+				c.Name = name
+			case "phone":
+				c.Phone = phone
+			case "address":
+				c.Address = address
+			}
+		})
+		if err != nil {
+			return err
+		}
+		ctx, cancel := e.withTimeout(ctx)
+		defer cancel()
+		if err := e.Update(ctx, e.args[0], c); err != nil {
+			return fmt.Errorf("update %s: %w", e.args[0], err)
+		}
+		fmt.Fprintf(e.stdout, "updated %s\n", e.args[0])
+		return nil
+	}
+}
+
+// each applies op to every id given, reporting each as done.
+func each(done string, op func(b backend, ctx context.Context, id string) error) command {
+	return func(*flag.FlagSet) func(ctx context.Context, e *env) error {
+		return func(ctx context.Context, e *env) error {
+			if len(e.args) == 0 {
+				return errors.New("no user id given")
+			}
+			for _, id := range e.args {
+				callCtx, cancel := e.withTimeout(ctx)
+				err := op(e.backend, callCtx, id)
+				cancel()
+				if err != nil {
+					return fmt.Errorf("%s: %w", id, err)
+				}
+				fmt.Fprintf(e.stdout, "%s %s\n", done, id)
+			}
+			return nil
+		}
+	}
+}
+
+func importUsers(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	file := fs.String("f", "-", "JSON or YAML list of users, - for stdin")
+	return func(ctx context.Context, e *env) error {
+		in := e.stdin
+		if *file != "-" {
+			f, err := os.Open(*file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			in = f
+		}
+		data, err := io.ReadAll(in)
+		if err != nil {
+			return err
+		}
+		// JSON is YAML, so one decoder reads both.
+		var users []user
+		if err := yaml.Unmarshal(data, &users); err != nil {
+			return fmt.Errorf("import: %w", err)
+		}
+
+		var failed int
+		for i, u := range users {
+			callCtx, cancel := e.withTimeout(ctx)
+			err := e.Create(callCtx, u)
+			cancel()
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				failed++
+				fmt.Fprintf(e.stderr, "userctl: import #%d %s: %s\n", i+1, u.Email, err)
+			}
+		}
+		fmt.Fprintf(e.stdout, "imported %d of %d users\n", len(users)-failed, len(users))
+		if failed > 0 {
+			return fmt.Errorf("import: %d users failed", failed)
+		}
+		return nil
+	}
+}
+
+func exportUsers(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	pageSize := fs.Int("page-size", 100, "number of users fetched per call")
+	return func(ctx context.Context, e *env) error {
+		if *pageSize <= 0 {
+			return errors.New("export: -page-size must be positive")
+		}
+		format := e.format
+		if !e.formatSet {
+			format = formatJSON
+		}
+		users := []user{}
+		for offset := 0; ; offset += *pageSize {
+			callCtx, cancel := e.withTimeout(ctx)
+			page, err := e.List(callCtx, offset, *pageSize)
+			cancel()
+			if err != nil {
+				return fmt.Errorf("export: %w", err)
+			}
+			users = append(users, page...)
+			if len(page) < *pageSize {
+				break
+			}
+		}
+		return write(e.stdout, format, users, false)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fake is an in-memory backend.
+type fake struct {
+	users    []user
+	updates  map[string]change
+	deleted  []string
+	restored []string
+}
+
+func (f *fake) Get(_ context.Context, id string) (*user, error) {
+	for _, u := range f.users {
+		if u.ID == id {
+			return &u, nil
+		}
+	}
+	return nil, io.EOF
+}
+
+func (f *fake) List(_ context.Context, offset, limit int) ([]user, error) {
+	if offset >= len(f.users) {
+		return nil, nil
+	}
+	return f.users[offset:min(offset+limit, len(f.users))], nil
+}
+
+func (f *fake) Create(_ context.Context, u user) error {
+	f.users = append(f.users, u)
+	return nil
+}
+
+func (f *fake) Update(_ context.Context, id string, c change) error {
+	f.updates[id] = c
+	return nil
+}
+
+func (f *fake) Delete(_ context.Context, id string) error {
+	f.deleted = append(f.deleted, id)
+	return nil
+}
+
+func (f *fake) Restore(_ context.Context, id string) error {
+	f.restored = append(f.restored, id)
+	return nil
+}
+
+func runWith(t *testing.T, f *fake, stdin string, args ...string) (string, error) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	err := run(context.Background(), args, strings.NewReader(stdin), &stdout, &stderr,
+		func(context.Context, globals, io.Writer) (backend, func(), error) {
+			return f, func() {}, nil
+		})
+	return stdout.String(), err
+}
+
+func newFake() *fake {
+	return &fake{
+		users: []user{
+			{ID: "1", Name: "Nguyen Van An", Email: "an@example.com", Phone: "0901"},
+			{ID: "2", Name: "Jane Doe", Email: "jane@example.com"},
+			{ID: "3", Name: "Tran Thi Binh", Email: "binh@example.com"},
+		},
+		updates: map[string]change{},
+	}
+}
+
+func TestListPrintsTable(t *testing.T) {
+	out, err := runWith(t, newFake(), "", "list", "-limit", "2")
+	require.NoError(t, err)
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		lines = append(lines, strings.TrimRight(line, " "))
+	}
+	require.Equal(t, []string{
+		"ID  NAME           EMAIL             PHONE  ADDRESS",
+		"1   Nguyen Van An  an@example.com    0901",
+		"2   Jane Doe       jane@example.com",
+	}, lines)
+}
+
+func TestGetPrintsOneObject(t *testing.T) {
+	out, err := runWith(t, newFake(), "", "-o", "json", "get", "2")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":"2","name":"Jane Doe","email":"jane@example.com"}`, out)
+
+	out, err = runWith(t, newFake(), "", "-o", "yaml", "get", "1", "3")
+	require.NoError(t, err)
+	require.Equal(t, `- id: "1"
+  name: Nguyen Van An
+  email: an@example.com
+  phone: "0901"
+- id: "3"
+  name: Tran Thi Binh
+  email: binh@example.com
+`, out)
+}
+
+func TestUpdateChangesOnlyGivenFields(t *testing.T) {
+	f := newFake()
+	_, err := runWith(t, f, "", "update", "-phone", "0902", "-name", "An", "1")
+	require.NoError(t, err)
+	c := f.updates["1"]
+	require.Equal(t, "An", *c.Name)
+	require.Equal(t, "0902", *c.Phone)
+	require.Nil(t, c.Address)
+
+	// As the usage has it, flags after the id.
+	_, err = runWith(t, f, "", "update", "2", "-address", "Hue")
+	require.NoError(t, err)
+	c = f.updates["2"]
+	require.Equal(t, "Hue", *c.Address)
+	require.Nil(t, c.Name)
+
+	_, err = runWith(t, f, "", "update", "3", "-phone", "")
+	require.ErrorContains(t, err, "-phone cannot be empty")
+	_, err = runWith(t, f, "", "update", "3", "-name", "An", "4")
+	require.ErrorContains(t, err, "exactly one user id")
+	require.NotContains(t, f.updates, "3")
+}
+
+func TestDeleteAndRestore(t *testing.T) {
+	f := newFake()
+	out, err := runWith(t, f, "", "delete", "1", "2")
+	require.NoError(t, err)
+	require.Equal(t, "deleted 1\ndeleted 2\n", out)
+	_, err = runWith(t, f, "", "restore", "2")
+	require.NoError(t, err)
+	require.Equal(t, []string{"1", "2"}, f.deleted)
+	require.Equal(t, []string{"2"}, f.restored)
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	exported, err := runWith(t, newFake(), "", "export", "-page-size", "2")
+	require.NoError(t, err)
+	var users []user
+	require.NoError(t, json.Unmarshal([]byte(exported), &users))
+	require.Len(t, users, 3)
+
+	f := &fake{}
+	out, err := runWith(t, f, exported, "import")
+	require.NoError(t, err)
+	require.Equal(t, "imported 3 of 3 users\n", out)
+	require.Equal(t, newFake().users, f.users)
+
+	f = &fake{}
+	_, err = runWith(t, f, "- name: Le Van Cuong\n  email: cuong@example.com\n", "import", "-f", "-")
+	require.NoError(t, err)
+	require.Equal(t, []user{{Name: "Le Van Cuong", Email: "cuong@example.com"}}, f.users)
+}
+
+func TestUsage(t *testing.T) {
+	_, err := runWith(t, newFake(), "", "frobnicate")
+	require.ErrorIs(t, err, errUsage)
+	_, err = runWith(t, newFake(), "", "-o", "xml", "list")
+	require.Error(t, err)
+	_, err = runWith(t, newFake(), "", "create", "-name", "An")
+	require.EqualError(t, err, "create: -name and -email are required")
+}
@@ -1,29 +1,107 @@
 package config
 
 import (
-	"os"
+	"strings"
+	"time"
 )
 
+// Config is populated by LoadConfig. Every field is described by its tags:
+// conf is the key in the config file and, with dots replaced by dashes, the
+// command-line flag; env is the environment variable; default, validate and
+// secret drive defaulting, validation and redaction. Secret fields may also
+// hold a reference to an external store, see SecretResolver.
 type Config struct {
-	PostgresDatabase string
-	PostgresHost     string
-	PostgresUser     string
-	PostgresPassword string
-	PostgresSSLMode  string
-	PostgresPort     string
-	ApiPort          string
+	PersistenceBackend string `conf:"persistence.backend" env:"PERSISTENCE_BACKEND" default:"database" validate:"oneof=database memory" usage:"where users are stored, memory keeps them in process and needs no database"`
+	DBDriver           string `conf:"database.driver" env:"DB_DRIVER" default:"postgres" validate:"oneof=postgres sqlite" usage:"database the database backend uses, sqlite needs no server"`
+	SQLitePath         string `conf:"sqlite.path" env:"SQLITE_PATH" default:"user-domain.db" usage:"file of the sqlite database, :memory: keeps it in process"`
+
+	PostgresDatabase string `conf:"postgres.database" env:"SECRET_POSTGRES_DATABASE" validate:"required_if=persistence.backend database database.driver postgres" usage:"database name"`
+	PostgresHost     string `conf:"postgres.host" env:"SECRET_POSTGRES_HOSTNAME" validate:"required_if=persistence.backend database database.driver postgres" usage:"database host"`
+	PostgresUser     string `conf:"postgres.user" env:"SECRET_POSTGRES_USER" validate:"required_if=persistence.backend database database.driver postgres" secret:"true" usage:"database user"`
+	PostgresPassword string `conf:"postgres.password" env:"SECRET_POSTGRES_PASSWORD" secret:"true" usage:"database password"`
+	PostgresSSLMode  string `conf:"postgres.ssl_mode" env:"SECRET_POSTGRES_SSL_MODE" default:"disable" validate:"oneof=disable require verify-ca verify-full" usage:"libpq sslmode; the local and docker-compose postgres have no TLS, use require or verify-full in production"`
+	PostgresPort     string `conf:"postgres.port" env:"SECRET_POSTGRES_PORT" default:"5432" validate:"port" usage:"database port"`
+	PostgresReplicas string `conf:"postgres.replicas" env:"SECRET_POSTGRES_REPLICAS" usage:"comma-separated host[:port] list of read replicas"`
+	ApiPort          string `conf:"api.port" env:"API_PORT" default:"8080" validate:"port" usage:"port the HTTP API listens on"`
+	TrustedProxies   string `conf:"api.trusted_proxies" env:"API_TRUSTED_PROXIES" usage:"comma-separated addresses or CIDR ranges of the proxies whose X-Forwarded-For and X-Real-IP headers name the client, empty ignores those headers"`
+
+	GRPCEnabled    bool   `conf:"grpc.enabled" env:"GRPC_ENABLED" default:"false" usage:"serve the gRPC API"`
+	GRPCPort       string `conf:"grpc.port" env:"GRPC_PORT" default:"9090" validate:"port" usage:"port the gRPC API listens on"`
+	GRPCAPIKeys    string `conf:"grpc.api_keys" env:"GRPC_API_KEYS" secret:"true" usage:"comma-separated keys gRPC clients must present, empty disables authentication"`
+	GRPCReflection bool   `conf:"grpc.reflection" env:"GRPC_REFLECTION" default:"true" usage:"serve gRPC server reflection"`
+
+	GraphQLEnabled       bool `conf:"graphql.enabled" env:"GRAPHQL_ENABLED" default:"true" usage:"serve the GraphQL API on /graphql"`
+	GraphQLMaxComplexity int  `conf:"graphql.max_complexity" env:"GRAPHQL_MAX_COMPLEXITY" default:"1000" validate:"min=0" usage:"maximum cost of a GraphQL query, 0 disables the check"`
+	GraphQLMaxDepth      int  `conf:"graphql.max_depth" env:"GRAPHQL_MAX_DEPTH" default:"10" validate:"min=0" usage:"maximum nesting of a GraphQL query, 0 disables the check"`
+
+	UserCacheEnabled bool          `conf:"user_cache.enabled" env:"USER_CACHE_ENABLED" default:"false" usage:"cache user lookups in process"`
+	UserCacheSize    int           `conf:"user_cache.size" env:"USER_CACHE_SIZE" default:"10000" validate:"min=1" usage:"maximum number of cached users"`
+	UserCacheTTL     time.Duration `conf:"user_cache.ttl" env:"USER_CACHE_TTL" default:"1m" validate:"min=0s" usage:"how long a cached user stays valid, 0 disables expiry"`
+
+	EncryptionMasterKeys string `conf:"encryption.master_keys" env:"ENCRYPTION_MASTER_KEYS" secret:"true" usage:"comma-separated id:base64 256-bit master keys wrapping the keys email and phone are encrypted with, empty stores them in plaintext"`
+	EncryptionActiveKey  string `conf:"encryption.active_key" env:"ENCRYPTION_ACTIVE_KEY" usage:"id of the master key new data keys are wrapped with, the first one if empty"`
+	EncryptionIndexKey   string `conf:"encryption.index_key" env:"ENCRYPTION_INDEX_KEY" secret:"true" usage:"base64 key of at least 256 bits hashing emails for lookups, needed with master keys and never to be changed"`
+
+	ServiceName        string  `conf:"service.name" env:"SERVICE_NAME" default:"user-domain" validate:"required" usage:"service name reported to telemetry backends"`
+	Env                string  `conf:"service.env" env:"ENV" default:"development" validate:"required" usage:"deployment environment, prod or production logs JSON and never validates responses"`
+	TracingExporter    string  `conf:"tracing.exporter" env:"TRACING_EXPORTER" default:"none" validate:"oneof=none stdout otlp" usage:"span exporter"`
+	TracingEndpoint    string  `conf:"tracing.otlp_endpoint" env:"TRACING_OTLP_ENDPOINT" usage:"host:port of the OTLP/HTTP collector"`
+	TracingInsecure    bool    `conf:"tracing.otlp_insecure" env:"TRACING_OTLP_INSECURE" default:"false" usage:"send spans to the collector without TLS"`
+	TracingSampleRatio float64 `conf:"tracing.sample_ratio" env:"TRACING_SAMPLE_RATIO" default:"1" validate:"min=0,max=1" usage:"fraction of new traces to sample"`
+
+	LogRedactFields   string `conf:"log.redact_fields" env:"LOG_REDACT_FIELDS" usage:"comma-separated field names whose values are never logged, besides email, phone, password, token and the like"`
+	LogRedactPatterns string `conf:"log.redact_patterns" env:"LOG_REDACT_PATTERNS" usage:"space-separated regular expressions whose matches are removed from logs, besides emails, phone numbers and tokens; only the first group if there is one"`
+
+	HealthCheckTimeout time.Duration `conf:"health.check_timeout" env:"HEALTH_CHECK_TIMEOUT" default:"2s" validate:"min=1ms" usage:"default timeout of a single health check"`
+
+	MigrationsSource      string        `conf:"migrations.source" env:"MIGRATIONS_SOURCE" usage:"golang-migrate source URL, empty uses the migrations built into the binary"`
+	MigrationsOnStartup   bool          `conf:"migrations.on_startup" env:"MIGRATE_ON_STARTUP" default:"false" usage:"apply pending migrations before the API starts serving"`
+	MigrationsLockTimeout time.Duration `conf:"migrations.lock_timeout" env:"MIGRATIONS_LOCK_TIMEOUT" default:"5m" validate:"min=1s" usage:"how long to wait for a migration running elsewhere"`
+
+	ShutdownTimeout time.Duration `conf:"shutdown.timeout" env:"SHUTDOWN_TIMEOUT" default:"15s" validate:"min=1ms" usage:"how long to drain in-flight work on shutdown"`
+	ShutdownDelay   time.Duration `conf:"shutdown.delay" env:"SHUTDOWN_DELAY" default:"0s" validate:"min=0s" usage:"how long to fail readiness before draining"`
+
+	OpenAPIValidateRequests  bool `conf:"openapi.validate_requests" env:"OPENAPI_VALIDATE_REQUESTS" default:"true" usage:"reject requests that do not match the OpenAPI spec"`
+	OpenAPIValidateResponses bool `conf:"openapi.validate_responses" env:"OPENAPI_VALIDATE_RESPONSES" default:"false" usage:"log responses that do not match the OpenAPI spec, ignored in production"`
+
+	IdempotencyStore       string        `conf:"idempotency.store" env:"IDEMPOTENCY_STORE" default:"postgres" validate:"oneof=none memory postgres" usage:"where Idempotency-Key responses are kept"`
+	IdempotencyTTL         time.Duration `conf:"idempotency.ttl" env:"IDEMPOTENCY_TTL" default:"24h" validate:"min=1s" usage:"how long a stored response is replayed"`
+	IdempotencyLockTimeout time.Duration `conf:"idempotency.lock_timeout" env:"IDEMPOTENCY_LOCK_TIMEOUT" default:"1m" validate:"min=1s" usage:"after how long an unfinished request no longer blocks its key"`
+
+	RateLimitEnabled bool   `conf:"rate_limit.enabled" env:"RATE_LIMIT_ENABLED" default:"false" usage:"reject clients that exceed their request rate"`
+	RateLimitStore   string `conf:"rate_limit.store" env:"RATE_LIMIT_STORE" default:"memory" validate:"oneof=memory redis" usage:"where request counts are kept"`
+	RateLimitKeys    string `conf:"rate_limit.keys" env:"RATE_LIMIT_KEYS" default:"principal,ip" usage:"comma-separated client identities to count against, first match wins (principal, api_key, ip); api_key trusts the X-API-Key header as sent, list it only behind a gateway that authenticates it"`
+	RateLimitDefault string `conf:"rate_limit.default" env:"RATE_LIMIT_DEFAULT" default:"100/1m" usage:"limit of routes without their own, as <requests>/<window>"`
+	RateLimitRoutes  string `conf:"rate_limit.routes" env:"RATE_LIMIT_ROUTES" usage:"comma-separated per-route limits, e.g. \"POST /api/v1/users=10/1m\""`
+
+	RedisAddr     string `conf:"redis.addr" env:"REDIS_ADDR" default:"localhost:6379" usage:"host:port of redis"`
+	RedisPassword string `conf:"redis.password" env:"REDIS_PASSWORD" secret:"true" usage:"redis password"`
+	RedisDB       int    `conf:"redis.db" env:"REDIS_DB" default:"0" validate:"min=0" usage:"redis database number"`
+
+	AWSRegion             string        `conf:"aws.region" env:"AWS_REGION" usage:"region of the AWS secret stores"`
+	AWSEndpoint           string        `conf:"aws.endpoint" env:"AWS_ENDPOINT_URL" usage:"override the AWS endpoint, e.g. a localstack URL"`
+	SecretRefreshInterval time.Duration `conf:"secrets.refresh_interval" env:"SECRET_REFRESH_INTERVAL" default:"5m" validate:"min=0s" usage:"how often secrets are re-read, 0 disables refresh"`
+
+	// args are the command-line arguments left after the flags.
+	args    []string
+	sources map[string]source
+	// files remembers which secret fields were read through *_FILE so that
+	// they can be re-read when the mounted secret is rotated.
+	files map[string]string
 }
 
-func LoadConfig() *Config {
-	cfg := &Config{
-		PostgresDatabase: os.Getenv("SECRET_POSTGRES_DATABASE"),
-		PostgresHost:     os.Getenv("SECRET_POSTGRES_HOSTNAME"),
-		PostgresUser:     os.Getenv("SECRET_POSTGRES_USER"),
-		PostgresPassword: os.Getenv("SECRET_POSTGRES_PASSWORD"),
-		PostgresSSLMode:  os.Getenv("SECRET_POSTGRES_SSL_MODE"),
-		PostgresPort:     os.Getenv("SECRET_POSTGRES_PORT"),
-		ApiPort:          os.Getenv("API_PORT"),
+// IsProduction reports whether env, the value of service.env, names a
+// production deployment. Both prod and production do, so that the logger,
+// which reads ENV before the config is loaded, and the API agree.
+func IsProduction(env string) bool {
+	switch strings.ToLower(strings.TrimSpace(env)) {
+	case "prod", "production":
+		return true
 	}
+	return false
+}
 
-	return cfg
+// IsProduction reports whether the service runs in production.
+func (c *Config) IsProduction() bool {
+	return IsProduction(c.Env)
 }
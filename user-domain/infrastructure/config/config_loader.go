@@ -0,0 +1,443 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	configFileFlag = "config"
+	configFileEnv  = "CONFIG_FILE"
+	fileEnvSuffix  = "_FILE"
+	redacted       = "******"
+)
+
+type source string
+
+const (
+	sourceDefault source = "default"
+	sourceFile    source = "file"
+	sourceEnv     source = "env"
+	sourceEnvFile source = "env file"
+	sourceFlag    source = "flag"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// ValidationError lists every problem found in the merged configuration so
+// that they can all be fixed in one go.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "config: invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+type field struct {
+	key      string
+	env      string
+	def      string
+	usage    string
+	validate string
+	secret   bool
+	value    reflect.Value
+	source   source
+}
+
+func (f *field) flagName() string {
+	return strings.NewReplacer(".", "-", "_", "-").Replace(f.key)
+}
+
+type loader struct {
+	lookupEnv func(string) (string, bool)
+	readFile  func(string) ([]byte, error)
+}
+
+// LoadConfig merges, from lowest to highest precedence, the defaults declared
+// on Config, an optional YAML or TOML file given by --config or CONFIG_FILE,
+// environment variables and command-line flags, then validates the result.
+func LoadConfig(args []string) (*Config, error) {
+	l := loader{lookupEnv: os.LookupEnv, readFile: os.ReadFile}
+	return l.load(args)
+}
+
+func (l loader) load(args []string) (*Config, error) {
+	cfg := &Config{}
+	fields := describe(cfg)
+
+	fs := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	configFile := fs.String(configFileFlag, "", "path to a YAML or TOML config file (env "+configFileEnv+")")
+	flagValues := make(map[string]*string, len(fields))
+	for _, f := range fields {
+		usage := f.usage
+		if f.env != "" {
+			usage += " (env " + f.env + ")"
+		}
+		flagValues[f.flagName()] = fs.String(f.flagName(), f.def, usage)
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	for _, f := range fields {
+		if f.def == "" {
+			continue
+		}
+		if err := set(f.value, f.def); err != nil {
+			return nil, fmt.Errorf("config: default of %s: %w", f.key, err)
+		}
+		f.source = sourceDefault
+	}
+
+	path := *configFile
+	if path == "" {
+		path, _ = l.lookupEnv(configFileEnv)
+	}
+	if path != "" {
+		if err := l.applyFile(path, fields); err != nil {
+			return nil, err
+		}
+	}
+
+	var problems []string
+	files := map[string]string{}
+	for _, f := range fields {
+		if f.env == "" {
+			continue
+		}
+		v, _ := l.lookupEnv(f.env)
+		path, _ := l.lookupEnv(f.env + fileEnvSuffix)
+		switch {
+		case v != "" && path != "":
+			problems = append(problems, fmt.Sprintf("%s: set only one of %s and %s", f.key, f.env, f.env+fileEnvSuffix))
+		case v != "":
+			if err := set(f.value, v); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %s", f.env, err))
+				continue
+			}
+			f.source = sourceEnv
+		case path != "":
+			// Docker and Kubernetes secrets are mounted as files, the
+			// variable with the _FILE suffix points at one.
+			data, err := l.readFile(path)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %s", f.env+fileEnvSuffix, err))
+				continue
+			}
+			if err := set(f.value, strings.TrimRight(string(data), "\r\n")); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %s", f.env+fileEnvSuffix, err))
+				continue
+			}
+			f.source = sourceEnvFile
+			files[f.key] = path
+		}
+	}
+
+	fs.Visit(func(fl *flag.Flag) {
+		v, ok := flagValues[fl.Name]
+		if !ok {
+			return
+		}
+		f := fieldByFlag(fields, fl.Name)
+		if err := set(f.value, *v); err != nil {
+			problems = append(problems, fmt.Sprintf("--%s: %s", fl.Name, err))
+			return
+		}
+		f.source = sourceFlag
+	})
+
+	for _, f := range fields {
+		problems = append(problems, f.check(fields)...)
+	}
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+
+	cfg.sources = make(map[string]source, len(fields))
+	for _, f := range fields {
+		cfg.sources[f.key] = f.source
+	}
+	cfg.files = files
+	cfg.args = fs.Args()
+	return cfg, nil
+}
+
+func (l loader) applyFile(path string, fields []*field) error {
+	data, err := l.readFile(path)
+	if err != nil {
+		return fmt.Errorf("config: read %s: %w", path, err)
+	}
+
+	raw := map[string]interface{}{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return fmt.Errorf("config: unsupported file type %q, use .yaml, .yml or .toml", ext)
+	}
+	if err != nil {
+		return fmt.Errorf("config: parse %s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	flatten("", raw, values)
+
+	byKey := make(map[string]*field, len(fields))
+	for _, f := range fields {
+		byKey[f.key] = f
+	}
+	var problems []string
+	for _, key := range sortedKeys(values) {
+		f, ok := byKey[key]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: unknown key in %s", key, path))
+			continue
+		}
+		if err := set(f.value, values[key]); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", key, err))
+			continue
+		}
+		f.source = sourceFile
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// Args returns the command-line arguments left after the flags, such as the
+// subcommand of a CLI.
+func (c *Config) Args() []string {
+	return c.args
+}
+
+// String renders the configuration one key per line together with where each
+// value came from. Secrets are redacted.
+func (c *Config) String() string {
+	var b strings.Builder
+	for _, f := range describe(c) {
+		v := format(f.value)
+		if f.secret && v != "" {
+			v = redacted
+		}
+		src := c.sources[f.key]
+		if src == "" {
+			src = "unset"
+		}
+		fmt.Fprintf(&b, "%s = %q (%s)\n", f.key, v, src)
+	}
+	return b.String()
+}
+
+func describe(cfg *Config) []*field {
+	v := reflect.ValueOf(cfg).Elem()
+	t := v.Type()
+	fields := make([]*field, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		key, ok := sf.Tag.Lookup("conf")
+		if !ok {
+			continue
+		}
+		fields = append(fields, &field{
+			key:      key,
+			env:      sf.Tag.Get("env"),
+			def:      sf.Tag.Get("default"),
+			usage:    sf.Tag.Get("usage"),
+			validate: sf.Tag.Get("validate"),
+			secret:   sf.Tag.Get("secret") == "true",
+			value:    v.Field(i),
+		})
+	}
+	return fields
+}
+
+func fieldByFlag(fields []*field, name string) *field {
+	for _, f := range fields {
+		if f.flagName() == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func fieldByKey(fields []*field, key string) *field {
+	for _, f := range fields {
+		if f.key == key {
+			return f
+		}
+	}
+	return nil
+}
+
+func (f *field) check(fields []*field) []string {
+	if f.validate == "" {
+		return nil
+	}
+	name := f.key
+	if f.env != "" {
+		name += " (" + f.env + ")"
+	}
+
+	var problems []string
+	for _, rule := range strings.Split(f.validate, ",") {
+		op, arg, _ := strings.Cut(rule, "=")
+		if err := f.apply(op, arg, fields); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", name, err))
+		}
+	}
+	return problems
+}
+
+func (f *field) apply(op, arg string, fields []*field) error {
+	switch op {
+	case "required":
+		if f.value.IsZero() {
+			return errors.New("is required")
+		}
+	case "required_if":
+		// required_if=<key> <value> [<key> <value>...] requires the field
+		// while every field at key holds its value.
+		pairs := strings.Fields(arg)
+		var conds []string
+		for i := 0; i+1 < len(pairs); i += 2 {
+			other := fieldByKey(fields, pairs[i])
+			if other == nil || format(other.value) != pairs[i+1] {
+				return nil
+			}
+			conds = append(conds, pairs[i]+" is "+pairs[i+1])
+		}
+		if f.value.IsZero() {
+			return fmt.Errorf("is required when %s", strings.Join(conds, " and "))
+		}
+	case "port":
+		port, err := strconv.Atoi(f.value.String())
+		if err != nil || port < 1 || port > 65535 {
+			return fmt.Errorf("%q is not a valid port", f.value.String())
+		}
+	case "oneof":
+		v := format(f.value)
+		for _, allowed := range strings.Fields(arg) {
+			if v == allowed {
+				return nil
+			}
+		}
+		return fmt.Errorf("%q must be one of [%s]", v, strings.Join(strings.Fields(arg), ", "))
+	case "min", "max":
+		bound := reflect.New(f.value.Type()).Elem()
+		if err := set(bound, arg); err != nil {
+			return fmt.Errorf("bad %s rule: %w", op, err)
+		}
+		c := compare(f.value, bound)
+		if op == "min" && c < 0 {
+			return fmt.Errorf("%s is below the minimum of %s", format(f.value), arg)
+		}
+		if op == "max" && c > 0 {
+			return fmt.Errorf("%s is above the maximum of %s", format(f.value), arg)
+		}
+	default:
+		return fmt.Errorf("unknown validation rule %q", op)
+	}
+	return nil
+}
+
+func set(v reflect.Value, s string) error {
+	s = strings.TrimSpace(s)
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration", s)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", s)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", s)
+		}
+		v.SetInt(i)
+	case reflect.Float64:
+		fl, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", s)
+		}
+		v.SetFloat(fl)
+	default:
+		return fmt.Errorf("unsupported config type %s", v.Type())
+	}
+	return nil
+}
+
+func format(v reflect.Value) string {
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String()
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func compare(a, b reflect.Value) int {
+	switch a.Kind() {
+	case reflect.Int, reflect.Int64:
+		switch {
+		case a.Int() < b.Int():
+			return -1
+		case a.Int() > b.Int():
+			return 1
+		}
+	case reflect.Float64:
+		switch {
+		case a.Float() < b.Float():
+			return -1
+		case a.Float() > b.Float():
+			return 1
+		}
+	}
+	return 0
+}
+
+func flatten(prefix string, in map[string]interface{}, out map[string]string) {
+	for k, v := range in {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if nested, ok := v.(map[string]interface{}); ok {
+			flatten(key, nested, out)
+			continue
+		}
+		out[key] = fmt.Sprint(v)
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,231 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var requiredEnv = map[string]string{
+	"SECRET_POSTGRES_DATABASE": "users",
+	"SECRET_POSTGRES_HOSTNAME": "localhost",
+	"SECRET_POSTGRES_USER":     "postgres",
+}
+
+func newLoader(env map[string]string, files map[string]string) loader {
+	return loader{
+		lookupEnv: func(key string) (string, bool) {
+			v, ok := env[key]
+			return v, ok
+		},
+		readFile: func(path string) ([]byte, error) {
+			data, ok := files[path]
+			if !ok {
+				return nil, os.ErrNotExist
+			}
+			return []byte(data), nil
+		},
+	}
+}
+
+func withEnv(extra map[string]string) map[string]string {
+	env := map[string]string{}
+	for k, v := range requiredEnv {
+		env[k] = v
+	}
+	for k, v := range extra {
+		env[k] = v
+	}
+	return env
+}
+
+func TestLoadDefaults(t *testing.T) {
+	t.Parallel()
+	cfg, err := newLoader(withEnv(nil), nil).load(nil)
+	require.NoError(t, err)
+	require.Equal(t, "8080", cfg.ApiPort)
+	require.Equal(t, "5432", cfg.PostgresPort)
+	require.Equal(t, "disable", cfg.PostgresSSLMode)
+	require.Equal(t, time.Minute, cfg.UserCacheTTL)
+	require.Equal(t, 10000, cfg.UserCacheSize)
+	require.Equal(t, float64(1), cfg.TracingSampleRatio)
+	require.Equal(t, "principal,ip", cfg.RateLimitKeys)
+}
+
+func TestLoadKeepsArgs(t *testing.T) {
+	t.Parallel()
+	cfg, err := newLoader(withEnv(nil), nil).load([]string{"--api-port", "9000", "goto", "2"})
+	require.NoError(t, err)
+	require.Equal(t, "9000", cfg.ApiPort)
+	require.Equal(t, []string{"goto", "2"}, cfg.Args())
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	t.Parallel()
+	files := map[string]string{
+		"app.yaml": `
+api:
+  port: 9000
+user_cache:
+  enabled: true
+  size: 50
+  ttl: 30s
+tracing:
+  sample_ratio: 0.25
+`,
+		"app.toml": `
+[api]
+port = "9100"
+
+[user_cache]
+size = 60
+`,
+	}
+	tests := []struct {
+		name      string
+		env       map[string]string
+		args      []string
+		wantPort  string
+		wantSize  int
+		wantTTL   time.Duration
+		wantRatio float64
+	}{
+		{
+			name:      "yaml file over defaults",
+			args:      []string{"--config", "app.yaml"},
+			wantPort:  "9000",
+			wantSize:  50,
+			wantTTL:   30 * time.Second,
+			wantRatio: 0.25,
+		},
+		{
+			name:      "toml file from env",
+			env:       map[string]string{"CONFIG_FILE": "app.toml"},
+			wantPort:  "9100",
+			wantSize:  60,
+			wantTTL:   time.Minute,
+			wantRatio: 1,
+		},
+		{
+			name:      "env over file",
+			env:       map[string]string{"API_PORT": "9001", "USER_CACHE_TTL": "5s"},
+			args:      []string{"--config", "app.yaml"},
+			wantPort:  "9001",
+			wantSize:  50,
+			wantTTL:   5 * time.Second,
+			wantRatio: 0.25,
+		},
+		{
+			name:      "flags over env",
+			env:       map[string]string{"API_PORT": "9001"},
+			args:      []string{"--config", "app.yaml", "--api-port", "9002", "--user-cache-size=7"},
+			wantPort:  "9002",
+			wantSize:  7,
+			wantTTL:   30 * time.Second,
+			wantRatio: 0.25,
+		},
+		{
+			name:      "empty env is ignored",
+			env:       map[string]string{"API_PORT": ""},
+			wantPort:  "8080",
+			wantSize:  10000,
+			wantTTL:   time.Minute,
+			wantRatio: 1,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg, err := newLoader(withEnv(tt.env), files).load(tt.args)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantPort, cfg.ApiPort)
+			require.Equal(t, tt.wantSize, cfg.UserCacheSize)
+			require.Equal(t, tt.wantTTL, cfg.UserCacheTTL)
+			require.Equal(t, tt.wantRatio, cfg.TracingSampleRatio)
+		})
+	}
+}
+
+func TestLoadReportsEveryProblem(t *testing.T) {
+	t.Parallel()
+	env := map[string]string{
+		"API_PORT":             "70000",
+		"TRACING_EXPORTER":     "jaeger",
+		"TRACING_SAMPLE_RATIO": "2",
+		"USER_CACHE_SIZE":      "lots",
+	}
+	_, err := newLoader(env, nil).load(nil)
+
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr))
+	require.ElementsMatch(t, []string{
+		`USER_CACHE_SIZE: "lots" is not an integer`,
+		`postgres.database (SECRET_POSTGRES_DATABASE): is required when persistence.backend is database and database.driver is postgres`,
+		`postgres.host (SECRET_POSTGRES_HOSTNAME): is required when persistence.backend is database and database.driver is postgres`,
+		`postgres.user (SECRET_POSTGRES_USER): is required when persistence.backend is database and database.driver is postgres`,
+		`api.port (API_PORT): "70000" is not a valid port`,
+		`tracing.exporter (TRACING_EXPORTER): "jaeger" must be one of [none, stdout, otlp]`,
+		`tracing.sample_ratio (TRACING_SAMPLE_RATIO): 2 is above the maximum of 1`,
+	}, verr.Problems)
+}
+
+func TestLoadWithoutPostgres(t *testing.T) {
+	t.Parallel()
+	cfg, err := newLoader(map[string]string{"PERSISTENCE_BACKEND": "memory"}, nil).load(nil)
+	require.NoError(t, err)
+	require.Equal(t, "memory", cfg.PersistenceBackend)
+
+	cfg, err = newLoader(map[string]string{"DB_DRIVER": "sqlite"}, nil).load(nil)
+	require.NoError(t, err)
+	require.Equal(t, "user-domain.db", cfg.SQLitePath)
+}
+
+func TestLoadRejectsUnknownFileKeys(t *testing.T) {
+	t.Parallel()
+	files := map[string]string{"app.yaml": "api:\n  prot: 9000\n"}
+	_, err := newLoader(withEnv(nil), files).load([]string{"--config", "app.yaml"})
+	require.ErrorContains(t, err, "api.prot: unknown key in app.yaml")
+}
+
+func TestStringRedactsSecrets(t *testing.T) {
+	t.Parallel()
+	cfg, err := newLoader(withEnv(map[string]string{"SECRET_POSTGRES_PASSWORD": "hunter2"}), nil).
+		load([]string{"--api-port", "9000"})
+	require.NoError(t, err)
+
+	out := cfg.String()
+	require.NotContains(t, out, "hunter2")
+	require.Contains(t, out, `postgres.password = "******" (env)`)
+	require.Contains(t, out, `api.port = "9000" (flag)`)
+	require.Contains(t, out, `postgres.port = "5432" (default)`)
+	require.Contains(t, out, `tracing.otlp_endpoint = "" (unset)`)
+}
+
+func TestLoadFileIndirection(t *testing.T) {
+	t.Parallel()
+	env := withEnv(map[string]string{"SECRET_POSTGRES_PASSWORD_FILE": "/run/secrets/db-password"})
+	files := map[string]string{"/run/secrets/db-password": "s3cret\n"}
+
+	cfg, err := newLoader(env, files).load(nil)
+	require.NoError(t, err)
+	require.Equal(t, "s3cret", cfg.PostgresPassword)
+	require.Equal(t, "/run/secrets/db-password", cfg.files["postgres.password"])
+	require.Contains(t, cfg.String(), `postgres.password = "******" (env file)`)
+
+	env["SECRET_POSTGRES_PASSWORD"] = "other"
+	_, err = newLoader(env, files).load(nil)
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	require.Contains(t, verr.Problems[0], "set only one of")
+}
+
+func TestIsProduction(t *testing.T) {
+	t.Parallel()
+	for env, want := range map[string]bool{"prod": true, "production": true, "Production": true, "development": false, "staging": false, "": false} {
+		require.Equal(t, want, (&Config{Env: env}).IsProduction(), env)
+	}
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const SchemeFile = "file"
+
+// SecretProvider fetches secrets from one kind of store. A secret field whose
+// value is "<scheme>:<reference>" is resolved by the provider with that scheme.
+type SecretProvider interface {
+	Scheme() string
+	GetSecret(ctx context.Context, ref string) (string, error)
+}
+
+type secretRef struct {
+	scheme string
+	ref    string
+}
+
+// SecretResolver replaces secret references in a Config with their values and
+// can re-read them later so that rotated credentials are picked up without a
+// restart.
+type SecretResolver struct {
+	providers map[string]SecretProvider
+
+	mu          sync.Mutex
+	refs        map[string]secretRef
+	current     Config
+	subscribers []func(cfg *Config)
+}
+
+func NewSecretResolver(providers ...SecretProvider) *SecretResolver {
+	r := &SecretResolver{providers: map[string]SecretProvider{}}
+	for _, p := range append([]SecretProvider{fileProvider{}}, providers...) {
+		r.providers[p.Scheme()] = p
+	}
+	return r
+}
+
+// Resolve replaces every secret reference in cfg with the secret it points
+// to. Fields loaded from *_FILE variables are remembered as file references.
+func (r *SecretResolver) Resolve(ctx context.Context, cfg *Config) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.refs = map[string]secretRef{}
+	for _, f := range describe(cfg) {
+		if !f.secret {
+			continue
+		}
+		if path, ok := cfg.files[f.key]; ok {
+			r.refs[f.key] = secretRef{scheme: SchemeFile, ref: path}
+			continue
+		}
+		scheme, ref, ok := strings.Cut(f.value.String(), ":")
+		if _, known := r.providers[scheme]; ok && known {
+			r.refs[f.key] = secretRef{scheme: scheme, ref: ref}
+		}
+	}
+
+	values, err := r.fetch(ctx)
+	if err != nil {
+		return err
+	}
+	for _, f := range describe(cfg) {
+		if v, ok := values[f.key]; ok {
+			f.value.SetString(v)
+		}
+	}
+	r.current = *cfg
+	return nil
+}
+
+// Subscribe registers fn to be called with a copy of the configuration after
+// a refresh changed at least one secret.
+func (r *SecretResolver) Subscribe(fn func(cfg *Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscribers = append(r.subscribers, fn)
+}
+
+// Refresh fetches every secret again and notifies subscribers if any of them
+// changed. It reports whether something changed.
+func (r *SecretResolver) Refresh(ctx context.Context) (bool, error) {
+	r.mu.Lock()
+	values, err := r.fetch(ctx)
+	if err != nil {
+		r.mu.Unlock()
+		return false, err
+	}
+
+	next := r.current
+	changed := false
+	for _, f := range describe(&next) {
+		if v, ok := values[f.key]; ok && v != f.value.String() {
+			f.value.SetString(v)
+			changed = true
+		}
+	}
+	if !changed {
+		r.mu.Unlock()
+		return false, nil
+	}
+	r.current = next
+	subscribers := append([]func(*Config){}, r.subscribers...)
+	r.mu.Unlock()
+
+	for _, fn := range subscribers {
+		cfg := next
+		fn(&cfg)
+	}
+	return true, nil
+}
+
+// Watch refreshes secrets every interval until ctx is done. Failures are
+// passed to onError and the previous values stay in use.
+func (r *SecretResolver) Watch(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := r.Refresh(ctx); err != nil && ctx.Err() == nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+func (r *SecretResolver) fetch(ctx context.Context) (map[string]string, error) {
+	values := make(map[string]string, len(r.refs))
+	for key, ref := range r.refs {
+		v, err := r.providers[ref.scheme].GetSecret(ctx, ref.ref)
+		if err != nil {
+			return nil, fmt.Errorf("config: resolve %s from %s: %w", key, ref.scheme, err)
+		}
+		values[key] = v
+	}
+	return values, nil
+}
+
+type fileProvider struct{}
+
+func (fileProvider) Scheme() string {
+	return SchemeFile
+}
+
+func (fileProvider) GetSecret(_ context.Context, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
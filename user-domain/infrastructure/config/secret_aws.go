@@ -0,0 +1,128 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+const (
+	SchemeAWSSecretsManager = "aws-secretsmanager"
+	SchemeAWSSSM            = "aws-ssm"
+)
+
+// awsConfig loads the shared AWS configuration on first use so that the
+// providers cost nothing when no secret refers to AWS.
+type awsConfig struct {
+	region   string
+	endpoint string
+	extra    []func(*awsconfig.LoadOptions) error
+
+	once sync.Once
+	cfg  aws.Config
+	err  error
+}
+
+func (c *awsConfig) load(ctx context.Context) (aws.Config, error) {
+	c.once.Do(func() {
+		opts := append([]func(*awsconfig.LoadOptions) error{}, c.extra...)
+		if c.region != "" {
+			opts = append(opts, awsconfig.WithRegion(c.region))
+		}
+		if c.endpoint != "" {
+			opts = append(opts, awsconfig.WithBaseEndpoint(c.endpoint))
+		}
+		c.cfg, c.err = awsconfig.LoadDefaultConfig(ctx, opts...)
+	})
+	return c.cfg, c.err
+}
+
+type awsSecretsManager struct {
+	aws    *awsConfig
+	once   sync.Once
+	client *secretsmanager.Client
+}
+
+// NewAWSSecretsManager resolves references of the form
+// "aws-secretsmanager:<secret-id>#<json-key>". The key is optional, without it
+// the whole secret string is returned. This matches the JSON "db-creds" secret
+// the terraform postgres module reads its username and password from.
+func NewAWSSecretsManager(cfg *Config, opts ...func(*awsconfig.LoadOptions) error) SecretProvider {
+	return &awsSecretsManager{aws: newAWSConfig(cfg, opts)}
+}
+
+func (p *awsSecretsManager) Scheme() string {
+	return SchemeAWSSecretsManager
+}
+
+func (p *awsSecretsManager) GetSecret(ctx context.Context, ref string) (string, error) {
+	awsCfg, err := p.aws.load(ctx)
+	if err != nil {
+		return "", err
+	}
+	p.once.Do(func() { p.client = secretsmanager.NewFromConfig(awsCfg) })
+
+	id, key, _ := strings.Cut(ref, "#")
+	out, err := p.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(id)})
+	if err != nil {
+		return "", err
+	}
+	secret := aws.ToString(out.SecretString)
+	if key == "" {
+		return secret, nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(secret), &fields); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object: %w", id, err)
+	}
+	v, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("secret %s has no key %q", id, key)
+	}
+	return fmt.Sprint(v), nil
+}
+
+type awsSSM struct {
+	aws    *awsConfig
+	once   sync.Once
+	client *ssm.Client
+}
+
+// NewAWSSSM resolves references of the form "aws-ssm:<parameter-name>",
+// SecureString parameters are decrypted.
+func NewAWSSSM(cfg *Config, opts ...func(*awsconfig.LoadOptions) error) SecretProvider {
+	return &awsSSM{aws: newAWSConfig(cfg, opts)}
+}
+
+func (p *awsSSM) Scheme() string {
+	return SchemeAWSSSM
+}
+
+func (p *awsSSM) GetSecret(ctx context.Context, ref string) (string, error) {
+	awsCfg, err := p.aws.load(ctx)
+	if err != nil {
+		return "", err
+	}
+	p.once.Do(func() { p.client = ssm.NewFromConfig(awsCfg) })
+
+	out, err := p.client.GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(ref),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(out.Parameter.Value), nil
+}
+
+func newAWSConfig(cfg *Config, opts []func(*awsconfig.LoadOptions) error) *awsConfig {
+	return &awsConfig{region: cfg.AWSRegion, endpoint: cfg.AWSEndpoint, extra: opts}
+}
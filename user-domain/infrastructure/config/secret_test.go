@@ -0,0 +1,128 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretResolverRefreshFile(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "db-password")
+	require.NoError(t, os.WriteFile(path, []byte("first\n"), 0o600))
+
+	env := withEnv(map[string]string{"SECRET_POSTGRES_PASSWORD_FILE": path})
+	l := newLoader(env, nil)
+	l.readFile = os.ReadFile
+	cfg, err := l.load(nil)
+	require.NoError(t, err)
+
+	r := NewSecretResolver()
+	require.NoError(t, r.Resolve(context.Background(), cfg))
+	require.Equal(t, "first", cfg.PostgresPassword)
+
+	var got []string
+	r.Subscribe(func(c *Config) { got = append(got, c.PostgresPassword) })
+
+	changed, err := r.Refresh(context.Background())
+	require.NoError(t, err)
+	require.False(t, changed)
+
+	require.NoError(t, os.WriteFile(path, []byte("rotated\n"), 0o600))
+	changed, err = r.Refresh(context.Background())
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, []string{"rotated"}, got)
+}
+
+func TestSecretResolverRefreshKeepsValuesOnError(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "db-password")
+	require.NoError(t, os.WriteFile(path, []byte("first"), 0o600))
+
+	cfg := &Config{PostgresPassword: SchemeFile + ":" + path}
+	r := NewSecretResolver()
+	require.NoError(t, r.Resolve(context.Background(), cfg))
+	require.Equal(t, "first", cfg.PostgresPassword)
+
+	require.NoError(t, os.Remove(path))
+	_, err := r.Refresh(context.Background())
+	require.ErrorContains(t, err, "resolve postgres.password from file")
+}
+
+// awsStandIn answers the two JSON-RPC calls the providers make the way
+// Secrets Manager and SSM do.
+func awsStandIn(t *testing.T, secrets, parameters map[string]string) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var in struct {
+			SecretId string
+			Name     string
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+
+		switch r.Header.Get("X-Amz-Target") {
+		case "secretsmanager.GetSecretValue":
+			v, ok := secrets[in.SecretId]
+			if !ok {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]string{"__type": "ResourceNotFoundException", "message": "not found"})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{"Name": in.SecretId, "SecretString": v})
+		case "AmazonSSM.GetParameter":
+			v, ok := parameters[in.Name]
+			if !ok {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]string{"__type": "ParameterNotFound", "message": "not found"})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"Parameter": map[string]string{"Name": in.Name, "Value": v}})
+		default:
+			w.WriteHeader(http.StatusNotImplemented)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestAWSProviders(t *testing.T) {
+	t.Parallel()
+	srv := awsStandIn(t,
+		map[string]string{"db-creds": `{"username":"app","password":"from-secrets-manager"}`},
+		map[string]string{"/user-domain/db-host": "db.internal"},
+	)
+	creds := awsconfig.WithCredentialsProvider(credentials.NewStaticCredentialsProvider("id", "secret", ""))
+	awsCfg := &Config{AWSRegion: "ap-southeast-1", AWSEndpoint: srv.URL}
+
+	cfg := &Config{
+		PostgresUser:     "aws-secretsmanager:db-creds#username",
+		PostgresPassword: "aws-secretsmanager:db-creds#password",
+		PostgresHost:     "aws-ssm:/user-domain/db-host",
+	}
+	r := NewSecretResolver(NewAWSSecretsManager(awsCfg, creds), NewAWSSSM(awsCfg, creds))
+	require.NoError(t, r.Resolve(context.Background(), cfg))
+	require.Equal(t, "app", cfg.PostgresUser)
+	require.Equal(t, "from-secrets-manager", cfg.PostgresPassword)
+	// Only secret fields are resolved.
+	require.Equal(t, "aws-ssm:/user-domain/db-host", cfg.PostgresHost)
+
+	p := NewAWSSSM(awsCfg, creds)
+	v, err := p.GetSecret(context.Background(), "/user-domain/db-host")
+	require.NoError(t, err)
+	require.Equal(t, "db.internal", v)
+
+	sm := NewAWSSecretsManager(awsCfg, creds)
+	_, err = sm.GetSecret(context.Background(), "db-creds#missing")
+	require.ErrorContains(t, err, `has no key "missing"`)
+	_, err = sm.GetSecret(context.Background(), "unknown")
+	require.Error(t, err)
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"user-domain/infrastructure/config"
+
+	"github.com/lib/pq"
+)
+
+type credentials struct {
+	user     string
+	password string
+}
+
+// Connector dials postgres with whatever credentials are current at dial
+// time, so a rotated password only affects connections opened after
+// SetCredentials while the established ones keep working.
+type Connector struct {
+	host    string
+	port    string
+	dbName  string
+	sslMode string
+	creds   *atomic.Pointer[credentials]
+}
+
+func NewConnector(cfg *config.Config) *Connector {
+	c := &Connector{
+		host:    cfg.PostgresHost,
+		port:    cfg.PostgresPort,
+		dbName:  cfg.PostgresDatabase,
+		sslMode: cfg.PostgresSSLMode,
+		creds:   &atomic.Pointer[credentials]{},
+	}
+	c.SetCredentials(cfg.PostgresUser, cfg.PostgresPassword)
+	return c
+}
+
+// WithAddress returns a connector for another server of the same cluster,
+// such as a read replica. Both share credentials, rotating them on one
+// rotates them on the other.
+func (c *Connector) WithAddress(host, port string) *Connector {
+	replica := *c
+	replica.host = host
+	replica.port = port
+	return &replica
+}
+
+func (c *Connector) SetCredentials(user, password string) {
+	c.creds.Store(&credentials{user: user, password: password})
+}
+
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	creds := c.creds.Load()
+	pc, err := pq.NewConnector(dsn(map[string]string{
+		"host":     c.host,
+		"port":     c.port,
+		"user":     creds.user,
+		"dbname":   c.dbName,
+		"password": creds.password,
+		"sslmode":  c.sslMode,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	return pc.Connect(ctx)
+}
+
+func (c *Connector) Driver() driver.Driver {
+	return &pq.Driver{}
+}
+
+func (c *Connector) String() string {
+	return fmt.Sprintf("%s:%s/%s", c.host, c.port, c.dbName)
+}
+
+// dsn builds a libpq keyword/value string, quoting every value so that
+// passwords with spaces or quotes survive.
+func dsn(params map[string]string) string {
+	quote := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	parts := make([]string, 0, len(params))
+	for _, k := range []string{"host", "port", "user", "dbname", "password", "sslmode"} {
+		if v := params[k]; v != "" {
+			parts = append(parts, fmt.Sprintf("%s='%s'", k, quote.Replace(v)))
+		}
+	}
+	return strings.Join(parts, " ")
+}
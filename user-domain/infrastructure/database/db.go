@@ -8,15 +8,19 @@ import (
 	"user-domain/infrastructure/config"
 	"user-domain/internal/application/outbound"
 
-	_ "github.com/lib/pq"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
+// QueryHook is called for every statement gorm executes, regardless of the
+// configured log level.
+type QueryHook func(ctx context.Context, sql string, elapsed time.Duration, err error)
+
 type gormLogger struct {
 	LogLevel logger.LogLevel
 	logger   outbound.Logger
+	hooks    []QueryHook
 }
 
 func (l *gormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
@@ -38,12 +42,18 @@ func (l *gormLogger) Error(ctx context.Context, msg string, data ...interface{})
 }
 
 func (l *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
-	if l.LogLevel <= logger.Silent {
+	if l.LogLevel <= logger.Silent && len(l.hooks) == 0 {
 		return
 	}
 
 	elapsed := time.Since(begin)
 	sql, rows := fc()
+	for _, hook := range l.hooks {
+		hook(ctx, sql, elapsed, err)
+	}
+	if l.LogLevel <= logger.Silent {
+		return
+	}
 	msg := fmt.Sprintf("elapsed=%v rows=%d sql=%s", elapsed, rows, sql)
 
 	switch {
@@ -64,33 +74,57 @@ func (l *gormLogger) LogMode(level logger.LogLevel) logger.Interface {
 }
 
 func NewDatabaseConection(cfg *config.Config) (*sql.DB, error) {
-	dsn := fmt.Sprintf("host=%s port=%s user=%s dbname=%s password=%s sslmode=%s", cfg.PostgresHost, cfg.PostgresPort, cfg.PostgresUser, cfg.PostgresDatabase, cfg.PostgresPassword, cfg.PostgresSSLMode)
-	conn, err := sql.Open("postgres", dsn)
-	if err != nil {
-		return nil, err
-	}
-	err = conn.Ping()
-	if err != nil {
-		println("ping err :", err.Error())
-	}
+	return Open(NewConnector(cfg))
+}
+
+// Open creates a connection pool on top of connector and checks that the
+// database is reachable.
+func Open(connector *Connector) (*sql.DB, error) {
+	conn := sql.OpenDB(connector)
 	conn.SetMaxOpenConns(10)
 	conn.SetMaxIdleConns(5)
 	conn.SetConnMaxLifetime(time.Hour * 24)
 	conn.SetConnMaxIdleTime(time.Hour * 12)
-	return conn, err
+	if err := conn.Ping(); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("ping database %s: %w", connector, err)
+	}
+	return conn, nil
+}
+
+// Connect opens a pool to the database cfg.DBDriver names.
+func Connect(cfg *config.Config) (*sql.DB, error) {
+	if cfg.DBDriver == DriverSQLite {
+		return OpenSQLite(cfg.SQLitePath)
+	}
+	return NewDatabaseConection(cfg)
 }
-func NewGorm(cfg *config.Config, l outbound.Logger) (*gorm.DB, error) {
-	conn, err := NewDatabaseConection(cfg)
+
+// NewGorm connects to the database cfg.DBDriver names.
+func NewGorm(cfg *config.Config, l outbound.Logger, hooks ...QueryHook) (*gorm.DB, error) {
+	conn, err := Connect(cfg)
 	if err != nil {
 		return nil, err
 	}
+	if cfg.DBDriver == DriverSQLite {
+		return NewSQLiteGormFromConn(conn, l, hooks...)
+	}
+	return NewGormFromConn(conn, l, hooks...)
+}
+
+func NewGormFromConn(conn *sql.DB, l outbound.Logger, hooks ...QueryHook) (*gorm.DB, error) {
 	psgConfig := postgres.Config{
 		Conn: conn,
 	}
-	logger := &gormLogger{
+	dialector := postgres.New(psgConfig)
+	return openGorm(dialector, &gorm.Config{}, l, hooks...)
+}
+
+func openGorm(dialector gorm.Dialector, cfg *gorm.Config, l outbound.Logger, hooks ...QueryHook) (*gorm.DB, error) {
+	cfg.Logger = &gormLogger{
 		LogLevel: logger.Info,
 		logger:   l,
+		hooks:    hooks,
 	}
-	dialector := postgres.New(psgConfig)
-	return gorm.Open(dialector, &gorm.Config{Logger: logger})
+	return gorm.Open(dialector, cfg)
 }
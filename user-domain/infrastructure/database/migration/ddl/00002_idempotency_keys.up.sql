@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS "idempotency_keys" (
+  "key" VARCHAR(255) NOT NULL,
+  "fingerprint" VARCHAR(64) NOT NULL,
+  "status" INTEGER NOT NULL DEFAULT 0,
+  "header" JSONB,
+  "body" BYTEA,
+  "completed_at" TIMESTAMP,
+  "locked_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "expires_at" TIMESTAMP NOT NULL,
+  PRIMARY KEY (key)
+);
+
+CREATE INDEX IF NOT EXISTS "idx_idempotency_keys_expires_at" ON "idempotency_keys" ("expires_at");
@@ -0,0 +1,36 @@
+-- Deleted users keep their email so that they can be restored, unless
+-- someone else has taken it since.
+--
+-- The index cannot be built while two live users share an email. Rather
+-- than pick which one to delete, the migration stops and says how many
+-- emails are shared, without naming them. Find them with
+--
+--   SELECT email, array_agg(id ORDER BY created_at) FROM users
+--   WHERE deleted_at IS NULL GROUP BY email HAVING count(*) > 1;
+--
+-- soft delete all but one user of each, here keeping the newest, with
+--
+--   UPDATE users SET deleted_at = now() WHERE id IN (
+--     SELECT id FROM (
+--       SELECT id, row_number() OVER (PARTITION BY email ORDER BY created_at DESC, id) AS n
+--       FROM users WHERE deleted_at IS NULL
+--     ) d WHERE n > 1
+--   );
+--
+-- then run migrate force 2 and migrate up again.
+DO $$
+DECLARE
+  shared BIGINT;
+BEGIN
+  SELECT count(*) INTO shared FROM (
+    SELECT 1 FROM "users" WHERE "deleted_at" IS NULL
+    GROUP BY "email" HAVING count(*) > 1
+  ) d;
+  IF shared > 0 THEN
+    RAISE EXCEPTION '% emails belong to more than one live user, keep one user of each', shared
+      USING HINT = 'see 00003_users_email_unique.up.sql for the cleanup';
+  END IF;
+END
+$$;
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_email" ON "users" ("email") WHERE "deleted_at" IS NULL;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "idx_personal_data_requests_user_id";
+
+DROP TABLE IF EXISTS "personal_data_requests";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "erased_at";
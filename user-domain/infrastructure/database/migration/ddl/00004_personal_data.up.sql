@@ -0,0 +1,14 @@
+-- Erasing a user anonymises the row rather than deleting it, so that rows
+-- referring to it stay valid; erased_at tells it apart from a plain delete
+-- and keeps it from being restored.
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "erased_at" TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS "personal_data_requests" (
+  "id" uuid NOT NULL DEFAULT uuid_generate_v4(),
+  "user_id" uuid NOT NULL REFERENCES "users" ("id"),
+  "kind" VARCHAR(16) NOT NULL,
+  "requested_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS "idx_personal_data_requests_user_id" ON "personal_data_requests" ("user_id");
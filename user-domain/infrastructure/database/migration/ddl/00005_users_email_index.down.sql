@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "idx_users_email_index";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "email_index";
+-- Fails while encrypted values too long for the old columns are stored.
+ALTER TABLE "users" ALTER COLUMN "email" TYPE VARCHAR(255), ALTER COLUMN "phone" TYPE VARCHAR(255);
@@ -0,0 +1,7 @@
+-- With encryption on, email and phone hold envelopes, which are longer than
+-- the plaintext and differ on every write. Lookups and uniqueness then go
+-- through email_index, a keyed hash of the email.
+ALTER TABLE "users" ALTER COLUMN "email" TYPE TEXT, ALTER COLUMN "phone" TYPE TEXT;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "email_index" VARCHAR(64);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_email_index" ON "users" ("email_index") WHERE "deleted_at" IS NULL;
@@ -0,0 +1,3 @@
+-- The request holding a key, so that one whose lock was taken over cannot
+-- store its response over, or release, the new owner's.
+ALTER TABLE "idempotency_keys" ADD COLUMN IF NOT EXISTS "owner" VARCHAR(36) NOT NULL DEFAULT '';
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS "users" (
+  "id" TEXT NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "deleted_at" TIMESTAMP,
+  "email" VARCHAR(255) NOT NULL,
+  "phone" VARCHAR(255),
+  "name" VARCHAR(255) NOT NULL,
+  PRIMARY KEY (id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_email" ON "users" ("email") WHERE "deleted_at" IS NULL;
@@ -0,0 +1,11 @@
+ALTER TABLE "users" ADD COLUMN "erased_at" TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS "personal_data_requests" (
+  "id" TEXT NOT NULL,
+  "user_id" TEXT NOT NULL REFERENCES "users" ("id"),
+  "kind" VARCHAR(16) NOT NULL,
+  "requested_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS "idx_personal_data_requests_user_id" ON "personal_data_requests" ("user_id");
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "idx_users_email_index";
+
+ALTER TABLE "users" DROP COLUMN "email_index";
@@ -0,0 +1,3 @@
+ALTER TABLE "users" ADD COLUMN "email_index" VARCHAR(64);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_email_index" ON "users" ("email_index") WHERE "deleted_at" IS NULL;
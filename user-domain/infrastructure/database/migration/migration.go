@@ -0,0 +1,254 @@
+// Package migration applies the schema migrations in its ddl directory, or
+// in any other golang-migrate source, to the postgres or sqlite database.
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// lockKey names the advisory lock every Migrator operation holds. It is
+// "userdom" in ASCII and must not change, or old and new binaries would stop
+// excluding each other.
+const lockKey int64 = 0x75736572646f6d
+
+// ErrNoVersion is returned by Version when no migration was ever applied.
+var ErrNoVersion = migrate.ErrNilVersion
+
+// ddl holds the golang-migrate files, <version>_<name>.<up|down>.sql, of
+// postgres in ddl and of sqlite in ddl/sqlite, so that binaries carry the
+// DDL they were built against.
+//
+//go:embed ddl/*.sql ddl/sqlite/*.sql
+var ddl embed.FS
+
+// Source opens the migrations at url, or the ones of driver, postgres or
+// sqlite, embedded in the binary if url is empty.
+func Source(driver, url string) (source.Driver, error) {
+	if url != "" {
+		return source.Open(url)
+	}
+	if driver == "sqlite" {
+		return iofs.New(ddl, "ddl/sqlite")
+	}
+	return iofs.New(ddl, "ddl")
+}
+
+// Status describes one migration of the source.
+type Status struct {
+	Version uint
+	Name    string
+	Applied bool
+	// Dirty is set on the current version if applying it failed halfway.
+	Dirty bool
+}
+
+// Migrator runs migrations. On postgres it uses a connection of its own and
+// every operation holds a session-level advisory lock for its whole
+// duration, so that instances started at the same time wait for each other
+// instead of failing, and so that Force cannot interleave with a running
+// migration. A sqlite database has a single user, the service on its host,
+// and is not locked beyond what sqlite does itself.
+type Migrator struct {
+	conn        *sql.Conn
+	src         source.Driver
+	m           *migrate.Migrate
+	lockTimeout time.Duration
+}
+
+// New opens the migrations at sourceURL, see Source, for db, a database of
+// driver. lockTimeout bounds how long an operation waits for another one to
+// finish. Close does not close db.
+func New(ctx context.Context, db *sql.DB, driver, sourceURL string, lockTimeout time.Duration) (*Migrator, error) {
+	src, err := Source(driver, sourceURL)
+	if err != nil {
+		return nil, fmt.Errorf("migration: open source: %w", err)
+	}
+	var conn *sql.Conn
+	var instance database.Driver
+	if driver == "sqlite" {
+		instance, err = newSQLiteDriver(db)
+	} else {
+		conn, err = db.Conn(ctx)
+		if err != nil {
+			_ = src.Close()
+			return nil, err
+		}
+		instance, err = postgres.WithConnection(ctx, conn, &postgres.Config{})
+	}
+	if err != nil {
+		_ = src.Close()
+		if conn != nil {
+			_ = conn.Close()
+		}
+		return nil, fmt.Errorf("migration: open database: %w", err)
+	}
+	m, err := migrate.NewWithInstance("ddl", src, driver, instance)
+	if err != nil {
+		_ = src.Close()
+		if conn != nil {
+			_ = conn.Close()
+		}
+		return nil, err
+	}
+	m.LockTimeout = lockTimeout
+	return &Migrator{conn: conn, src: src, m: m, lockTimeout: lockTimeout}, nil
+}
+
+// Up applies every pending migration.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.run(ctx, m.m.Up)
+}
+
+// Down reverts every applied migration.
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.run(ctx, m.m.Down)
+}
+
+// Steps applies the next n migrations, or reverts the last -n if n is
+// negative.
+func (m *Migrator) Steps(ctx context.Context, n int) error {
+	return m.run(ctx, func() error { return m.m.Steps(n) })
+}
+
+// Goto migrates up or down to version.
+func (m *Migrator) Goto(ctx context.Context, version uint) error {
+	return m.run(ctx, func() error { return m.m.Migrate(version) })
+}
+
+// Force records version as applied and clears the dirty flag without running
+// anything, after a failed migration was repaired by hand. A version of -1
+// means that nothing is applied.
+func (m *Migrator) Force(ctx context.Context, version int) error {
+	return m.run(ctx, func() error { return m.m.Force(version) })
+}
+
+// Version returns the current version and whether applying it failed
+// halfway, or ErrNoVersion.
+func (m *Migrator) Version(ctx context.Context) (version uint, dirty bool, err error) {
+	err = m.run(ctx, func() error {
+		version, dirty, err = m.m.Version()
+		return err
+	})
+	return version, dirty, err
+}
+
+// Status lists every migration of the source, oldest first.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+	err := m.run(ctx, func() error {
+		current, dirty, err := m.m.Version()
+		applied := err == nil
+		if err != nil && !errors.Is(err, ErrNoVersion) {
+			return err
+		}
+		statuses, err = list(m.src, current, dirty, applied)
+		return err
+	})
+	return statuses, err
+}
+
+// Close releases the connection and the source.
+func (m *Migrator) Close() error {
+	// Not m.m.Close, the postgres driver would close the connection a
+	// second time.
+	err := m.src.Close()
+	if m.conn != nil {
+		err = errors.Join(err, m.conn.Close())
+	}
+	return err
+}
+
+func (m *Migrator) run(ctx context.Context, fn func() error) error {
+	lock := func(fn func() error) error {
+		if m.conn == nil {
+			return fn()
+		}
+		return withLock(ctx, m.conn, m.lockTimeout, fn)
+	}
+	return lock(func() error {
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			select {
+			case <-ctx.Done():
+				// Lets the running migration finish, then stops. The
+				// channel holds one request, a pending one is enough.
+				select {
+				case m.m.GracefulStop <- true:
+				default:
+				}
+			case <-done:
+			}
+		}()
+		err := fn()
+		if errors.Is(err, migrate.ErrNoChange) {
+			return nil
+		}
+		if err == nil {
+			err = ctx.Err()
+		}
+		return err
+	})
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func withLock(ctx context.Context, conn execer, timeout time.Duration, fn func() error) error {
+	lockCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if _, err := conn.ExecContext(lockCtx, `SELECT pg_advisory_lock($1)`, lockKey); err != nil {
+		if lockCtx.Err() != nil && ctx.Err() == nil {
+			return fmt.Errorf("migration: another migration held the lock for more than %s", timeout)
+		}
+		return fmt.Errorf("migration: take lock: %w", err)
+	}
+	fnErr := fn()
+	// The lock is released even if ctx is done, the connection goes back to
+	// the pool.
+	_, err := conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, lockKey)
+	if err != nil {
+		err = fmt.Errorf("migration: release lock: %w", err)
+	}
+	return errors.Join(fnErr, err)
+}
+
+func list(src source.Driver, current uint, dirty, applied bool) ([]Status, error) {
+	var statuses []Status
+	version, err := src.First()
+	for err == nil {
+		var r io.ReadCloser
+		var name string
+		r, name, err = src.ReadUp(version)
+		if err != nil {
+			return nil, err
+		}
+		_ = r.Close()
+		statuses = append(statuses, Status{
+			Version: version,
+			Name:    name,
+			Applied: applied && version <= current,
+			Dirty:   applied && dirty && version == current,
+		})
+		version, err = src.Next(version)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return statuses, nil
+}
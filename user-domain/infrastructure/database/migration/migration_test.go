@@ -0,0 +1,160 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/glebarez/sqlite"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedSource(t *testing.T) {
+	t.Parallel()
+	for _, driver := range []string{"postgres", "sqlite"} {
+		src, err := Source(driver, "")
+		require.NoError(t, err)
+		defer src.Close()
+
+		statuses, err := list(src, 0, false, false)
+		require.NoError(t, err)
+		require.NotEmpty(t, statuses, driver)
+		for _, s := range statuses {
+			// Every migration must be reversible for down and goto.
+			r, _, err := src.ReadDown(s.Version)
+			require.NoError(t, err, "%s version %d has no down migration", driver, s.Version)
+			_ = r.Close()
+		}
+	}
+}
+
+// TestSQLite runs the embedded sqlite migrations all the way up and down.
+func TestSQLite(t *testing.T) {
+	t.Parallel()
+	db, err := sql.Open(sqlite.DriverName, ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+	ctx := t.Context()
+	m, err := New(ctx, db, "sqlite", "", time.Second)
+	require.NoError(t, err)
+	defer m.Close()
+
+	_, _, err = m.Version(ctx)
+	require.ErrorIs(t, err, ErrNoVersion)
+	require.NoError(t, m.Up(ctx))
+	version, dirty, err := m.Version(ctx)
+	require.NoError(t, err)
+	require.False(t, dirty)
+	statuses, err := m.Status(ctx)
+	require.NoError(t, err)
+	require.Equal(t, statuses[len(statuses)-1].Version, version)
+	_, err = db.ExecContext(ctx, `SELECT count(*) FROM users`)
+	require.NoError(t, err)
+
+	require.NoError(t, m.Steps(ctx, -1))
+	previous, _, err := m.Version(ctx)
+	require.NoError(t, err)
+	require.Less(t, previous, version)
+	require.NoError(t, m.Force(ctx, int(version)))
+	again, _, err := m.Version(ctx)
+	require.NoError(t, err)
+	require.Equal(t, version, again)
+
+	require.NoError(t, m.Force(ctx, int(previous)))
+	require.NoError(t, m.Down(ctx))
+	_, _, err = m.Version(ctx)
+	require.ErrorIs(t, err, ErrNoVersion)
+	_, err = db.ExecContext(ctx, `SELECT count(*) FROM users`)
+	require.Error(t, err)
+}
+
+func TestList(t *testing.T) {
+	t.Parallel()
+	src, err := iofs.New(fstest.MapFS{
+		"1_users.up.sql":   {Data: []byte("CREATE TABLE users ()")},
+		"1_users.down.sql": {Data: []byte("DROP TABLE users")},
+		"2_keys.up.sql":    {Data: []byte("CREATE TABLE keys ()")},
+		"5_index.up.sql":   {Data: []byte("CREATE INDEX i ON keys ()")},
+	}, ".")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		current uint
+		dirty   bool
+		applied bool
+		want    []Status
+	}{
+		{
+			name: "never migrated",
+			want: []Status{{Version: 1, Name: "users"}, {Version: 2, Name: "keys"}, {Version: 5, Name: "index"}},
+		},
+		{
+			name:    "partly applied",
+			current: 2,
+			applied: true,
+			want:    []Status{{Version: 1, Name: "users", Applied: true}, {Version: 2, Name: "keys", Applied: true}, {Version: 5, Name: "index"}},
+		},
+		{
+			name:    "dirty",
+			current: 5,
+			dirty:   true,
+			applied: true,
+			want: []Status{
+				{Version: 1, Name: "users", Applied: true},
+				{Version: 2, Name: "keys", Applied: true},
+				{Version: 5, Name: "index", Applied: true, Dirty: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := list(src, tt.current, tt.dirty, tt.applied)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWithLock(t *testing.T) {
+	t.Parallel()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec(`SELECT pg_advisory_lock\(\$1\)`).WithArgs(lockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`SELECT pg_advisory_unlock\(\$1\)`).WithArgs(lockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+	ran := false
+	require.NoError(t, withLock(t.Context(), db, time.Second, func() error {
+		ran = true
+		return nil
+	}))
+	require.True(t, ran)
+
+	// The lock is released when the migration fails.
+	boom := errors.New("boom")
+	mock.ExpectExec(`SELECT pg_advisory_lock\(\$1\)`).WithArgs(lockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`SELECT pg_advisory_unlock\(\$1\)`).WithArgs(lockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+	require.ErrorIs(t, withLock(t.Context(), db, time.Second, func() error { return boom }), boom)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWithLockTimeout(t *testing.T) {
+	t.Parallel()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec(`SELECT pg_advisory_lock\(\$1\)`).WithArgs(lockKey).WillDelayFor(time.Second).WillReturnResult(sqlmock.NewResult(0, 0))
+	err = withLock(context.Background(), db, 10*time.Millisecond, func() error {
+		t.Fatal("ran without the lock")
+		return nil
+	})
+	require.ErrorContains(t, err, "another migration held the lock")
+}
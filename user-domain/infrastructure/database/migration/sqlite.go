@@ -0,0 +1,137 @@
+package migration
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// sqliteTable keeps the version in the table golang-migrate's own sqlite
+// drivers use, so databases they migrated carry on where they stopped.
+const sqliteTable = "schema_migrations"
+
+// sqliteDriver is the golang-migrate database driver of sqlite. Those of
+// golang-migrate either need cgo or register modernc.org/sqlite under the
+// same database/sql name as the driver database.OpenSQLite uses, so this
+// one runs on the caller's *sql.DB whatever its driver. Each migration runs
+// in a transaction.
+type sqliteDriver struct {
+	db     *sql.DB
+	locked atomic.Bool
+}
+
+func newSQLiteDriver(db *sql.DB) (*sqliteDriver, error) {
+	if err := db.Ping(); err != nil {
+		return nil, err
+	}
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS ` + sqliteTable + ` (version uint64, dirty bool);
+CREATE UNIQUE INDEX IF NOT EXISTS version_unique ON ` + sqliteTable + ` (version);`)
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteDriver{db: db}, nil
+}
+
+// Open is not supported, Migrator opens drivers on a *sql.DB.
+func (d *sqliteDriver) Open(string) (database.Driver, error) {
+	return nil, errors.New("migration: sqlite databases are opened by database.OpenSQLite")
+}
+
+// Close does nothing, the *sql.DB belongs to the caller.
+func (d *sqliteDriver) Close() error {
+	return nil
+}
+
+func (d *sqliteDriver) Lock() error {
+	if !d.locked.CompareAndSwap(false, true) {
+		return database.ErrLocked
+	}
+	return nil
+}
+
+func (d *sqliteDriver) Unlock() error {
+	if !d.locked.CompareAndSwap(true, false) {
+		return database.ErrNotLocked
+	}
+	return nil
+}
+
+func (d *sqliteDriver) Run(migration io.Reader) error {
+	query, err := io.ReadAll(migration)
+	if err != nil {
+		return err
+	}
+	return d.inTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(string(query)); err != nil {
+			return &database.Error{OrigErr: err, Query: query}
+		}
+		return nil
+	})
+}
+
+func (d *sqliteDriver) SetVersion(version int, dirty bool) error {
+	return d.inTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM ` + sqliteTable); err != nil {
+			return err
+		}
+		// A dirty nil version is kept, it is what a failed down migration of
+		// the first version leaves.
+		if version < 0 && (version != database.NilVersion || !dirty) {
+			return nil
+		}
+		_, err := tx.Exec(`INSERT INTO `+sqliteTable+` (version, dirty) VALUES (?, ?)`, version, dirty)
+		return err
+	})
+}
+
+func (d *sqliteDriver) Version() (version int, dirty bool, err error) {
+	err = d.db.QueryRow(`SELECT version, dirty FROM ` + sqliteTable + ` LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.NilVersion, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return version, dirty, nil
+}
+
+func (d *sqliteDriver) Drop() error {
+	rows, err := d.db.Query(`SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'`)
+	if err != nil {
+		return err
+	}
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		tables = append(tables, name)
+	}
+	// Read to the end before dropping, the pool may hold one connection.
+	if err := errors.Join(rows.Err(), rows.Close()); err != nil {
+		return err
+	}
+	for _, name := range tables {
+		if _, err := d.db.Exec(fmt.Sprintf(`DROP TABLE %q`, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *sqliteDriver) inTx(fn func(tx *sql.Tx) error) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		return errors.Join(err, tx.Rollback())
+	}
+	return tx.Commit()
+}
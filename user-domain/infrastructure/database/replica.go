@@ -0,0 +1,253 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"user-domain/infrastructure/config"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// ReplicaConnectors returns a connector for every host in
+// cfg.PostgresReplicas. Replicas without a port use the primary's.
+func ReplicaConnectors(cfg *config.Config, primary *Connector) ([]*Connector, error) {
+	var connectors []*Connector
+	for _, addr := range strings.Split(cfg.PostgresReplicas, ",") {
+		addr = strings.TrimSpace(addr)
+		if addr == "" {
+			continue
+		}
+		host, port := addr, cfg.PostgresPort
+		if h, p, err := net.SplitHostPort(addr); err == nil {
+			host, port = h, p
+		}
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return nil, fmt.Errorf("replica %q: invalid port %q", addr, port)
+		}
+		connectors = append(connectors, primary.WithAddress(host, port))
+	}
+	return connectors, nil
+}
+
+type sessionKey struct{}
+
+type primaryKey struct{}
+
+// session tracks, for one request, the last write position the client has
+// seen and whether the request itself wrote anything.
+type session struct {
+	minLSN LSN
+	wrote  atomic.Bool
+}
+
+// ReadYourWrites sends reads to the replicas through dbresolver and writes to
+// the primary. A request that carries the position of an earlier write is
+// served by the primary until every replica has replayed that position, so a
+// client never reads data older than its own last write.
+type ReadYourWrites struct {
+	primary  *sql.DB
+	replicas []*sql.DB
+
+	// replayed is the lowest position every replica is known to have
+	// replayed. It only moves forward and saves a round trip to the replicas
+	// for requests that are already covered.
+	replayed atomic.Uint64
+	// written is the highest write position of the primary seen so far.
+	written atomic.Uint64
+	mu      sync.Mutex
+}
+
+// UseReplicas registers dbresolver on db with the given replica pools and
+// returns the read-your-writes tracker the HTTP layer hands positions to.
+func UseReplicas(db *gorm.DB, replicas []*sql.DB) (*ReadYourWrites, error) {
+	primary, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	dialectors := make([]gorm.Dialector, 0, len(replicas))
+	for _, conn := range replicas {
+		dialectors = append(dialectors, postgres.New(postgres.Config{Conn: conn}))
+	}
+	// Both plugins hook in before everything else, the tracker has to be
+	// registered first to pin a read before dbresolver picks a replica.
+	rw := &ReadYourWrites{primary: primary, replicas: replicas}
+	if err := db.Use(rw); err != nil {
+		return nil, err
+	}
+	if err := db.Use(dbresolver.Register(dbresolver.Config{
+		Replicas: dialectors,
+		Policy:   dbresolver.RandomPolicy{},
+	})); err != nil {
+		return nil, err
+	}
+	return rw, nil
+}
+
+func (rw *ReadYourWrites) Name() string {
+	return "read_your_writes"
+}
+
+func (rw *ReadYourWrites) Initialize(db *gorm.DB) error {
+	return errors.Join(
+		db.Callback().Query().Before("*").Register("read_your_writes:query", rw.pinReads),
+		db.Callback().Row().Before("*").Register("read_your_writes:row", rw.pinReads),
+		db.Callback().Create().After("*").Register("read_your_writes:create", rw.recordWrite),
+		db.Callback().Update().After("*").Register("read_your_writes:update", rw.recordWrite),
+		db.Callback().Delete().After("*").Register("read_your_writes:delete", rw.recordWrite),
+	)
+}
+
+// Begin starts a session for one request. lastWrite is the position the
+// client received after its previous write, an empty or malformed value
+// starts a session without constraints. The client is not trusted with it:
+// a position past the primary's is lowered to the primary's, so that
+// nobody can keep every read on the primary by sending one no replica will
+// ever reach.
+func (rw *ReadYourWrites) Begin(ctx context.Context, lastWrite string) context.Context {
+	s := &session{}
+	if lsn, err := ParseLSN(lastWrite); err == nil {
+		s.minLSN = rw.bound(ctx, lsn)
+	}
+	return context.WithValue(ctx, sessionKey{}, s)
+}
+
+// bound returns lsn, or the primary's current position if that is lower.
+// If the primary cannot be asked lsn is kept, a read it then pins to the
+// primary fails or is served correctly either way.
+func (rw *ReadYourWrites) bound(ctx context.Context, lsn LSN) LSN {
+	if uint64(lsn) <= rw.replayed.Load() || uint64(lsn) <= rw.written.Load() {
+		return lsn
+	}
+	current, err := rw.primaryPosition(ctx)
+	if err != nil {
+		return lsn
+	}
+	return min(lsn, current)
+}
+
+// primaryPosition returns the current write position of the primary.
+func (rw *ReadYourWrites) primaryPosition(ctx context.Context) (LSN, error) {
+	var text string
+	if err := rw.primary.QueryRowContext(ctx, "SELECT pg_current_wal_lsn()::text").Scan(&text); err != nil {
+		return 0, fmt.Errorf("read primary wal position: %w", err)
+	}
+	lsn, err := ParseLSN(text)
+	if err != nil {
+		return 0, err
+	}
+	for {
+		written := rw.written.Load()
+		if uint64(lsn) <= written || rw.written.CompareAndSwap(written, uint64(lsn)) {
+			return lsn, nil
+		}
+	}
+}
+
+// LastWrite returns the current write position of the primary if the
+// request behind ctx wrote anything. It is meant to be called once the
+// request's transaction has committed.
+func (rw *ReadYourWrites) LastWrite(ctx context.Context) (string, bool, error) {
+	s, ok := ctx.Value(sessionKey{}).(*session)
+	if !ok || !s.wrote.Load() {
+		return "", false, nil
+	}
+	lsn, err := rw.primaryPosition(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	return lsn.String(), true, nil
+}
+
+// Primary returns ctx with every read made with it served by the primary,
+// for callers that keep what they read, such as a cache.
+func (rw *ReadYourWrites) Primary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+func (rw *ReadYourWrites) pinReads(db *gorm.DB) {
+	if primary, _ := db.Statement.Context.Value(primaryKey{}).(bool); primary {
+		dbresolver.Write.ModifyStatement(db.Statement)
+		return
+	}
+	s, ok := db.Statement.Context.Value(sessionKey{}).(*session)
+	if !ok || s.minLSN == 0 {
+		return
+	}
+	if !rw.caughtUp(db.Statement.Context, s.minLSN) {
+		dbresolver.Write.ModifyStatement(db.Statement)
+	}
+}
+
+func (rw *ReadYourWrites) recordWrite(db *gorm.DB) {
+	if db.Error != nil || db.RowsAffected == 0 {
+		return
+	}
+	if s, ok := db.Statement.Context.Value(sessionKey{}).(*session); ok {
+		s.wrote.Store(true)
+	}
+}
+
+// caughtUp reports whether every replica has replayed lsn. Replicas that
+// cannot be asked count as behind.
+func (rw *ReadYourWrites) caughtUp(ctx context.Context, lsn LSN) bool {
+	if uint64(lsn) <= rw.replayed.Load() {
+		return true
+	}
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	if uint64(lsn) <= rw.replayed.Load() {
+		return true
+	}
+
+	lowest := LSN(0)
+	for i, conn := range rw.replicas {
+		var replay sql.NullString
+		if err := conn.QueryRowContext(ctx, "SELECT pg_last_wal_replay_lsn()::text").Scan(&replay); err != nil || !replay.Valid {
+			return false
+		}
+		current, err := ParseLSN(replay.String)
+		if err != nil {
+			return false
+		}
+		if i == 0 || current < lowest {
+			lowest = current
+		}
+	}
+	if uint64(lowest) > rw.replayed.Load() {
+		rw.replayed.Store(uint64(lowest))
+	}
+	return lsn <= lowest
+}
+
+// LSN is a postgres write-ahead log position.
+type LSN uint64
+
+// ParseLSN parses the textual "XXXXXXXX/XXXXXXXX" form postgres uses.
+func ParseLSN(s string) (LSN, error) {
+	hi, lo, ok := strings.Cut(s, "/")
+	if !ok {
+		return 0, fmt.Errorf("invalid lsn %q", s)
+	}
+	h, err := strconv.ParseUint(hi, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid lsn %q", s)
+	}
+	l, err := strconv.ParseUint(lo, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid lsn %q", s)
+	}
+	return LSN(h<<32 | l), nil
+}
+
+func (l LSN) String() string {
+	return fmt.Sprintf("%X/%X", uint64(l)>>32, uint64(l)&0xFFFFFFFF)
+}
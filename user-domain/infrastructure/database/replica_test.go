@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"user-domain/infrastructure/config"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type user struct {
+	ID   string
+	Name string
+}
+
+func newReplicatedGorm(t *testing.T) (*gorm.DB, *ReadYourWrites, sqlmock.Sqlmock, sqlmock.Sqlmock) {
+	primary, primaryMock, err := sqlmock.New()
+	require.NoError(t, err)
+	replica, replicaMock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, primaryMock.ExpectationsWereMet())
+		require.NoError(t, replicaMock.ExpectationsWereMet())
+	})
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: primary}), &gorm.Config{})
+	require.NoError(t, err)
+	rw, err := UseReplicas(db, []*sql.DB{replica})
+	require.NoError(t, err)
+	return db, rw, primaryMock, replicaMock
+}
+
+func TestReadsGoToReplica(t *testing.T) {
+	db, rw, _, replicaMock := newReplicatedGorm(t)
+	replicaMock.ExpectQuery(`SELECT \* FROM "users"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow("1", "An"))
+
+	ctx := rw.Begin(context.Background(), "")
+	var u user
+	require.NoError(t, db.WithContext(ctx).First(&u).Error)
+	require.Equal(t, "An", u.Name)
+}
+
+func TestWriteReturnsPosition(t *testing.T) {
+	db, rw, primaryMock, _ := newReplicatedGorm(t)
+	primaryMock.ExpectBegin()
+	primaryMock.ExpectExec(`UPDATE "users"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	primaryMock.ExpectCommit()
+	primaryMock.ExpectQuery(`pg_current_wal_lsn`).
+		WillReturnRows(sqlmock.NewRows([]string{"lsn"}).AddRow("0/3000148"))
+
+	ctx := rw.Begin(context.Background(), "")
+	_, ok, err := rw.LastWrite(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, db.WithContext(ctx).Model(&user{ID: "1"}).Update("name", "Binh").Error)
+	lsn, ok, err := rw.LastWrite(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "0/3000148", lsn)
+}
+
+func TestReadAfterWriteStaysOnPrimaryUntilReplicaCatchesUp(t *testing.T) {
+	db, rw, primaryMock, replicaMock := newReplicatedGorm(t)
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "name"}).AddRow("1", "Binh")
+	}
+
+	// The replica is behind, the read is served by the primary.
+	primaryMock.ExpectQuery(`pg_current_wal_lsn`).
+		WillReturnRows(sqlmock.NewRows([]string{"lsn"}).AddRow("0/3000148"))
+	replicaMock.ExpectQuery(`pg_last_wal_replay_lsn`).
+		WillReturnRows(sqlmock.NewRows([]string{"lsn"}).AddRow("0/3000000"))
+	primaryMock.ExpectQuery(`SELECT \* FROM "users"`).WillReturnRows(rows())
+	var u user
+	ctx := rw.Begin(context.Background(), "0/3000148")
+	require.NoError(t, db.WithContext(ctx).First(&u).Error)
+
+	// Once it has caught up reads go back to the replica, and positions it
+	// is known to have passed do not ask again.
+	replicaMock.ExpectQuery(`pg_last_wal_replay_lsn`).
+		WillReturnRows(sqlmock.NewRows([]string{"lsn"}).AddRow("0/3000200"))
+	replicaMock.ExpectQuery(`SELECT \* FROM "users"`).WillReturnRows(rows())
+	replicaMock.ExpectQuery(`SELECT \* FROM "users"`).WillReturnRows(rows())
+	require.NoError(t, db.WithContext(ctx).First(&u).Error)
+	ctx = rw.Begin(context.Background(), "0/3000100")
+	require.NoError(t, db.WithContext(ctx).First(&u).Error)
+}
+
+func TestPositionsPastThePrimaryAreLowered(t *testing.T) {
+	db, rw, primaryMock, replicaMock := newReplicatedGorm(t)
+	primaryMock.ExpectQuery(`pg_current_wal_lsn`).
+		WillReturnRows(sqlmock.NewRows([]string{"lsn"}).AddRow("0/3000148"))
+	replicaMock.ExpectQuery(`pg_last_wal_replay_lsn`).
+		WillReturnRows(sqlmock.NewRows([]string{"lsn"}).AddRow("0/3000148"))
+	replicaMock.ExpectQuery(`SELECT \* FROM "users"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow("1", "An"))
+
+	// A forged position the replica will never reach does not keep the
+	// read on the primary.
+	ctx := rw.Begin(context.Background(), "FFFFFFFF/FFFFFFFF")
+	var u user
+	require.NoError(t, db.WithContext(ctx).First(&u).Error)
+}
+
+func TestPrimaryReadsSkipTheReplica(t *testing.T) {
+	db, rw, primaryMock, _ := newReplicatedGorm(t)
+	primaryMock.ExpectQuery(`SELECT \* FROM "users"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow("1", "An"))
+
+	var u user
+	require.NoError(t, db.WithContext(rw.Primary(context.Background())).First(&u).Error)
+}
+
+func TestParseLSN(t *testing.T) {
+	t.Parallel()
+	lsn, err := ParseLSN("16/B374D848")
+	require.NoError(t, err)
+	require.Equal(t, LSN(0x16B374D848), lsn)
+	require.Equal(t, "16/B374D848", lsn.String())
+
+	for _, bad := range []string{"", "16", "x/1", "1/100000000"} {
+		_, err := ParseLSN(bad)
+		require.Error(t, err, bad)
+	}
+}
+
+func TestReplicaConnectors(t *testing.T) {
+	t.Parallel()
+	cfg := &config.Config{
+		PostgresHost:     "primary",
+		PostgresPort:     "5432",
+		PostgresDatabase: "users",
+		PostgresReplicas: "replica-a, replica-b:6432,",
+	}
+	connectors, err := ReplicaConnectors(cfg, NewConnector(cfg))
+	require.NoError(t, err)
+	require.Len(t, connectors, 2)
+	require.Equal(t, "replica-a:5432/users", connectors[0].String())
+	require.Equal(t, "replica-b:6432/users", connectors[1].String())
+
+	cfg.PostgresReplicas = "replica-c:0"
+	_, err = ReplicaConnectors(cfg, NewConnector(cfg))
+	require.Error(t, err)
+}
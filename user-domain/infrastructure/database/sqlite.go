@@ -0,0 +1,51 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"user-domain/internal/application/outbound"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// Drivers of config.Config.DBDriver.
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// OpenSQLite opens, and creates if need be, the sqlite database at path.
+// SQLite has a single writer, so the pool has a single connection: callers
+// queue in Go instead of failing with SQLITE_BUSY. That also keeps a
+// :memory: database, which lives and dies with its connection, in one
+// piece. The driver is pure Go, binaries build with CGO_ENABLED=0.
+func OpenSQLite(path string) (*sql.DB, error) {
+	q := url.Values{}
+	q.Add("_pragma", "foreign_keys(1)")
+	q.Add("_pragma", "busy_timeout(5000)")
+	q.Add("_pragma", "journal_mode(WAL)")
+	// Times are written the way sqlite's own date functions read them.
+	q.Set("_time_format", "sqlite")
+	conn, err := sql.Open(sqlite.DriverName, "file:"+path+"?"+q.Encode())
+	if err != nil {
+		return nil, err
+	}
+	conn.SetMaxOpenConns(1)
+	conn.SetConnMaxLifetime(0)
+	conn.SetConnMaxIdleTime(0)
+	if err := conn.Ping(); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("open sqlite database %s: %w", path, err)
+	}
+	return conn, nil
+}
+
+// NewSQLiteGormFromConn is NewGormFromConn for a database opened by
+// OpenSQLite.
+func NewSQLiteGormFromConn(conn *sql.DB, l outbound.Logger, hooks ...QueryHook) (*gorm.DB, error) {
+	// The sqlite dialector reports unique violations as
+	// gorm.ErrDuplicatedKey only when asked to translate errors.
+	return openGorm(&sqlite.Dialector{Conn: conn}, &gorm.Config{TranslateError: true}, l, hooks...)
+}
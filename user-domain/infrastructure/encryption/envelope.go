@@ -0,0 +1,223 @@
+// Package encryption encrypts personal data before it is stored, with
+// AES-GCM data keys that are themselves stored wrapped by a master key.
+package encryption
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"user-domain/infrastructure/config"
+)
+
+const (
+	keySize     = 32
+	maxKeyIDLen = 255
+	// prefix starts every encrypted value; values without it were stored
+	// before encryption was turned on and are read as they are.
+	prefix = "enc:v1:"
+	// maxUses bounds how many values a data key encrypts, well below where
+	// random GCM nonces could collide.
+	maxUses = 1 << 20
+	// maxOpened bounds the cache of unwrapped data keys.
+	maxOpened = 1024
+)
+
+var errCorrupt = errors.New("encrypted value is corrupt")
+
+// Envelope encrypts values with a data key it wraps with the active master
+// key and stores alongside, so that a value can be decrypted on its own and
+// master keys can be rotated without touching data keys in use. It also
+// computes the blind index under which a value can be looked up. It is safe
+// for concurrent use.
+type Envelope struct {
+	kms      KeyWrapper
+	indexKey []byte
+
+	mu      sync.Mutex
+	current *dataKey
+	opened  map[string]cipher.AEAD
+}
+
+type dataKey struct {
+	keyID   string
+	wrapped []byte
+	aead    cipher.AEAD
+	uses    int
+}
+
+// New returns an Envelope wrapping data keys with kms and keying blind
+// indexes with indexKey. Changing indexKey makes every stored index stale.
+func New(kms KeyWrapper, indexKey []byte) *Envelope {
+	return &Envelope{kms: kms, indexKey: indexKey, opened: map[string]cipher.AEAD{}}
+}
+
+// FromConfig returns the Envelope cfg configures, or nil if it configures
+// no master key and personal data is to be stored in plaintext.
+func FromConfig(cfg *config.Config) (*Envelope, error) {
+	if cfg.EncryptionMasterKeys == "" {
+		return nil, nil
+	}
+	kms, err := NewLocalKMS(cfg.EncryptionMasterKeys, cfg.EncryptionActiveKey)
+	if err != nil {
+		return nil, fmt.Errorf("encryption.master_keys: %w", err)
+	}
+	indexKey, err := base64.StdEncoding.DecodeString(cfg.EncryptionIndexKey)
+	if err != nil || len(indexKey) < keySize {
+		return nil, fmt.Errorf("encryption.index_key must be at least %d base64-encoded bytes", keySize)
+	}
+	return New(kms, indexKey), nil
+}
+
+// Encrypt encrypts plaintext, binding it to aad, which Decrypt must be given
+// too. Empty values are left empty.
+func (e *Envelope) Encrypt(ctx context.Context, plaintext, aad string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	key, err := e.dataKey(ctx)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := seal(key.aead, []byte(plaintext), []byte(aad))
+	if err != nil {
+		return "", err
+	}
+	buf := make([]byte, 0, 1+len(key.keyID)+2+len(key.wrapped)+len(sealed))
+	buf = append(buf, byte(len(key.keyID)))
+	buf = append(buf, key.keyID...)
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(key.wrapped)))
+	buf = append(buf, key.wrapped...)
+	buf = append(buf, sealed...)
+	return prefix + base64.RawStdEncoding.EncodeToString(buf), nil
+}
+
+// Decrypt returns the plaintext of a value Encrypt returned for the same
+// aad. Values that are not encrypted are returned as they are.
+func (e *Envelope) Decrypt(ctx context.Context, value, aad string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	keyID, wrapped, sealed, err := split(value)
+	if err != nil {
+		return "", err
+	}
+	aead, err := e.open(ctx, keyID, wrapped)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(aead, sealed, []byte(aad))
+	if err != nil {
+		return "", fmt.Errorf("decrypt value: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// Index returns the blind index of value: a keyed hash that lets rows be
+// found by, and kept unique on, a value they store encrypted. Empty values
+// have an empty index.
+func (e *Envelope) Index(value string) string {
+	if value == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, e.indexKey)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// IsCurrent reports whether value needs no rotation: it is empty or
+// encrypted with a data key wrapped by the active master key.
+func (e *Envelope) IsCurrent(value string) bool {
+	if value == "" {
+		return true
+	}
+	if !IsEncrypted(value) {
+		return false
+	}
+	keyID, _, _, err := split(value)
+	return err == nil && keyID == e.kms.ActiveKey()
+}
+
+// IsEncrypted reports whether value was returned by Encrypt.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// dataKey returns the data key to encrypt with, making a new one when the
+// current one has been used enough or the active master key has changed.
+func (e *Envelope) dataKey(ctx context.Context) (*dataKey, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.current != nil && e.current.uses < maxUses && e.current.keyID == e.kms.ActiveKey() {
+		e.current.uses++
+		return e.current, nil
+	}
+	raw := make([]byte, keySize)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	keyID, wrapped, err := e.kms.Wrap(ctx, raw)
+	if err != nil {
+		return nil, fmt.Errorf("wrap data key: %w", err)
+	}
+	aead, err := newAEAD(raw)
+	if err != nil {
+		return nil, err
+	}
+	e.current = &dataKey{keyID: keyID, wrapped: wrapped, aead: aead, uses: 1}
+	return e.current, nil
+}
+
+// open returns the unwrapped data key, asking the KMS only the first time.
+func (e *Envelope) open(ctx context.Context, keyID string, wrapped []byte) (cipher.AEAD, error) {
+	cacheKey := keyID + "\x00" + string(wrapped)
+	e.mu.Lock()
+	aead, ok := e.opened[cacheKey]
+	e.mu.Unlock()
+	if ok {
+		return aead, nil
+	}
+	raw, err := e.kms.Unwrap(ctx, keyID, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	if aead, err = newAEAD(raw); err != nil {
+		return nil, err
+	}
+	e.mu.Lock()
+	if len(e.opened) >= maxOpened {
+		clear(e.opened)
+	}
+	e.opened[cacheKey] = aead
+	e.mu.Unlock()
+	return aead, nil
+}
+
+// split takes an encrypted value apart into the id of the master key, the
+// wrapped data key and the sealed plaintext.
+func split(value string) (keyID string, wrapped, sealed []byte, err error) {
+	buf, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(value, prefix))
+	if err != nil || len(buf) < 1 {
+		return "", nil, nil, errCorrupt
+	}
+	n := int(buf[0])
+	buf = buf[1:]
+	if len(buf) < n+2 {
+		return "", nil, nil, errCorrupt
+	}
+	keyID, buf = string(buf[:n]), buf[n:]
+	n = int(binary.BigEndian.Uint16(buf))
+	buf = buf[2:]
+	if len(buf) < n {
+		return "", nil, nil, errCorrupt
+	}
+	return keyID, buf[:n], buf[n:], nil
+}
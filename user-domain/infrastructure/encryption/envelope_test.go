@@ -0,0 +1,126 @@
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"user-domain/infrastructure/config"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, keySize))
+}
+
+func newTestEnvelope(t *testing.T, keys, active string) *Envelope {
+	t.Helper()
+	kms, err := NewLocalKMS(keys, active)
+	require.NoError(t, err)
+	return New(kms, bytes.Repeat([]byte{9}, keySize))
+}
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+	e := newTestEnvelope(t, "k1:"+testKey(1), "")
+	ctx := t.Context()
+
+	sealed, err := e.Encrypt(ctx, "an@example.com", "users/1/email")
+	require.NoError(t, err)
+	require.True(t, IsEncrypted(sealed))
+	require.NotContains(t, sealed, "an@example.com")
+	again, err := e.Encrypt(ctx, "an@example.com", "users/1/email")
+	require.NoError(t, err)
+	require.NotEqual(t, sealed, again, "nonces must differ")
+
+	plain, err := e.Decrypt(ctx, sealed, "users/1/email")
+	require.NoError(t, err)
+	require.Equal(t, "an@example.com", plain)
+
+	_, err = e.Decrypt(ctx, sealed, "users/2/email")
+	require.Error(t, err, "a value copied to another row must not decrypt")
+	_, err = e.Decrypt(ctx, sealed[:len(sealed)-4], "users/1/email")
+	require.Error(t, err)
+
+	empty, err := e.Encrypt(ctx, "", "users/1/phone")
+	require.NoError(t, err)
+	require.Empty(t, empty)
+	plain, err = e.Decrypt(ctx, "stored before encryption", "users/1/phone")
+	require.NoError(t, err)
+	require.Equal(t, "stored before encryption", plain)
+}
+
+func TestMasterKeyRotation(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	old := newTestEnvelope(t, "k1:"+testKey(1), "")
+	sealed, err := old.Encrypt(ctx, "0901234567", "users/1/phone")
+	require.NoError(t, err)
+	require.True(t, old.IsCurrent(sealed))
+	require.False(t, old.IsCurrent("0901234567"))
+	require.True(t, old.IsCurrent(""))
+
+	rotated := newTestEnvelope(t, "k1:"+testKey(1)+",k2:"+testKey(2), "k2")
+	require.False(t, rotated.IsCurrent(sealed))
+	plain, err := rotated.Decrypt(ctx, sealed, "users/1/phone")
+	require.NoError(t, err)
+	require.Equal(t, "0901234567", plain)
+	resealed, err := rotated.Encrypt(ctx, plain, "users/1/phone")
+	require.NoError(t, err)
+	require.True(t, rotated.IsCurrent(resealed))
+
+	retired := newTestEnvelope(t, "k2:"+testKey(2), "")
+	_, err = retired.Decrypt(ctx, sealed, "users/1/phone")
+	require.ErrorContains(t, err, `"k1" is unknown`)
+}
+
+func TestIndex(t *testing.T) {
+	t.Parallel()
+	e := newTestEnvelope(t, "k1:"+testKey(1), "")
+	other := New(e.kms, bytes.Repeat([]byte{8}, keySize))
+
+	require.Equal(t, e.Index("an@example.com"), e.Index("an@example.com"))
+	require.NotEqual(t, e.Index("an@example.com"), e.Index("binh@example.com"))
+	require.NotEqual(t, e.Index("an@example.com"), other.Index("an@example.com"))
+	require.Len(t, e.Index("an@example.com"), 64)
+	require.Empty(t, e.Index(""))
+}
+
+func TestNewLocalKMS(t *testing.T) {
+	t.Parallel()
+	for name, tt := range map[string]struct {
+		keys, active, err string
+	}{
+		"empty":      {keys: " ", err: "no master key"},
+		"no id":      {keys: testKey(1), err: "not id:base64"},
+		"short key":  {keys: "k1:" + base64.StdEncoding.EncodeToString([]byte("short")), err: "must be 32"},
+		"duplicate":  {keys: "k1:" + testKey(1) + ",k1:" + testKey(2), err: "given twice"},
+		"bad active": {keys: "k1:" + testKey(1), active: "k2", err: "not among"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewLocalKMS(tt.keys, tt.active)
+			require.ErrorContains(t, err, tt.err)
+		})
+	}
+
+	kms, err := NewLocalKMS("k1:"+testKey(1)+", k2:"+testKey(2), "")
+	require.NoError(t, err)
+	require.Equal(t, "k1", kms.ActiveKey())
+}
+
+func TestFromConfig(t *testing.T) {
+	t.Parallel()
+	e, err := FromConfig(&config.Config{})
+	require.NoError(t, err)
+	require.Nil(t, e)
+
+	_, err = FromConfig(&config.Config{EncryptionMasterKeys: "k1:" + testKey(1)})
+	require.ErrorContains(t, err, "index_key")
+
+	e, err = FromConfig(&config.Config{EncryptionMasterKeys: "k1:" + testKey(1), EncryptionIndexKey: testKey(3)})
+	require.NoError(t, err)
+	sealed, err := e.Encrypt(t.Context(), "an@example.com", "users/1/email")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(sealed, prefix))
+}
@@ -0,0 +1,116 @@
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KeyWrapper wraps data keys with master keys it never gives out, the way a
+// KMS does.
+type KeyWrapper interface {
+	// ActiveKey is the id of the master key Wrap uses.
+	ActiveKey() string
+	// Wrap encrypts dataKey with the active master key.
+	Wrap(ctx context.Context, dataKey []byte) (keyID string, wrapped []byte, err error)
+	// Unwrap decrypts a data key wrapped with the master key keyID.
+	Unwrap(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// LocalKMS stands in for a KMS with master keys from the config. Old keys
+// stay listed until rotatekeys has moved every row off them.
+type LocalKMS struct {
+	active string
+	keys   map[string]cipher.AEAD
+}
+
+// NewLocalKMS parses keys, a comma-separated list of id:base64 256-bit
+// keys. New data keys are wrapped with active, or with the first key if
+// active is empty.
+func NewLocalKMS(keys, active string) (*LocalKMS, error) {
+	k := &LocalKMS{active: active, keys: map[string]cipher.AEAD{}}
+	for i, entry := range strings.Split(keys, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		// Do not quote a malformed entry, it may be all key.
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || id == "" || len(id) > maxKeyIDLen {
+			return nil, fmt.Errorf("master key #%d is not id:base64", i+1)
+		}
+		if _, dup := k.keys[id]; dup {
+			return nil, fmt.Errorf("master key %q is given twice", id)
+		}
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(raw) != keySize {
+			return nil, fmt.Errorf("master key %q must be %d base64-encoded bytes", id, keySize)
+		}
+		aead, err := newAEAD(raw)
+		if err != nil {
+			return nil, err
+		}
+		k.keys[id] = aead
+		if k.active == "" {
+			k.active = id
+		}
+	}
+	if len(k.keys) == 0 {
+		return nil, errors.New("no master key given")
+	}
+	if _, ok := k.keys[k.active]; !ok {
+		return nil, fmt.Errorf("active master key %q is not among the master keys", k.active)
+	}
+	return k, nil
+}
+
+func (k *LocalKMS) ActiveKey() string {
+	return k.active
+}
+
+func (k *LocalKMS) Wrap(_ context.Context, dataKey []byte) (string, []byte, error) {
+	wrapped, err := seal(k.keys[k.active], dataKey, []byte(k.active))
+	return k.active, wrapped, err
+}
+
+func (k *LocalKMS) Unwrap(_ context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	aead, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("master key %q is unknown", keyID)
+	}
+	dataKey, err := open(aead, wrapped, []byte(keyID))
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key with master key %q: %w", keyID, err)
+	}
+	return dataKey, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext under a random nonce, which it puts in front.
+func seal(aead cipher.AEAD, plaintext, aad []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(aead cipher.AEAD, sealed, aad []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errCorrupt
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, aad)
+}
@@ -0,0 +1,120 @@
+package graphql
+
+import (
+	"strconv"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Limits bound the work a single query may ask for.
+type Limits struct {
+	// MaxComplexity caps the cost of a query: every field costs 1, a users
+	// filter 1 more per id it looks up, and the fields under a connection
+	// count once per requested item.
+	MaxComplexity int
+	// MaxDepth caps how deeply selections may be nested.
+	MaxDepth int
+}
+
+// cost walks the operation of a validated document, so fragments are known
+// to exist and not to form cycles.
+type cost struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+}
+
+func measure(doc *ast.Document, operationName string, variables map[string]interface{}) (complexity, depth int) {
+	c := cost{fragments: map[string]*ast.FragmentDefinition{}, variables: variables}
+	var op *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.FragmentDefinition:
+			c.fragments[def.Name.Value] = def
+		case *ast.OperationDefinition:
+			if op == nil && (operationName == "" || def.Name != nil && def.Name.Value == operationName) {
+				op = def
+			}
+		}
+	}
+	if op == nil {
+		return 0, 0
+	}
+	return c.selectionSet(op.SelectionSet)
+}
+
+func (c cost) selectionSet(set *ast.SelectionSet) (complexity, depth int) {
+	if set == nil {
+		return 0, 0
+	}
+	for _, sel := range set.Selections {
+		var cx, d int
+		switch sel := sel.(type) {
+		case *ast.Field:
+			childCx, childDepth := c.selectionSet(sel.SelectionSet)
+			cx, d = 1+c.lookups(sel)+c.multiplier(sel)*childCx, 1+childDepth
+		case *ast.InlineFragment:
+			cx, d = c.selectionSet(sel.SelectionSet)
+		case *ast.FragmentSpread:
+			if f, ok := c.fragments[sel.Name.Value]; ok {
+				cx, d = c.selectionSet(f.SelectionSet)
+			}
+		}
+		complexity += cx
+		depth = max(depth, d)
+	}
+	return complexity, depth
+}
+
+// multiplier is the page size of a connection field, 1 for the others.
+func (c cost) multiplier(f *ast.Field) int {
+	for _, arg := range f.Arguments {
+		if arg.Name.Value != "first" {
+			continue
+		}
+		switch v := arg.Value.(type) {
+		case *ast.IntValue:
+			if n, err := strconv.Atoi(v.Value); err == nil && n > 0 {
+				return n
+			}
+		case *ast.Variable:
+			// Variables decoded from JSON are float64.
+			if n, ok := c.variables[v.Name.Value].(float64); ok && n > 0 {
+				return int(n)
+			}
+		}
+		return 1
+	}
+	if f.Name.Value == "users" {
+		return defaultPageSize
+	}
+	return 1
+}
+
+// lookups is the number of ids the filter of a users field looks up.
+func (c cost) lookups(f *ast.Field) int {
+	for _, arg := range f.Arguments {
+		if arg.Name.Value != "filter" {
+			continue
+		}
+		switch v := arg.Value.(type) {
+		case *ast.ObjectValue:
+			for _, field := range v.Fields {
+				if field.Name.Value != "ids" {
+					continue
+				}
+				switch ids := field.Value.(type) {
+				case *ast.ListValue:
+					return len(ids.Values)
+				case *ast.Variable:
+					list, _ := c.variables[ids.Name.Value].([]interface{})
+					return len(list)
+				}
+			}
+		case *ast.Variable:
+			filter, _ := c.variables[v.Name.Value].(map[string]interface{})
+			list, _ := filter["ids"].([]interface{})
+			return len(list)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,61 @@
+package graphql
+
+import (
+	"errors"
+	domainerror "user-domain/internal/domain/error"
+
+	"github.com/graphql-go/graphql"
+)
+
+// resolverError is what clients see of a failed field: the client-safe
+// message, with the stable error code and any invalid fields as extensions.
+type resolverError struct {
+	err error
+}
+
+func (e resolverError) Error() string {
+	return domainerror.MessageOf(e.err)
+}
+
+func (e resolverError) Extensions() map[string]interface{} {
+	ext := map[string]interface{}{"code": domainerror.CodeOf(e.err)}
+	var domainErr *domainerror.Error
+	if errors.As(e.err, &domainErr) && len(domainErr.Fields) > 0 {
+		ext["errors"] = domainErr.Fields
+	}
+	return ext
+}
+
+func (e resolverError) Unwrap() error {
+	return e.err
+}
+
+// fail is the GraphQL counterpart of apiutil's Failure: it logs err and
+// hides everything but its code and message from the client.
+func (r *resolver) fail(p graphql.ResolveParams, err error) error {
+	if domainerror.CodeOf(err) == domainerror.CodeInternal {
+		r.logger.WithContext(p.Context).Error("error: %s", err)
+	} else {
+		r.logger.WithContext(p.Context).Warn("error: %s", err)
+	}
+	return resolverError{err: err}
+}
+
+// invalidArgument rejects an argument the service was never called with.
+func invalidArgument(field, message string) error {
+	return invalidArguments(argumentError(field, message))
+}
+
+func invalidArguments(fields ...domainerror.FieldError) error {
+	return domainerror.New(domainerror.ErrCodeInvalidInput, domainerror.CodeValidation, "arguments are invalid").
+		WithFields(fields...)
+}
+
+func argumentError(field, message string) domainerror.FieldError {
+	return domainerror.FieldError{Field: field, Location: "argument", Message: message}
+}
+
+// requestError fails the whole request before any resolver ran.
+func requestError(code domainerror.Code, message string) error {
+	return resolverError{err: domainerror.New(domainerror.ErrCodeInvalidInput, code, message)}
+}
@@ -0,0 +1,132 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	applicationoutbound "user-domain/internal/application/outbound"
+	domainerror "user-domain/internal/domain/error"
+	"user-domain/internal/domain/inport"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+const maxBodySize = 1 << 20
+
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+type handler struct {
+	schema graphql.Schema
+	sv     inport.UserService
+	limits Limits
+}
+
+// NewHandler serves GraphQL queries over sv on GET and POST, following the
+// GraphQL over HTTP conventions. Queries beyond limits are rejected before
+// any resolver runs.
+func NewHandler(sv inport.UserService, logger applicationoutbound.Logger, limits Limits) http.Handler {
+	schema, err := NewSchema(sv, logger)
+	if err != nil {
+		// The schema is static, so this is a bug the tests catch.
+		panic(fmt.Sprintf("graphql: build schema: %s", err))
+	}
+	return &handler{schema: schema, sv: sv, limits: limits}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req request
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		req.Query = q.Get("query")
+		req.OperationName = q.Get("operationName")
+		if v := q.Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				writeErrors(w, http.StatusBadRequest, requestError(domainerror.CodeMalformedRequest, "variables are not a JSON object"))
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(&req); err != nil {
+			writeErrors(w, http.StatusBadRequest, requestError(domainerror.CodeMalformedRequest, "the request body is not valid JSON"))
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if req.Query == "" {
+		writeErrors(w, http.StatusBadRequest, requestError(domainerror.CodeMalformedRequest, "query is required"))
+		return
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{Source: req.Query})
+	if err != nil {
+		writeErrors(w, http.StatusBadRequest, err)
+		return
+	}
+	if res := graphql.ValidateDocument(&h.schema, doc, nil); !res.IsValid {
+		writeResult(w, http.StatusBadRequest, &graphql.Result{Errors: res.Errors})
+		return
+	}
+	// GET must be safe to cache and prefetch.
+	if r.Method == http.MethodGet && isMutation(doc, req.OperationName) {
+		w.Header().Set("Allow", "POST")
+		writeErrors(w, http.StatusMethodNotAllowed, requestError(domainerror.CodeInvalidInput, "mutations must be sent with POST"))
+		return
+	}
+	complexity, depth := measure(doc, req.OperationName, req.Variables)
+	if h.limits.MaxDepth > 0 && depth > h.limits.MaxDepth {
+		writeErrors(w, http.StatusBadRequest, requestError(domainerror.CodeQueryTooComplex,
+			fmt.Sprintf("query depth %d exceeds the limit of %d", depth, h.limits.MaxDepth)))
+		return
+	}
+	if h.limits.MaxComplexity > 0 && complexity > h.limits.MaxComplexity {
+		writeErrors(w, http.StatusBadRequest, requestError(domainerror.CodeQueryTooComplex,
+			fmt.Sprintf("query complexity %d exceeds the limit of %d", complexity, h.limits.MaxComplexity)))
+		return
+	}
+
+	res := graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       withLoader(r.Context(), h.sv),
+	})
+	writeResult(w, http.StatusOK, res)
+}
+
+func isMutation(doc *ast.Document, operationName string) bool {
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok || operationName != "" && (op.Name == nil || op.Name.Value != operationName) {
+			continue
+		}
+		return op.Operation == ast.OperationTypeMutation
+	}
+	return false
+}
+
+func writeErrors(w http.ResponseWriter, status int, errs ...error) {
+	res := &graphql.Result{}
+	for _, err := range errs {
+		// Locating the error keeps the extensions of a resolverError.
+		res.Errors = append(res.Errors, gqlerrors.FormatError(gqlerrors.NewLocatedError(err, nil)))
+	}
+	writeResult(w, status, res)
+}
+
+func writeResult(w http.ResponseWriter, status int, res *graphql.Result) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(res)
+}
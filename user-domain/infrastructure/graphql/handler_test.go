@@ -0,0 +1,235 @@
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	application_mock "user-domain/internal/application/mocks/outbound"
+	domainerror "user-domain/internal/domain/error"
+	domain_mock "user-domain/internal/domain/mocks/inport"
+	"user-domain/internal/entity"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type response struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func newTestHandler(t *testing.T, limits Limits) (http.Handler, *domain_mock.UserService) {
+	t.Helper()
+	sv := domain_mock.NewUserService(t)
+	logger := application_mock.NewLogger(t)
+	logger.On("WithContext", mock.Anything).Return(logger).Maybe()
+	logger.On("Warn", mock.Anything, mock.Anything).Maybe()
+	logger.On("Error", mock.Anything, mock.Anything).Maybe()
+	return NewHandler(sv, logger, limits), sv
+}
+
+func post(t *testing.T, h http.Handler, query string, variables map[string]any) (int, response) {
+	t.Helper()
+	body, err := json.Marshal(request{Query: query, Variables: variables})
+	require.NoError(t, err)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body))))
+	var res response
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	return rec.Code, res
+}
+
+const (
+	id1 = "6f1f7c1e-2a4b-4c55-9a1e-000000000001"
+	id2 = "6f1f7c1e-2a4b-4c55-9a1e-000000000002"
+	id3 = "6f1f7c1e-2a4b-4c55-9a1e-000000000003"
+)
+
+func TestUserLookupsAreBatched(t *testing.T) {
+	t.Parallel()
+	h, sv := newTestHandler(t, Limits{})
+	// Query fields resolve in no particular order.
+	ids := mock.MatchedBy(func(ids []string) bool {
+		return len(ids) == 3 && slices.Contains(ids, id1) && slices.Contains(ids, id2) && slices.Contains(ids, id3)
+	})
+	sv.On("GetUsersByIDs", mock.Anything, ids).Return([]*entity.User{
+		{ID: id1, Name: "An", Email: "an@example.com"},
+		{ID: id2, Name: "Binh", Email: "binh@example.com", Phone: "0901"},
+	}, nil).Once()
+
+	status, res := post(t, h, fmt.Sprintf(`{
+		a: user(id: %[1]q) { name }
+		b: user(id: %[2]q) { name phone }
+		c: user(id: %[1]q) { email phone }
+		d: user(id: %[3]q) { name }
+		e: user(id: "1") { name }
+	}`, id1, id2, id3), nil)
+	require.Equal(t, http.StatusOK, status)
+	require.JSONEq(t, `{"name":"An"}`, string(res.Data["a"]))
+	require.JSONEq(t, `{"name":"Binh","phone":"0901"}`, string(res.Data["b"]))
+	require.JSONEq(t, `{"email":"an@example.com","phone":null}`, string(res.Data["c"]))
+	require.JSONEq(t, `null`, string(res.Data["d"]))
+	// A malformed id fails its own field, not the batch.
+	require.JSONEq(t, `null`, string(res.Data["e"]))
+	require.Len(t, res.Errors, 1)
+	require.Equal(t, "validation_failed", res.Errors[0].Extensions["code"])
+}
+
+func TestUsersFilteredByIDs(t *testing.T) {
+	t.Parallel()
+	h, sv := newTestHandler(t, Limits{MaxComplexity: 150})
+	sv.On("GetUsersByIDs", mock.Anything, []string{id1}).Return([]*entity.User{{ID: id1}}, nil).Once()
+
+	query := `query($ids: [ID!]) { users(filter: {ids: $ids}) { edges { node { id } } } }`
+	_, res := post(t, h, query, map[string]any{"ids": []string{id1}})
+	require.Empty(t, res.Errors)
+	require.JSONEq(t, `{"edges":[{"node":{"id":"`+id1+`"}}]}`, string(res.Data["users"]))
+
+	_, res = post(t, h, query, map[string]any{"ids": []string{id1, "1"}})
+	require.Len(t, res.Errors, 1)
+	require.Equal(t, "validation_failed", res.Errors[0].Extensions["code"])
+
+	many := make([]string, maxPageSize+1)
+	for i := range many {
+		many[i] = id1
+	}
+	// Every id is a lookup: 1 + 101 + 20 * (1 + 1 + 1).
+	status, res := post(t, h, query, map[string]any{"ids": many})
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, "query_too_complex", res.Errors[0].Extensions["code"])
+
+	h, _ = newTestHandler(t, Limits{})
+	_, res = post(t, h, query, map[string]any{"ids": many})
+	require.Len(t, res.Errors, 1)
+	require.Contains(t, res.Errors[0].Extensions["errors"].([]any)[0].(map[string]any)["message"], "at most 100 ids")
+}
+
+func TestUsersConnection(t *testing.T) {
+	t.Parallel()
+	h, sv := newTestHandler(t, Limits{})
+	sv.On("ListUsers", mock.Anything, 0, 3).Return([]*entity.User{{ID: "1"}, {ID: "2"}, {ID: "3"}}, nil)
+	sv.On("ListUsers", mock.Anything, 2, 3).Return([]*entity.User{{ID: "3"}}, nil)
+
+	query := `query($after: String) {
+		users(first: 2, after: $after) {
+			edges { cursor node { id } }
+			pageInfo { hasNextPage hasPreviousPage endCursor }
+		}
+	}`
+	type page struct {
+		Edges []struct {
+			Cursor string
+			Node   struct{ ID string }
+		}
+		PageInfo struct {
+			HasNextPage     bool
+			HasPreviousPage bool
+			EndCursor       string
+		}
+	}
+
+	_, res := post(t, h, query, nil)
+	require.Empty(t, res.Errors)
+	var first page
+	require.NoError(t, json.Unmarshal(res.Data["users"], &first))
+	require.Len(t, first.Edges, 2)
+	require.True(t, first.PageInfo.HasNextPage)
+	require.False(t, first.PageInfo.HasPreviousPage)
+
+	_, res = post(t, h, query, map[string]any{"after": first.PageInfo.EndCursor})
+	require.Empty(t, res.Errors)
+	var second page
+	require.NoError(t, json.Unmarshal(res.Data["users"], &second))
+	require.Len(t, second.Edges, 1)
+	require.Equal(t, "3", second.Edges[0].Node.ID)
+	require.False(t, second.PageInfo.HasNextPage)
+	require.True(t, second.PageInfo.HasPreviousPage)
+}
+
+func TestUsersFilteredByEmail(t *testing.T) {
+	t.Parallel()
+	h, sv := newTestHandler(t, Limits{})
+	sv.On("GetUserByEmail", mock.Anything, "an@example.com").Return(&entity.User{ID: "1"}, nil)
+	sv.On("GetUserByEmail", mock.Anything, "nobody@example.com").Return((*entity.User)(nil),
+		domainerror.New(domainerror.ErrCodeNotFound, domainerror.CodeUserNotFound, "user was not found"))
+
+	_, res := post(t, h, `{
+		found: users(filter: {email: "an@example.com"}) { edges { node { id } } }
+		missing: users(filter: {email: "nobody@example.com"}) { edges { node { id } } }
+	}`, nil)
+	require.Empty(t, res.Errors)
+	require.JSONEq(t, `{"edges":[{"node":{"id":"1"}}]}`, string(res.Data["found"]))
+	require.JSONEq(t, `{"edges":[]}`, string(res.Data["missing"]))
+}
+
+func TestErrorsCarryCode(t *testing.T) {
+	t.Parallel()
+	h, sv := newTestHandler(t, Limits{})
+	sv.On("DeleteUser", mock.Anything, "42").Return(
+		domainerror.New(domainerror.ErrCodeNotFound, domainerror.CodeUserNotFound, "user 42 was not found").
+			WithCause(errors.New("pq: connection refused to 10.0.0.5")))
+
+	status, res := post(t, h, `mutation { deleteUser(id: "42") }`, nil)
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, res.Errors, 1)
+	require.Equal(t, "user 42 was not found", res.Errors[0].Message)
+	require.Equal(t, "user_not_found", res.Errors[0].Extensions["code"])
+
+	_, res = post(t, h, `{ users(first: 1000) { edges { cursor } } }`, nil)
+	require.Len(t, res.Errors, 1)
+	require.Equal(t, "validation_failed", res.Errors[0].Extensions["code"])
+}
+
+func TestCreateUserValidatesInput(t *testing.T) {
+	t.Parallel()
+	h, sv := newTestHandler(t, Limits{})
+	create := `mutation($input: CreateUserInput!) { createUser(input: $input) { id } }`
+
+	_, res := post(t, h, create, map[string]any{"input": map[string]any{"name": "", "email": "not an email"}})
+	require.Len(t, res.Errors, 1)
+	require.Equal(t, "validation_failed", res.Errors[0].Extensions["code"])
+	require.Equal(t, []any{
+		map[string]any{"field": "input.name", "location": "argument", "message": "is required"},
+		map[string]any{"field": "input.email", "location": "argument", "message": "is not a valid email address"},
+	}, res.Errors[0].Extensions["errors"])
+
+	sv.On("CreateUser", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(*entity.User).ID = "1"
+	}).Return(nil).Once()
+	_, res = post(t, h, create, map[string]any{"input": map[string]any{"name": "An", "email": "an@example.com"}})
+	require.Empty(t, res.Errors)
+	require.JSONEq(t, `{"id":"1"}`, string(res.Data["createUser"]))
+}
+
+func TestLimits(t *testing.T) {
+	t.Parallel()
+	h, _ := newTestHandler(t, Limits{MaxComplexity: 100, MaxDepth: 3})
+
+	// 1 + 50 * (1 + 1 + 1) edges, cursor and node, plus id.
+	status, res := post(t, h, `{ users(first: 50) { edges { cursor node { id } } } }`, nil)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, "query_too_complex", res.Errors[0].Extensions["code"])
+
+	status, res = post(t, h, `query($n: Int) { users(first: $n) { edges { node { id } } } }`, map[string]any{"n": 50})
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Contains(t, res.Errors[0].Message, "depth 4")
+}
+
+func TestMutationsRequirePost(t *testing.T) {
+	t.Parallel()
+	h, _ := newTestHandler(t, Limits{})
+
+	rec := httptest.NewRecorder()
+	q := url.Values{"query": {`mutation { deleteUser(id: "42") }`}}
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/graphql?"+q.Encode(), nil))
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
@@ -0,0 +1,103 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+	"user-domain/internal/domain/inport"
+	"user-domain/internal/entity"
+)
+
+const maxBatchSize = 100
+
+// userLoader batches the user lookups of one request into GetUsersByIDs
+// calls. Load only queues the id; the batch is fetched when the first of its
+// thunks is called, which graphql-go does once every field of the current
+// level has been resolved. Each id is fetched at most once per request.
+type userLoader struct {
+	sv inport.UserService
+
+	mu      sync.Mutex
+	current *userBatch
+	batches map[string]*userBatch
+}
+
+type userBatch struct {
+	ids   []string
+	once  sync.Once
+	users map[string]*entity.User
+	err   error
+}
+
+type loaderKey struct{}
+
+func withLoader(ctx context.Context, sv inport.UserService) context.Context {
+	return context.WithValue(ctx, loaderKey{}, &userLoader{sv: sv, batches: map[string]*userBatch{}})
+}
+
+func loaderFrom(ctx context.Context) *userLoader {
+	return ctx.Value(loaderKey{}).(*userLoader)
+}
+
+// Load returns a thunk yielding the user with id, nil if there is none.
+func (l *userLoader) Load(ctx context.Context, id string) func() (*entity.User, error) {
+	l.mu.Lock()
+	b, ok := l.batches[id]
+	if !ok {
+		if l.current == nil || len(l.current.ids) >= maxBatchSize {
+			l.current = &userBatch{}
+		}
+		b = l.current
+		b.ids = append(b.ids, id)
+		l.batches[id] = b
+	}
+	l.mu.Unlock()
+
+	return func() (*entity.User, error) {
+		b.once.Do(func() {
+			l.mu.Lock()
+			if l.current == b {
+				l.current = nil
+			}
+			ids := b.ids
+			l.mu.Unlock()
+			b.fetch(ctx, l.sv, ids)
+		})
+		if b.err != nil {
+			return nil, b.err
+		}
+		return b.users[id], nil
+	}
+}
+
+// LoadMany is Load for several ids, missing users are left out.
+func (l *userLoader) LoadMany(ctx context.Context, ids []string) func() ([]*entity.User, error) {
+	thunks := make([]func() (*entity.User, error), len(ids))
+	for i, id := range ids {
+		thunks[i] = l.Load(ctx, id)
+	}
+	return func() ([]*entity.User, error) {
+		users := make([]*entity.User, 0, len(ids))
+		for _, thunk := range thunks {
+			u, err := thunk()
+			if err != nil {
+				return nil, err
+			}
+			if u != nil {
+				users = append(users, u)
+			}
+		}
+		return users, nil
+	}
+}
+
+func (b *userBatch) fetch(ctx context.Context, sv inport.UserService, ids []string) {
+	users, err := sv.GetUsersByIDs(ctx, ids)
+	if err != nil {
+		b.err = err
+		return
+	}
+	b.users = make(map[string]*entity.User, len(users))
+	for _, u := range users {
+		b.users[u.ID] = u
+	}
+}
@@ -0,0 +1,358 @@
+package graphql
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/mail"
+	"slices"
+	"strconv"
+	"strings"
+	applicationoutbound "user-domain/internal/application/outbound"
+	domainerror "user-domain/internal/domain/error"
+	"user-domain/internal/domain/inport"
+	"user-domain/internal/entity"
+
+	"github.com/google/uuid"
+	"github.com/graphql-go/graphql"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+	cursorPrefix    = "offset:"
+)
+
+type resolver struct {
+	sv     inport.UserService
+	logger applicationoutbound.Logger
+}
+
+// NewSchema builds the GraphQL schema over sv:
+//
+//	type Query {
+//	  user(id: ID!): User
+//	  users(first: Int = 20, after: String, filter: UserFilter): UserConnection!
+//	}
+//	type Mutation {
+//	  createUser(input: CreateUserInput!): User!
+//	  updateUser(id: ID!, input: UpdateUserInput!): User!
+//	  deleteUser(id: ID!): ID!
+//	}
+func NewSchema(sv inport.UserService, logger applicationoutbound.Logger) (graphql.Schema, error) {
+	r := &resolver{sv: sv, logger: logger}
+
+	user := graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"id":      userField(graphql.NewNonNull(graphql.ID), func(u *entity.User) any { return u.ID }),
+			"name":    userField(graphql.NewNonNull(graphql.String), func(u *entity.User) any { return u.Name }),
+			"email":   userField(graphql.NewNonNull(graphql.String), func(u *entity.User) any { return u.Email }),
+			"phone":   userField(graphql.String, func(u *entity.User) any { return optional(u.Phone) }),
+			"address": userField(graphql.String, func(u *entity.User) any { return optional(u.Address) }),
+		},
+	})
+	pageInfo := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"hasNextPage":     &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"hasPreviousPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"startCursor":     &graphql.Field{Type: graphql.String},
+			"endCursor":       &graphql.Field{Type: graphql.String},
+		},
+	})
+	edge := graphql.NewObject(graphql.ObjectConfig{
+		Name: "UserEdge",
+		Fields: graphql.Fields{
+			"cursor": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"node":   &graphql.Field{Type: graphql.NewNonNull(user)},
+		},
+	})
+	connection := graphql.NewObject(graphql.ObjectConfig{
+		Name: "UserConnection",
+		Fields: graphql.Fields{
+			"edges":    &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(edge)))},
+			"pageInfo": &graphql.Field{Type: graphql.NewNonNull(pageInfo)},
+		},
+	})
+	filter := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "UserFilter",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"ids":   &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.ID))},
+			"email": &graphql.InputObjectFieldConfig{Type: graphql.String},
+		},
+	})
+	createInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "CreateUserInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"name":    &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"email":   &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"phone":   &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"address": &graphql.InputObjectFieldConfig{Type: graphql.String},
+		},
+	})
+	updateInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "UpdateUserInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"name":    &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"phone":   &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"address": &graphql.InputObjectFieldConfig{Type: graphql.String},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"user": &graphql.Field{
+				Type:    user,
+				Args:    graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve: r.user,
+			},
+			"users": &graphql.Field{
+				Type: graphql.NewNonNull(connection),
+				Args: graphql.FieldConfigArgument{
+					"first":  {Type: graphql.Int, DefaultValue: defaultPageSize},
+					"after":  {Type: graphql.String},
+					"filter": {Type: filter},
+				},
+				Resolve: r.users,
+			},
+		},
+	})
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createUser": &graphql.Field{
+				Type:    graphql.NewNonNull(user),
+				Args:    graphql.FieldConfigArgument{"input": {Type: graphql.NewNonNull(createInput)}},
+				Resolve: r.createUser,
+			},
+			"updateUser": &graphql.Field{
+				Type: graphql.NewNonNull(user),
+				Args: graphql.FieldConfigArgument{
+					"id":    {Type: graphql.NewNonNull(graphql.ID)},
+					"input": {Type: graphql.NewNonNull(updateInput)},
+				},
+				Resolve: r.updateUser,
+			},
+			"deleteUser": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.ID),
+				Args:    graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve: r.deleteUser,
+			},
+		},
+	})
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+func userField(typ graphql.Output, get func(u *entity.User) any) *graphql.Field {
+	return &graphql.Field{
+		Type: typ,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return get(p.Source.(*entity.User)), nil
+		},
+	}
+}
+
+func optional(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func (r *resolver) user(p graphql.ResolveParams) (interface{}, error) {
+	id := p.Args["id"].(string)
+	// A malformed id would fail the whole batch it is loaded in.
+	if uuid.Validate(id) != nil {
+		return nil, r.fail(p, invalidArgument("id", "is not a valid id"))
+	}
+	load := loaderFrom(p.Context).Load(p.Context, id)
+	return func() (interface{}, error) {
+		u, err := load()
+		if err != nil {
+			return nil, r.fail(p, err)
+		}
+		if u == nil {
+			return nil, nil
+		}
+		return u, nil
+	}, nil
+}
+
+type connection struct {
+	Edges    []edge   `json:"edges"`
+	PageInfo pageInfo `json:"pageInfo"`
+}
+
+type edge struct {
+	Cursor string       `json:"cursor"`
+	Node   *entity.User `json:"node"`
+}
+
+type pageInfo struct {
+	HasNextPage     bool    `json:"hasNextPage"`
+	HasPreviousPage bool    `json:"hasPreviousPage"`
+	StartCursor     *string `json:"startCursor"`
+	EndCursor       *string `json:"endCursor"`
+}
+
+// users pages through all users, or through the ones matching the filter.
+// Cursors are opaque to clients; they hold the offset after the edge.
+func (r *resolver) users(p graphql.ResolveParams) (interface{}, error) {
+	first, _ := p.Args["first"].(int)
+	if first < 0 || first > maxPageSize {
+		return nil, r.fail(p, invalidArgument("first", fmt.Sprintf("must be between 0 and %d", maxPageSize)))
+	}
+	offset := 0
+	if after, ok := p.Args["after"].(string); ok {
+		var err error
+		if offset, err = decodeCursor(after); err != nil {
+			return nil, r.fail(p, invalidArgument("after", "is not a valid cursor"))
+		}
+	}
+
+	filter, _ := p.Args["filter"].(map[string]interface{})
+	if filter == nil {
+		users, err := r.sv.ListUsers(p.Context, offset, first+1)
+		if err != nil {
+			return nil, r.fail(p, err)
+		}
+		return page(users, offset, first), nil
+	}
+
+	ids := []string{}
+	if raw, ok := filter["ids"].([]interface{}); ok {
+		if len(raw) > maxPageSize {
+			return nil, r.fail(p, invalidArgument("filter.ids", fmt.Sprintf("must hold at most %d ids", maxPageSize)))
+		}
+		for _, id := range raw {
+			if uuid.Validate(id.(string)) != nil {
+				return nil, r.fail(p, invalidArgument("filter.ids", fmt.Sprintf("%q is not a valid id", id)))
+			}
+			ids = append(ids, id.(string))
+		}
+	}
+	email, byEmail := filter["email"].(string)
+	var loadIDs func() ([]*entity.User, error)
+	if _, byIDs := filter["ids"]; byIDs {
+		loadIDs = loaderFrom(p.Context).LoadMany(p.Context, ids)
+	}
+	return func() (interface{}, error) {
+		var users []*entity.User
+		switch {
+		case byEmail:
+			u, err := r.sv.GetUserByEmail(p.Context, email)
+			if err != nil && domainerror.CodeOf(err) != domainerror.CodeUserNotFound {
+				return nil, r.fail(p, err)
+			}
+			if u != nil && (loadIDs == nil || slices.Contains(ids, u.ID)) {
+				users = append(users, u)
+			}
+		case loadIDs != nil:
+			var err error
+			if users, err = loadIDs(); err != nil {
+				return nil, r.fail(p, err)
+			}
+		default:
+			var err error
+			if users, err = r.sv.ListUsers(p.Context, offset, first+1); err != nil {
+				return nil, r.fail(p, err)
+			}
+			return page(users, offset, first), nil
+		}
+		if offset > len(users) {
+			offset = len(users)
+		}
+		return page(users[offset:], offset, first), nil
+	}, nil
+}
+
+// page turns users, starting at offset and holding one more than first when
+// there is a next page, into a connection.
+func page(users []*entity.User, offset, first int) connection {
+	c := connection{Edges: []edge{}, PageInfo: pageInfo{HasPreviousPage: offset > 0}}
+	if len(users) > first {
+		users = users[:first]
+		c.PageInfo.HasNextPage = true
+	}
+	for i, u := range users {
+		c.Edges = append(c.Edges, edge{Cursor: encodeCursor(offset + i + 1), Node: u})
+	}
+	if len(c.Edges) > 0 {
+		c.PageInfo.StartCursor = &c.Edges[0].Cursor
+		c.PageInfo.EndCursor = &c.Edges[len(c.Edges)-1].Cursor
+	}
+	return c
+}
+
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := strings.CutPrefix(string(b), cursorPrefix)
+	if !ok {
+		return 0, fmt.Errorf("cursor %q: unknown format", cursor)
+	}
+	offset, err := strconv.Atoi(n)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("cursor %q: bad offset", cursor)
+	}
+	return offset, nil
+}
+
+func (r *resolver) createUser(p graphql.ResolveParams) (interface{}, error) {
+	input := p.Args["input"].(map[string]interface{})
+	u := &entity.User{}
+	u.Name, _ = input["name"].(string)
+	u.Email, _ = input["email"].(string)
+	u.Phone, _ = input["phone"].(string)
+	u.Address, _ = input["address"].(string)
+	// /graphql is outside /api/v1 and its OpenAPI validation, the checks
+	// of UserPost are made here.
+	var fields []domainerror.FieldError
+	if u.Name == "" {
+		fields = append(fields, argumentError("input.name", "is required"))
+	}
+	if u.Email == "" {
+		fields = append(fields, argumentError("input.email", "is required"))
+	} else if _, err := mail.ParseAddress(u.Email); err != nil {
+		fields = append(fields, argumentError("input.email", "is not a valid email address"))
+	}
+	if len(fields) > 0 {
+		return nil, r.fail(p, invalidArguments(fields...))
+	}
+	if err := r.sv.CreateUser(p.Context, u); err != nil {
+		return nil, r.fail(p, err)
+	}
+	return u, nil
+}
+
+func (r *resolver) updateUser(p graphql.ResolveParams) (interface{}, error) {
+	id := p.Args["id"].(string)
+	input := p.Args["input"].(map[string]interface{})
+	u := &entity.User{ID: id}
+	u.Name, _ = input["name"].(string)
+	u.Phone, _ = input["phone"].(string)
+	u.Address, _ = input["address"].(string)
+	if err := r.sv.UpdateUser(p.Context, u); err != nil {
+		return nil, r.fail(p, err)
+	}
+	updated, err := r.sv.GetUserByID(p.Context, id)
+	if err != nil {
+		return nil, r.fail(p, err)
+	}
+	return updated, nil
+}
+
+func (r *resolver) deleteUser(p graphql.ResolveParams) (interface{}, error) {
+	id := p.Args["id"].(string)
+	if err := r.sv.DeleteUser(p.Context, id); err != nil {
+		return nil, r.fail(p, err)
+	}
+	return id, nil
+}
@@ -0,0 +1,154 @@
+package grpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"runtime/debug"
+	"strings"
+	"time"
+	"user-domain/infrastructure/http/middleware"
+	applicationoutbound "user-domain/internal/application/outbound"
+	domainoutport "user-domain/internal/domain/outport"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	requestIDKey = "x-request-id"
+	apiKeyKey    = "x-api-key"
+)
+
+// publicServices are reachable without credentials so that probes and
+// tooling keep working.
+var publicServices = []string{"/grpc.health.v1.Health/", "/grpc.reflection."}
+
+// recovery turns a panic in a handler into an Internal status, like the
+// Recoverer middleware does for HTTP.
+func recovery(logger applicationoutbound.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				logger.WithContext(ctx).Error("panic in %s: %v\n%s", info.FullMethod, p, debug.Stack())
+				err = status.Error(codes.Internal, "an unexpected internal server error occurred")
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+func streamRecovery(logger applicationoutbound.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				logger.WithContext(ss.Context()).Error("panic in %s: %v\n%s", info.FullMethod, p, debug.Stack())
+				err = status.Error(codes.Internal, "an unexpected internal server error occurred")
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+// requestID takes the request ID from the x-request-id metadata or makes
+// one up, stores it where the logger looks for it and echoes it back.
+func requestID(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	id := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(requestIDKey); len(v) > 0 {
+			id = v[0]
+		}
+	}
+	if id == "" {
+		id = uuid.NewString()
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, id))
+	return handler(context.WithValue(ctx, chimiddleware.RequestIDKey, id), req)
+}
+
+func logging(logger applicationoutbound.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		logger.WithContext(ctx).Info("Start call", domainoutport.LogFields{
+			"method": info.FullMethod,
+		})
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logger.WithContext(ctx).Info("end call", domainoutport.LogFields{
+			"code":     status.Code(err).String(),
+			"duration": time.Since(start).Microseconds(),
+		})
+		return resp, err
+	}
+}
+
+// auth accepts calls that present one of keys, either as a bearer token in
+// the authorization metadata or in x-api-key. The key is recorded as the
+// principal, hashed.
+type auth struct {
+	keys [][]byte
+}
+
+func newAuth(keys []string) *auth {
+	a := &auth{}
+	for _, k := range keys {
+		if k = strings.TrimSpace(k); k != "" {
+			a.keys = append(a.keys, []byte(k))
+		}
+	}
+	return a
+}
+
+func (a *auth) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := a.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *auth) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if _, err := a.authenticate(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+func (a *auth) authenticate(ctx context.Context, method string) (context.Context, error) {
+	for _, prefix := range publicServices {
+		if strings.HasPrefix(method, prefix) {
+			return ctx, nil
+		}
+	}
+	key := credentials(ctx)
+	if key == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing credentials")
+	}
+	for _, k := range a.keys {
+		if subtle.ConstantTimeCompare(k, []byte(key)) == 1 {
+			sum := sha256.Sum256([]byte(key))
+			return middleware.WithPrincipal(ctx, "key:"+hex.EncodeToString(sum[:16])), nil
+		}
+	}
+	return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+}
+
+func credentials(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if v := md.Get("authorization"); len(v) > 0 {
+		if token, ok := strings.CutPrefix(v[0], "Bearer "); ok {
+			return token
+		}
+	}
+	if v := md.Get(apiKeyKey); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
@@ -0,0 +1,133 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"user-domain/infrastructure/grpc/userpb"
+	"user-domain/infrastructure/lifecycle"
+	applicationoutbound "user-domain/internal/application/outbound"
+	"user-domain/internal/domain/inport"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+type options struct {
+	apiKeys    []string
+	reflection bool
+}
+
+type Option func(*options)
+
+// WithAPIKeys requires every call but health checks and reflection to
+// present one of keys.
+func WithAPIKeys(keys []string) Option {
+	return func(o *options) {
+		o.apiKeys = keys
+	}
+}
+
+// WithReflection lets tools such as grpcurl discover the services.
+func WithReflection() Option {
+	return func(o *options) {
+		o.reflection = true
+	}
+}
+
+// Server serves the user API, the standard health service and optionally
+// server reflection over gRPC.
+type Server struct {
+	grpcServer *grpc.Server
+	health     *health.Server
+	addr       string
+	errCh      chan error
+}
+
+func NewServer(port string, sv inport.UserService, logger applicationoutbound.Logger, opts ...Option) *Server {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	unary := []grpc.UnaryServerInterceptor{recovery(logger), requestID, logging(logger)}
+	stream := []grpc.StreamServerInterceptor{streamRecovery(logger)}
+	if a := newAuth(o.apiKeys); len(a.keys) > 0 {
+		unary = append(unary, a.unary)
+		stream = append(stream, a.stream)
+	}
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	)
+
+	userpb.RegisterUserServiceServer(s, newUserServer(sv, logger))
+	h := health.NewServer()
+	healthpb.RegisterHealthServer(s, h)
+	if o.reflection {
+		reflection.Register(s)
+	}
+
+	return &Server{
+		grpcServer: s,
+		health:     h,
+		addr:       fmt.Sprintf(":%s", port),
+		errCh:      make(chan error, 1),
+	}
+}
+
+// Start binds the listener synchronously so that a busy port fails startup,
+// then serves in the background.
+func (s *Server) Start(ctx context.Context) error {
+	ln, err := (&net.ListenConfig{}).Listen(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	s.serve(ln)
+	return nil
+}
+
+func (s *Server) serve(ln net.Listener) {
+	s.health.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	s.health.SetServingStatus(userpb.UserService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	go func() {
+		if err := s.grpcServer.Serve(ln); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			s.errCh <- err
+		}
+		close(s.errCh)
+	}()
+}
+
+// Stop reports NOT_SERVING, then waits for in-flight calls until ctx
+// expires and cuts off the rest.
+func (s *Server) Stop(ctx context.Context) error {
+	s.health.Shutdown()
+	done := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+		return ctx.Err()
+	}
+}
+
+// Err reports a failure of the serve loop after Start returned.
+func (s *Server) Err() <-chan error {
+	return s.errCh
+}
+
+func (s *Server) Hook() lifecycle.Hook {
+	return lifecycle.Hook{
+		Name:    "grpc server",
+		OnStart: s.Start,
+		OnStop:  s.Stop,
+	}
+}
@@ -0,0 +1,130 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"user-domain/infrastructure/grpc/userpb"
+	application_mock "user-domain/internal/application/mocks/outbound"
+	domainerror "user-domain/internal/domain/error"
+	domain_mock "user-domain/internal/domain/mocks/inport"
+	"user-domain/internal/entity"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newTestServer(t *testing.T, opts ...Option) (userpb.UserServiceClient, *grpc.ClientConn, *domain_mock.UserService) {
+	t.Helper()
+	sv := domain_mock.NewUserService(t)
+	logger := application_mock.NewLogger(t)
+	logger.On("WithContext", mock.Anything).Return(logger).Maybe()
+	logger.On("Info", mock.Anything, mock.Anything).Maybe()
+	logger.On("Warn", mock.Anything, mock.Anything).Maybe()
+	logger.On("Error", mock.Anything, mock.Anything).Maybe()
+	logger.On("Error", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe()
+
+	srv := NewServer("0", sv, logger, opts...)
+	ln := bufconn.Listen(1 << 20)
+	srv.serve(ln)
+	t.Cleanup(func() { _ = srv.Stop(context.Background()) })
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return userpb.NewUserServiceClient(conn), conn, sv
+}
+
+func TestGetUser(t *testing.T) {
+	t.Parallel()
+	client, _, sv := newTestServer(t)
+	sv.On("GetUserByID", mock.Anything, "42").Return(&entity.User{ID: "42", Name: "An", Email: "an@example.com"}, nil)
+
+	var header metadata.MD
+	ctx := metadata.AppendToOutgoingContext(context.Background(), requestIDKey, "req-1")
+	res, err := client.GetUser(ctx, &userpb.GetUserRequest{Id: "42"}, grpc.Header(&header))
+	require.NoError(t, err)
+	require.Equal(t, "an@example.com", res.GetUser().GetEmail())
+	require.Equal(t, []string{"req-1"}, header.Get(requestIDKey))
+}
+
+func TestErrorsMapToStatus(t *testing.T) {
+	t.Parallel()
+	client, _, sv := newTestServer(t)
+	cause := errors.New("pq: connection refused to 10.0.0.5")
+	sv.On("GetUserByID", mock.Anything, "42").Return((*entity.User)(nil),
+		domainerror.New(domainerror.ErrCodeNotFound, domainerror.CodeUserNotFound, "user 42 was not found").WithCause(cause))
+	sv.On("DeleteUser", mock.Anything, "42").Return(cause)
+
+	_, err := client.GetUser(context.Background(), &userpb.GetUserRequest{Id: "42"})
+	st := status.Convert(err)
+	require.Equal(t, codes.NotFound, st.Code())
+	require.Equal(t, "user 42 was not found", st.Message())
+	require.Len(t, st.Details(), 1)
+	require.Equal(t, "user_not_found", st.Details()[0].(*errdetails.ErrorInfo).GetReason())
+
+	_, err = client.DeleteUser(context.Background(), &userpb.DeleteUserRequest{Id: "42"})
+	st = status.Convert(err)
+	require.Equal(t, codes.Internal, st.Code())
+	require.NotContains(t, st.Message(), "10.0.0.5")
+}
+
+func TestCreateUserValidatesInput(t *testing.T) {
+	t.Parallel()
+	client, _, _ := newTestServer(t)
+
+	_, err := client.CreateUser(context.Background(), &userpb.CreateUserRequest{Email: "not-an-email"})
+	st := status.Convert(err)
+	require.Equal(t, codes.InvalidArgument, st.Code())
+	var fields []string
+	for _, d := range st.Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			for _, v := range br.GetFieldViolations() {
+				fields = append(fields, v.GetField())
+			}
+		}
+	}
+	require.ElementsMatch(t, []string{"name", "email"}, fields)
+}
+
+func TestAuth(t *testing.T) {
+	t.Parallel()
+	client, conn, sv := newTestServer(t, WithAPIKeys([]string{"secret"}))
+	sv.On("ListUsers", mock.Anything, 0, defaultListLimit).Return([]*entity.User{}, nil)
+
+	_, err := client.ListUsers(context.Background(), &userpb.ListUsersRequest{})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer wrong")
+	_, err = client.ListUsers(ctx, &userpb.ListUsersRequest{})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx = metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")
+	_, err = client.ListUsers(ctx, &userpb.ListUsersRequest{})
+	require.NoError(t, err)
+
+	// Probes do not need credentials.
+	res, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, res.GetStatus())
+}
+
+func TestRecovery(t *testing.T) {
+	t.Parallel()
+	client, _, sv := newTestServer(t)
+	sv.On("UpdateUser", mock.Anything, mock.Anything).Run(func(mock.Arguments) { panic("boom") })
+
+	_, err := client.UpdateUser(context.Background(), &userpb.UpdateUserRequest{Id: "42", Name: "An"})
+	require.Equal(t, codes.Internal, status.Code(err))
+}
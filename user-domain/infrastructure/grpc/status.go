@@ -0,0 +1,80 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	applicationoutbound "user-domain/internal/application/outbound"
+	domainerror "user-domain/internal/domain/error"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+const errorDomain = "user-domain"
+
+// toStatus is the gRPC counterpart of apiutil's Failure: it logs err and
+// returns a status carrying only its code and client-safe message, with
+// the stable error code in an ErrorInfo and invalid fields in a BadRequest.
+func toStatus(ctx context.Context, err error, logger applicationoutbound.Logger) error {
+	var code codes.Code
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, domainerror.ErrCodeNotFound):
+		code = codes.NotFound
+	case errors.Is(err, domainerror.ErrCodeConflict):
+		code = codes.AlreadyExists
+	case errors.Is(err, domainerror.ErrCodeInvalidInput):
+		code = codes.InvalidArgument
+	case errors.Is(err, domainerror.ErrCodeForbidden):
+		code = codes.PermissionDenied
+	default:
+		code = codes.Internal
+	}
+
+	if code == codes.Internal {
+		logger.WithContext(ctx).Error("error: %s", err)
+	} else {
+		logger.WithContext(ctx).Warn("error: %s", err)
+	}
+
+	st := status.New(code, domainerror.MessageOf(err))
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: string(domainerror.CodeOf(err)), Domain: errorDomain}}
+	var domainErr *domainerror.Error
+	if errors.As(err, &domainErr) && len(domainErr.Fields) > 0 {
+		details = append(details, badRequest(domainErr.Fields...))
+	}
+	return withDetails(st, details...)
+}
+
+// invalidArgument rejects a request the service was never called with.
+func invalidArgument(message string, fields ...domainerror.FieldError) error {
+	st := status.New(codes.InvalidArgument, message)
+	return withDetails(st,
+		&errdetails.ErrorInfo{Reason: string(domainerror.CodeValidation), Domain: errorDomain},
+		badRequest(fields...),
+	)
+}
+
+func badRequest(fields ...domainerror.FieldError) *errdetails.BadRequest {
+	br := &errdetails.BadRequest{}
+	for _, f := range fields {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       f.Field,
+			Description: f.Message,
+		})
+	}
+	return br
+}
+
+func withDetails(st *status.Status, details ...protoadapt.MessageV1) error {
+	withDetails, err := st.WithDetails(details...)
+	if err != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
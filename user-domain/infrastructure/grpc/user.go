@@ -0,0 +1,124 @@
+package grpc
+
+import (
+	"context"
+	"net/mail"
+	"user-domain/infrastructure/grpc/userpb"
+	applicationoutbound "user-domain/internal/application/outbound"
+	domainerror "user-domain/internal/domain/error"
+	"user-domain/internal/domain/inport"
+	"user-domain/internal/entity"
+)
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// userServer adapts inport.UserService to the generated gRPC interface. The
+// REST API has its input checked against the OpenAPI spec, the same rules
+// are applied here by hand.
+type userServer struct {
+	userpb.UnimplementedUserServiceServer
+	sv     inport.UserService
+	logger applicationoutbound.Logger
+}
+
+func newUserServer(sv inport.UserService, logger applicationoutbound.Logger) *userServer {
+	return &userServer{sv: sv, logger: logger}
+}
+
+func (s *userServer) CreateUser(ctx context.Context, req *userpb.CreateUserRequest) (*userpb.CreateUserResponse, error) {
+	var fields []domainerror.FieldError
+	if req.GetName() == "" {
+		fields = append(fields, domainerror.FieldError{Field: "name", Message: "is required"})
+	}
+	if req.GetEmail() == "" {
+		fields = append(fields, domainerror.FieldError{Field: "email", Message: "is required"})
+	} else if _, err := mail.ParseAddress(req.GetEmail()); err != nil {
+		fields = append(fields, domainerror.FieldError{Field: "email", Message: "is not a valid email address"})
+	}
+	if len(fields) > 0 {
+		return nil, invalidArgument("request is invalid", fields...)
+	}
+
+	user := &entity.User{
+		Name:    req.GetName(),
+		Email:   req.GetEmail(),
+		Phone:   req.GetPhone(),
+		Address: req.GetAddress(),
+	}
+	if err := s.sv.CreateUser(ctx, user); err != nil {
+		return nil, toStatus(ctx, err, s.logger)
+	}
+	return &userpb.CreateUserResponse{User: toProto(user)}, nil
+}
+
+func (s *userServer) GetUser(ctx context.Context, req *userpb.GetUserRequest) (*userpb.GetUserResponse, error) {
+	if req.GetId() == "" {
+		return nil, invalidArgument("request is invalid", domainerror.FieldError{Field: "id", Message: "is required"})
+	}
+	user, err := s.sv.GetUserByID(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(ctx, err, s.logger)
+	}
+	return &userpb.GetUserResponse{User: toProto(user)}, nil
+}
+
+func (s *userServer) UpdateUser(ctx context.Context, req *userpb.UpdateUserRequest) (*userpb.UpdateUserResponse, error) {
+	if req.GetId() == "" {
+		return nil, invalidArgument("request is invalid", domainerror.FieldError{Field: "id", Message: "is required"})
+	}
+	user := &entity.User{
+		ID:      req.GetId(),
+		Name:    req.GetName(),
+		Phone:   req.GetPhone(),
+		Address: req.GetAddress(),
+	}
+	if err := s.sv.UpdateUser(ctx, user); err != nil {
+		return nil, toStatus(ctx, err, s.logger)
+	}
+	return &userpb.UpdateUserResponse{}, nil
+}
+
+func (s *userServer) DeleteUser(ctx context.Context, req *userpb.DeleteUserRequest) (*userpb.DeleteUserResponse, error) {
+	if req.GetId() == "" {
+		return nil, invalidArgument("request is invalid", domainerror.FieldError{Field: "id", Message: "is required"})
+	}
+	if err := s.sv.DeleteUser(ctx, req.GetId()); err != nil {
+		return nil, toStatus(ctx, err, s.logger)
+	}
+	return &userpb.DeleteUserResponse{}, nil
+}
+
+func (s *userServer) ListUsers(ctx context.Context, req *userpb.ListUsersRequest) (*userpb.ListUsersResponse, error) {
+	if req.GetOffset() < 0 {
+		return nil, invalidArgument("request is invalid", domainerror.FieldError{Field: "offset", Message: "must not be negative"})
+	}
+	limit := int(req.GetLimit())
+	switch {
+	case limit <= 0:
+		limit = defaultListLimit
+	case limit > maxListLimit:
+		limit = maxListLimit
+	}
+	users, err := s.sv.ListUsers(ctx, int(req.GetOffset()), limit)
+	if err != nil {
+		return nil, toStatus(ctx, err, s.logger)
+	}
+	res := &userpb.ListUsersResponse{Users: make([]*userpb.User, 0, len(users))}
+	for _, u := range users {
+		res.Users = append(res.Users, toProto(u))
+	}
+	return res, nil
+}
+
+func toProto(u *entity.User) *userpb.User {
+	return &userpb.User{
+		Id:      u.ID,
+		Name:    u.Name,
+		Email:   u.Email,
+		Phone:   u.Phone,
+		Address: u.Address,
+	}
+}
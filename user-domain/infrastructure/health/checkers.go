@@ -0,0 +1,74 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// PingChecker adapts any dependency that can be pinged, such as a database
+// pool, a cache client or a message broker connection.
+func PingChecker(p Pinger) Checker {
+	return CheckerFunc(p.PingContext)
+}
+
+type migrationChecker struct {
+	db     *sql.DB
+	latest uint
+}
+
+// NewMigrationChecker reports the database as not ready while it is behind
+// the newest migration in src or left dirty by a failed run.
+func NewMigrationChecker(db *sql.DB, src source.Driver) (Checker, error) {
+	latest, err := latestVersion(src)
+	if err != nil {
+		return nil, fmt.Errorf("health: read migrations: %w", err)
+	}
+	return &migrationChecker{db: db, latest: latest}, nil
+}
+
+func (c *migrationChecker) Check(ctx context.Context) error {
+	var (
+		version uint
+		dirty   bool
+	)
+	err := c.db.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("no migrations applied, latest is %d", c.latest)
+	}
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("migration %d is dirty", version)
+	}
+	if version < c.latest {
+		return fmt.Errorf("pending migrations: database at %d, latest is %d", version, c.latest)
+	}
+	return nil
+}
+
+func latestVersion(src source.Driver) (uint, error) {
+	version, err := src.First()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		version = next
+	}
+}
@@ -0,0 +1,186 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusUp   Status = "up"
+	StatusDown Status = "down"
+)
+
+var ErrShuttingDown = errors.New("service is shutting down")
+
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+type CheckResult struct {
+	Name     string `json:"name"`
+	Status   Status `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+type Report struct {
+	Status Status        `json:"status"`
+	Error  string        `json:"error,omitempty"`
+	Checks []CheckResult `json:"checks,omitempty"`
+}
+
+type check struct {
+	name    string
+	checker Checker
+	timeout time.Duration
+}
+
+type CheckOption func(*check)
+
+// WithTimeout overrides the default timeout for a single check.
+func WithTimeout(d time.Duration) CheckOption {
+	return func(c *check) {
+		c.timeout = d
+	}
+}
+
+// Health aggregates liveness and readiness checks. Liveness should only
+// contain checks whose failure a restart can fix, dependencies belong to
+// readiness so that an outage takes the pod out of rotation instead.
+type Health struct {
+	mu             sync.RWMutex
+	liveness       []check
+	readiness      []check
+	defaultTimeout time.Duration
+	shuttingDown   atomic.Bool
+}
+
+func New(defaultTimeout time.Duration) *Health {
+	return &Health{defaultTimeout: defaultTimeout}
+}
+
+func (h *Health) AddLivenessCheck(name string, c Checker, opts ...CheckOption) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.liveness = append(h.liveness, h.newCheck(name, c, opts))
+}
+
+func (h *Health) AddReadinessCheck(name string, c Checker, opts ...CheckOption) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.readiness = append(h.readiness, h.newCheck(name, c, opts))
+}
+
+// SetShuttingDown makes readiness fail immediately so that load balancers
+// stop sending traffic while in-flight requests drain.
+func (h *Health) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+func (h *Health) Liveness(ctx context.Context) Report {
+	h.mu.RLock()
+	checks := h.liveness
+	h.mu.RUnlock()
+	return run(ctx, checks)
+}
+
+func (h *Health) Readiness(ctx context.Context) Report {
+	if h.shuttingDown.Load() {
+		return Report{Status: StatusDown, Error: ErrShuttingDown.Error()}
+	}
+	h.mu.RLock()
+	checks := h.readiness
+	h.mu.RUnlock()
+	return run(ctx, checks)
+}
+
+func (h *Health) LivenessHandler() http.Handler {
+	return reportHandler(h.Liveness)
+}
+
+func (h *Health) ReadinessHandler() http.Handler {
+	return reportHandler(h.Readiness)
+}
+
+func (h *Health) newCheck(name string, c Checker, opts []CheckOption) check {
+	ch := check{name: name, checker: c, timeout: h.defaultTimeout}
+	for _, opt := range opts {
+		opt(&ch)
+	}
+	return ch
+}
+
+func run(ctx context.Context, checks []check) Report {
+	report := Report{Status: StatusUp, Checks: make([]CheckResult, len(checks))}
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c check) {
+			defer wg.Done()
+			report.Checks[i] = runCheck(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+	for _, res := range report.Checks {
+		if res.Status == StatusDown {
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+func runCheck(ctx context.Context, c check) CheckResult {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- c.checker.Check(ctx)
+	}()
+
+	var err error
+	// A checker that ignores its context must not hold the probe hostage.
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	res := CheckResult{Name: c.name, Status: StatusUp, Duration: time.Since(start).String()}
+	if err != nil {
+		res.Status = StatusDown
+		res.Error = err.Error()
+	}
+	return res
+}
+
+func reportHandler(fn func(ctx context.Context) Report) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := fn(r.Context())
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		if report.Status == StatusUp {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
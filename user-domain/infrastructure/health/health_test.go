@@ -0,0 +1,159 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/stub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadinessReport(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		checks     map[string]Checker
+		wantCode   int
+		wantStatus Status
+	}{
+		{
+			name:       "no checks",
+			wantCode:   http.StatusOK,
+			wantStatus: StatusUp,
+		},
+		{
+			name: "all up",
+			checks: map[string]Checker{
+				"postgres": CheckerFunc(func(context.Context) error { return nil }),
+			},
+			wantCode:   http.StatusOK,
+			wantStatus: StatusUp,
+		},
+		{
+			name: "one down",
+			checks: map[string]Checker{
+				"postgres": CheckerFunc(func(context.Context) error { return nil }),
+				"broker":   CheckerFunc(func(context.Context) error { return errors.New("connection refused") }),
+			},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: StatusDown,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			h := New(time.Second)
+			for name, c := range tt.checks {
+				h.AddReadinessCheck(name, c)
+			}
+			w := httptest.NewRecorder()
+			h.ReadinessHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			require.Equal(t, tt.wantCode, w.Code)
+			require.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+			var report Report
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&report))
+			require.Equal(t, tt.wantStatus, report.Status)
+			require.Len(t, report.Checks, len(tt.checks))
+		})
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	t.Parallel()
+	h := New(time.Hour)
+	block := make(chan struct{})
+	t.Cleanup(func() { close(block) })
+	h.AddReadinessCheck("stuck", CheckerFunc(func(context.Context) error {
+		<-block
+		return nil
+	}), WithTimeout(10*time.Millisecond))
+
+	report := h.Readiness(t.Context())
+	require.Equal(t, StatusDown, report.Status)
+	require.Equal(t, context.DeadlineExceeded.Error(), report.Checks[0].Error)
+}
+
+func TestReadinessFailsWhileShuttingDown(t *testing.T) {
+	t.Parallel()
+	h := New(time.Second)
+	h.AddLivenessCheck("noop", CheckerFunc(func(context.Context) error { return nil }))
+	h.AddReadinessCheck("noop", CheckerFunc(func(context.Context) error { return nil }))
+
+	h.SetShuttingDown()
+
+	require.Equal(t, StatusDown, h.Readiness(t.Context()).Status)
+	require.Equal(t, StatusUp, h.Liveness(t.Context()).Status)
+}
+
+func TestMigrationChecker(t *testing.T) {
+	t.Parallel()
+	query := regexp.QuoteMeta(`SELECT version, dirty FROM schema_migrations LIMIT 1`)
+	tests := []struct {
+		name    string
+		rows    *sqlmock.Rows
+		wantErr string
+	}{
+		{
+			name: "up to date",
+			rows: sqlmock.NewRows([]string{"version", "dirty"}).AddRow(3, false),
+		},
+		{
+			name:    "pending",
+			rows:    sqlmock.NewRows([]string{"version", "dirty"}).AddRow(1, false),
+			wantErr: "pending migrations: database at 1, latest is 3",
+		},
+		{
+			name:    "dirty",
+			rows:    sqlmock.NewRows([]string{"version", "dirty"}).AddRow(3, true),
+			wantErr: "migration 3 is dirty",
+		},
+		{
+			name:    "never migrated",
+			rows:    sqlmock.NewRows([]string{"version", "dirty"}),
+			wantErr: "no migrations applied, latest is 3",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			db, m, err := sqlmock.New()
+			require.NoError(t, err)
+			m.ExpectQuery(query).WillReturnRows(tt.rows)
+
+			src := newStubSource(t, 1, 2, 3)
+			c, err := NewMigrationChecker(db, src)
+			require.NoError(t, err)
+
+			err = c.Check(t.Context())
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			require.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+func newStubSource(t *testing.T, versions ...uint) source.Driver {
+	t.Helper()
+	src, err := (&stub.Stub{}).Open("stub://")
+	require.NoError(t, err)
+	migrations := source.NewMigrations()
+	for _, v := range versions {
+		migrations.Append(&source.Migration{Version: v, Direction: source.Up, Identifier: "m"})
+	}
+	src.(*stub.Stub).Migrations = migrations
+	return src
+}
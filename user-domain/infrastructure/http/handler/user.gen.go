@@ -6,12 +6,45 @@ package handler
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/oapi-codegen/runtime"
 	openapi_types "github.com/oapi-codegen/runtime/types"
 )
 
+// Defines values for PersonalDataRequestKind.
+const (
+	Erasure PersonalDataRequestKind = "erasure"
+	Export  PersonalDataRequestKind = "export"
+)
+
+// PersonalDataRequest defines model for PersonalDataRequest.
+type PersonalDataRequest struct {
+	Id          string                  `json:"id"`
+	Kind        PersonalDataRequestKind `json:"kind"`
+	RequestedAt time.Time               `json:"requested_at"`
+}
+
+// PersonalDataRequestKind defines model for PersonalDataRequest.Kind.
+type PersonalDataRequestKind string
+
+// PersonalDataResponse Everything stored about a user
+type PersonalDataResponse struct {
+	CreatedAt time.Time `json:"created_at"`
+
+	// DeletedAt Set once the user is deleted
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+
+	// ErasedAt Set once the personal data of the user is erased
+	ErasedAt *time.Time `json:"erased_at,omitempty"`
+
+	// Requests Data-subject requests made about the user, oldest first
+	Requests  []PersonalDataRequest `json:"requests"`
+	UpdatedAt time.Time             `json:"updated_at"`
+	User      UserResponse          `json:"user"`
+}
+
 // UserPost defines model for UserPost.
 type UserPost struct {
 	// Address Address (optional)
@@ -89,6 +122,15 @@ type ServerInterface interface {
 	// Update specific user
 	// (PUT /users/{user_id})
 	PutUsersUserId(w http.ResponseWriter, r *http.Request, userId string)
+	// Erase the personal data of a user
+	// (DELETE /users/{user_id}/personal-data)
+	DeleteUsersUserIdPersonalData(w http.ResponseWriter, r *http.Request, userId string)
+	// Export the personal data of a user
+	// (GET /users/{user_id}/personal-data)
+	GetUsersUserIdPersonalData(w http.ResponseWriter, r *http.Request, userId string)
+	// Restore a deleted user
+	// (POST /users/{user_id}/restore)
+	PostUsersUserIdRestore(w http.ResponseWriter, r *http.Request, userId string)
 }
 
 // Unimplemented server implementation that returns http.StatusNotImplemented for each endpoint.
@@ -125,6 +167,24 @@ func (_ Unimplemented) PutUsersUserId(w http.ResponseWriter, r *http.Request, us
 	w.WriteHeader(http.StatusNotImplemented)
 }
 
+// Erase the personal data of a user
+// (DELETE /users/{user_id}/personal-data)
+func (_ Unimplemented) DeleteUsersUserIdPersonalData(w http.ResponseWriter, r *http.Request, userId string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Export the personal data of a user
+// (GET /users/{user_id}/personal-data)
+func (_ Unimplemented) GetUsersUserIdPersonalData(w http.ResponseWriter, r *http.Request, userId string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Restore a deleted user
+// (POST /users/{user_id}/restore)
+func (_ Unimplemented) PostUsersUserIdRestore(w http.ResponseWriter, r *http.Request, userId string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
 // ServerInterfaceWrapper converts contexts to parameters.
 type ServerInterfaceWrapper struct {
 	Handler            ServerInterface
@@ -258,6 +318,81 @@ func (siw *ServerInterfaceWrapper) PutUsersUserId(w http.ResponseWriter, r *http
 	handler.ServeHTTP(w, r)
 }
 
+// DeleteUsersUserIdPersonalData operation middleware
+func (siw *ServerInterfaceWrapper) DeleteUsersUserIdPersonalData(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "user_id" -------------
+	var userId string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "user_id", chi.URLParam(r, "user_id"), &userId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "user_id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.DeleteUsersUserIdPersonalData(w, r, userId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetUsersUserIdPersonalData operation middleware
+func (siw *ServerInterfaceWrapper) GetUsersUserIdPersonalData(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "user_id" -------------
+	var userId string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "user_id", chi.URLParam(r, "user_id"), &userId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "user_id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetUsersUserIdPersonalData(w, r, userId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// PostUsersUserIdRestore operation middleware
+func (siw *ServerInterfaceWrapper) PostUsersUserIdRestore(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "user_id" -------------
+	var userId string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "user_id", chi.URLParam(r, "user_id"), &userId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "user_id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostUsersUserIdRestore(w, r, userId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
 type UnescapedCookieParamError struct {
 	ParamName string
 	Err       error
@@ -386,6 +521,15 @@ func HandlerWithOptions(si ServerInterface, options ChiServerOptions) http.Handl
 	r.Group(func(r chi.Router) {
 		r.Put(options.BaseURL+"/users/{user_id}", wrapper.PutUsersUserId)
 	})
+	r.Group(func(r chi.Router) {
+		r.Delete(options.BaseURL+"/users/{user_id}/personal-data", wrapper.DeleteUsersUserIdPersonalData)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/users/{user_id}/personal-data", wrapper.GetUsersUserIdPersonalData)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/users/{user_id}/restore", wrapper.PostUsersUserIdRestore)
+	})
 
 	return r
 }
@@ -0,0 +1,192 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"user-domain/internal/application/controller/apiutil"
+	applicationoutbound "user-domain/internal/application/outbound"
+	domainerror "user-domain/internal/domain/error"
+
+	"github.com/google/uuid"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	maxIdempotentRequestBytes = 1 << 20
+)
+
+// IdempotentResponse is what an IdempotencyStore keeps for a key. Until the
+// first request finishes only Fingerprint is set and Completed is false.
+type IdempotentResponse struct {
+	Fingerprint string
+	Completed   bool
+	Status      int
+	Header      http.Header
+	Body        []byte
+}
+
+// IdempotencyStore keeps the responses of idempotent requests. owner is a
+// token unique to the request that claims a key; once its lock has timed
+// out and another request took the key over, Complete and Release of the
+// first owner do nothing.
+type IdempotencyStore interface {
+	// Claim locks key for a new request. If the key is already known it
+	// returns the stored response, completed or still in flight, and false.
+	Claim(ctx context.Context, key, owner, fingerprint string) (*IdempotentResponse, bool, error)
+	// Complete stores the response of the request holding the lock on key,
+	// if owner still holds it.
+	Complete(ctx context.Context, key, owner string, resp *IdempotentResponse) error
+	// Release drops the lock owner holds on key without storing anything,
+	// so that the request can be retried.
+	Release(ctx context.Context, key, owner string) error
+}
+
+// Idempotency makes mutating requests that carry an Idempotency-Key header
+// safe to retry. The first request with a key runs and its response is
+// stored; repeats get the stored response, a repeat with a different method,
+// URL or body is rejected with an idempotency_key_reused problem and one arriving while the first is still
+// running gets idempotency_key_in_progress. Server errors are not stored so they can be retried.
+// Keys are scoped to the client named by client, so that one client cannot
+// replay, or block, the requests of another that chose the same key.
+func Idempotency(store IdempotencyStore, client KeyFunc, logger applicationoutbound.Logger) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" || !mutating(r.Method) {
+				h.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				apiutil.WriteProblem(w, apiutil.NewProblem(r, http.StatusBadRequest, domainerror.CodeInvalidInput, "Idempotency-Key is too long"))
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentRequestBytes))
+			if err != nil {
+				apiutil.WriteProblem(w, apiutil.NewProblem(r, http.StatusRequestEntityTooLarge, domainerror.CodeInvalidInput, "request body is too large"))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			fingerprint := fingerprint(r, body)
+			key = storeKey(r, client, key)
+
+			ctx := r.Context()
+			owner := uuid.NewString()
+			stored, claimed, err := store.Claim(ctx, key, owner, fingerprint)
+			if err != nil {
+				logger.WithContext(ctx).Error("claim idempotency key: %s", err)
+				apiutil.WriteProblem(w, apiutil.NewProblem(r, http.StatusServiceUnavailable, domainerror.CodeInternal, "the request could not be made idempotent, retry later"))
+				return
+			}
+			if !claimed {
+				switch {
+				case stored.Fingerprint != fingerprint:
+					apiutil.WriteProblem(w, apiutil.NewProblem(r, http.StatusUnprocessableEntity, domainerror.CodeIdempotencyReuse, "Idempotency-Key was already used for a different request"))
+				case !stored.Completed:
+					w.Header().Set("Retry-After", "1")
+					apiutil.WriteProblem(w, apiutil.NewProblem(r, http.StatusConflict, domainerror.CodeIdempotencyBusy, "a request with this Idempotency-Key is in progress"))
+				default:
+					replay(w, stored)
+				}
+				return
+			}
+
+			rec := &recordingWriter{ResponseWriter: w}
+			defer func() {
+				// The request context may be gone by now, the key must be
+				// settled regardless.
+				ctx := context.WithoutCancel(ctx)
+				if p := recover(); p != nil {
+					if err := store.Release(ctx, key, owner); err != nil {
+						logger.WithContext(ctx).Error("release idempotency key: %s", err)
+					}
+					panic(p)
+				}
+				if rec.Status() >= http.StatusInternalServerError {
+					err = store.Release(ctx, key, owner)
+				} else {
+					err = store.Complete(ctx, key, owner, &IdempotentResponse{
+						Fingerprint: fingerprint,
+						Completed:   true,
+						Status:      rec.Status(),
+						Header:      rec.header,
+						Body:        rec.body.Bytes(),
+					})
+				}
+				if err != nil {
+					logger.WithContext(ctx).Error("store idempotent response: %s", err)
+				}
+			}()
+			h.ServeHTTP(rec, r)
+		})
+	}
+}
+
+func mutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// storeKey hashes key together with the client, which keeps the stored key
+// within the column and the client identity out of the store.
+func storeKey(r *http.Request, client KeyFunc, key string) string {
+	id, _ := client(r)
+	sum := sha256.Sum256([]byte(id + "\n" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+func fingerprint(r *http.Request, body []byte) string {
+	sum := sha256.New()
+	io.WriteString(sum, r.Method+" "+r.URL.RequestURI()+"\n")
+	sum.Write(body)
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+func replay(w http.ResponseWriter, resp *IdempotentResponse) {
+	for k, v := range resp.Header {
+		w.Header()[k] = v
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(resp.Status)
+	_, _ = w.Write(resp.Body)
+}
+
+// recordingWriter passes the response through and keeps a copy of it.
+type recordingWriter struct {
+	http.ResponseWriter
+	code   int
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(statusCode int) {
+	if w.code == 0 {
+		w.code = statusCode
+		w.header = w.Header().Clone()
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) Status() int {
+	if w.code == 0 {
+		return http.StatusOK
+	}
+	return w.code
+}
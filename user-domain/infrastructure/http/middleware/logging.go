@@ -19,11 +19,23 @@ func newResponseWriterWrapper(w http.ResponseWriter) *responseWriterWrapper {
 }
 
 func (w *responseWriterWrapper) WriteHeader(statusCode int) {
-	w.code = statusCode
+	if w.code == 0 {
+		w.code = statusCode
+	}
 	w.ResponseWriter.WriteHeader(statusCode)
 }
 
+func (w *responseWriterWrapper) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
 func (w *responseWriterWrapper) Status() int {
+	if w.code == 0 {
+		return http.StatusOK
+	}
 	return w.code
 }
 
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+)
+
+const unmatchedRoute = "unmatched"
+
+type HTTPObserver interface {
+	ObserveHTTPRequest(method, route string, status int, elapsed time.Duration)
+}
+
+// Metrics reports the duration of every request labelled with its chi route
+// template, requests that did not match any route share a single label.
+func Metrics(observer HTTPObserver) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			wrapped := newResponseWriterWrapper(w)
+			startTime := time.Now()
+			h.ServeHTTP(wrapped, r)
+
+			route := routePattern(r)
+			if route == "" {
+				route = unmatchedRoute
+			}
+			observer.ObserveHTTPRequest(r.Method, route, wrapped.Status(), time.Since(startTime))
+		})
+	}
+}
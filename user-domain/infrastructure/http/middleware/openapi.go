@@ -0,0 +1,195 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"user-domain/internal/application/controller/apiutil"
+	applicationoutbound "user-domain/internal/application/outbound"
+	domainerror "user-domain/internal/domain/error"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+)
+
+// FieldError points at the part of a request that failed validation.
+// Location is body, path, query, header or cookie; Field is the parameter
+// name or, for the body, the dotted path to the offending property.
+type FieldError = domainerror.FieldError
+
+var defineFormats sync.Once
+
+// LoadOpenAPI parses and validates spec and serves its paths under basePath.
+func LoadOpenAPI(spec []byte, basePath string) (routers.Router, error) {
+	// Formats are only checked once a validator is registered for them.
+	defineFormats.Do(func() {
+		openapi3.DefineStringFormatValidator("email", openapi3.NewRegexpFormatValidator(openapi3.FormatOfStringForEmail))
+	})
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(spec)
+	if err != nil {
+		return nil, fmt.Errorf("load openapi spec: %w", err)
+	}
+	if err := doc.Validate(loader.Context); err != nil {
+		return nil, fmt.Errorf("invalid openapi spec: %w", err)
+	}
+	doc.Servers = openapi3.Servers{{URL: basePath}}
+	return gorillamux.NewRouter(doc)
+}
+
+// OpenAPIValidation rejects requests that do not match the operation they
+// are routed to with a 400 validation_failed problem listing the invalid
+// fields. Requests the spec does
+// not describe are left to the router. With validateResponses set every
+// response is checked too and mismatches are logged; it buffers responses
+// and is meant for development.
+func OpenAPIValidation(router routers.Router, validateResponses bool, logger applicationoutbound.Logger) func(http.Handler) http.Handler {
+	options := &openapi3filter.Options{
+		MultiError:         true,
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+	}
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, pathParams, err := router.FindRoute(r)
+			if err != nil {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			input := &openapi3filter.RequestValidationInput{
+				Request:    r,
+				PathParams: pathParams,
+				Route:      route,
+				Options:    options,
+			}
+			if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+				writeValidationError(w, r, fieldErrors(err))
+				return
+			}
+			// ValidateRequest consumed the body and put back a copy.
+
+			if !validateResponses {
+				h.ServeHTTP(w, r)
+				return
+			}
+			rec := &bufferedWriter{header: http.Header{}}
+			h.ServeHTTP(rec, r)
+			validateResponse(r.Context(), input, rec, logger)
+			rec.flush(w)
+		})
+	}
+}
+
+func validateResponse(ctx context.Context, input *openapi3filter.RequestValidationInput, rec *bufferedWriter, logger applicationoutbound.Logger) {
+	err := openapi3filter.ValidateResponse(ctx, &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 rec.Status(),
+		Header:                 rec.header,
+		Body:                   io.NopCloser(bytes.NewReader(rec.body.Bytes())),
+		Options:                &openapi3filter.Options{MultiError: true, IncludeResponseStatus: true},
+	})
+	if err != nil {
+		logger.WithContext(ctx).Error("response of %s %s does not match the openapi spec: %s",
+			input.Request.Method, input.Route.Path, err)
+	}
+}
+
+func writeValidationError(w http.ResponseWriter, r *http.Request, errs []FieldError) {
+	p := apiutil.NewProblem(r, http.StatusBadRequest, domainerror.CodeValidation, "request does not match the API specification")
+	p.Errors = errs
+	apiutil.WriteProblem(w, p)
+}
+
+// fieldErrors flattens the errors of openapi3filter into one entry per
+// offending field.
+func fieldErrors(err error) []FieldError {
+	if multi, ok := err.(openapi3.MultiError); ok {
+		var out []FieldError
+		for _, e := range multi {
+			out = append(out, fieldErrors(e)...)
+		}
+		return out
+	}
+
+	var reqErr *openapi3filter.RequestError
+	if !errors.As(err, &reqErr) {
+		return []FieldError{{Location: "request", Message: err.Error()}}
+	}
+
+	base := FieldError{Location: "body"}
+	if reqErr.Parameter != nil {
+		base = FieldError{Location: reqErr.Parameter.In, Field: reqErr.Parameter.Name}
+	}
+	if reqErr.Err == nil {
+		base.Message = reqErr.Reason
+		return []FieldError{base}
+	}
+	var multi openapi3.MultiError
+	if errors.As(reqErr.Err, &multi) {
+		var out []FieldError
+		for _, e := range multi {
+			out = append(out, schemaFieldError(base, e))
+		}
+		return out
+	}
+	return []FieldError{schemaFieldError(base, reqErr.Err)}
+}
+
+func schemaFieldError(base FieldError, err error) FieldError {
+	var schemaErr *openapi3.SchemaError
+	if !errors.As(err, &schemaErr) {
+		base.Message = err.Error()
+		return base
+	}
+	if pointer := schemaErr.JSONPointer(); len(pointer) > 0 && base.Location == "body" {
+		base.Field = strings.Join(pointer, ".")
+	}
+	base.Message = schemaErr.Reason
+	return base
+}
+
+// bufferedWriter holds the response back until it has been validated.
+type bufferedWriter struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func (w *bufferedWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedWriter) WriteHeader(statusCode int) {
+	if w.code == 0 {
+		w.code = statusCode
+	}
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+func (w *bufferedWriter) Status() int {
+	if w.code == 0 {
+		return http.StatusOK
+	}
+	return w.code
+}
+
+func (w *bufferedWriter) flush(dst http.ResponseWriter) {
+	for k, v := range w.header {
+		dst.Header()[k] = v
+	}
+	dst.WriteHeader(w.Status())
+	_, _ = dst.Write(w.body.Bytes())
+}
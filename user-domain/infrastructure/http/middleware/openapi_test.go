@@ -0,0 +1,100 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"user-domain/infrastructure/http/middleware"
+	"user-domain/internal/application/controller/apiutil"
+	application_mock "user-domain/internal/application/mocks/outbound"
+	domainerror "user-domain/internal/domain/error"
+	"user-domain/schemas"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newValidated(t *testing.T, validateResponses bool, h http.HandlerFunc) (http.Handler, *application_mock.Logger) {
+	spec, err := middleware.LoadOpenAPI(schemas.User, "/api/v1")
+	require.NoError(t, err)
+	logger := application_mock.NewLogger(t)
+	return middleware.OpenAPIValidation(spec, validateResponses, logger)(h), logger
+}
+
+func TestOpenAPIValidationRejectsInvalidBody(t *testing.T) {
+	t.Parallel()
+	h, _ := newValidated(t, false, func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("invalid request reached the handler")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users", strings.NewReader(`{"email":"not-an-email"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Equal(t, apiutil.ProblemContentType, rec.Header().Get("Content-Type"))
+	var body apiutil.Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Equal(t, domainerror.CodeValidation, body.Code)
+	require.Equal(t, http.StatusBadRequest, body.Status)
+	require.Len(t, body.Errors, 2)
+	fields := map[string]string{}
+	for _, e := range body.Errors {
+		require.Equal(t, "body", e.Location)
+		fields[e.Field] = e.Message
+	}
+	require.Contains(t, fields, "email")
+	require.Contains(t, fields, "name")
+}
+
+func TestOpenAPIValidationRejectsInvalidQuery(t *testing.T) {
+	t.Parallel()
+	h, _ := newValidated(t, false, func(w http.ResponseWriter, r *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/users?limit=ten", nil))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	var body apiutil.Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Equal(t, []middleware.FieldError{{Location: "query", Field: "limit", Message: body.Errors[0].Message}}, body.Errors)
+}
+
+func TestOpenAPIValidationPassesValidAndUnknownRequests(t *testing.T) {
+	t.Parallel()
+	var got string
+	h, _ := newValidated(t, false, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got = string(b)
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users", strings.NewReader(`{"name":"An","email":"an@example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	require.JSONEq(t, `{"name":"An","email":"an@example.com"}`, got)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusCreated, rec.Code)
+}
+
+func TestOpenAPIValidationLogsInvalidResponses(t *testing.T) {
+	t.Parallel()
+	h, logger := newValidated(t, true, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"1","name":"An"}`))
+	})
+	logger.On("WithContext", mock.Anything).Return(logger)
+	logger.On("Error", mock.Anything, http.MethodGet, "/users/{user_id}", mock.Anything).Once()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/users/1", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"id":"1","name":"An"}`, rec.Body.String())
+}
@@ -0,0 +1,213 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"user-domain/internal/application/controller/apiutil"
+	applicationoutbound "user-domain/internal/application/outbound"
+	domainerror "user-domain/internal/domain/error"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+// Limit allows Requests per Window, spent at most all at once.
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// ParseLimit parses "<requests>/<window>", e.g. "100/1m".
+func ParseLimit(s string) (Limit, error) {
+	n, w, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("rate limit %q: want <requests>/<window>", s)
+	}
+	requests, err := strconv.Atoi(n)
+	if err != nil || requests < 1 {
+		return Limit{}, fmt.Errorf("rate limit %q: requests must be a positive integer", s)
+	}
+	window, err := time.ParseDuration(w)
+	if err != nil || window <= 0 {
+		return Limit{}, fmt.Errorf("rate limit %q: window must be a positive duration", s)
+	}
+	if window/time.Duration(requests) < time.Millisecond {
+		return Limit{}, fmt.Errorf("rate limit %q: more than one request per millisecond", s)
+	}
+	return Limit{Requests: requests, Window: window}, nil
+}
+
+func (l Limit) String() string {
+	return fmt.Sprintf("%d/%s", l.Requests, l.Window)
+}
+
+// RateLimits holds the limit of every route. Routes are keyed by method and
+// chi pattern, e.g. "POST /api/v1/users"; the others get Default.
+type RateLimits struct {
+	Default Limit
+	Routes  map[string]Limit
+}
+
+// ParseRouteLimits parses a comma-separated list of
+// "<METHOD> <pattern>=<requests>/<window>" entries.
+func ParseRouteLimits(s string) (map[string]Limit, error) {
+	routes := map[string]Limit{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		route, limit, ok := strings.Cut(entry, "=")
+		method, pattern, hasPattern := strings.Cut(strings.TrimSpace(route), " ")
+		if !ok || !hasPattern {
+			return nil, fmt.Errorf("route limit %q: want <METHOD> <pattern>=<requests>/<window>", entry)
+		}
+		l, err := ParseLimit(limit)
+		if err != nil {
+			return nil, err
+		}
+		routes[strings.ToUpper(method)+" "+strings.TrimSpace(pattern)] = l
+	}
+	return routes, nil
+}
+
+type RateLimitResult struct {
+	Allowed   bool
+	Remaining int
+	// Reset is how long until the full limit is available again.
+	Reset time.Duration
+	// RetryAfter is how long a rejected client has to wait.
+	RetryAfter time.Duration
+}
+
+type RateLimitStore interface {
+	Allow(ctx context.Context, key string, limit Limit) (RateLimitResult, error)
+}
+
+// KeyFunc names the client a request is counted against, false means it
+// cannot tell.
+type KeyFunc func(r *http.Request) (string, bool)
+
+// KeyByIP counts requests against the client address. Run it after RealIP,
+// which only takes the address from forwarding headers sent by a trusted
+// proxy; a client could otherwise get a fresh bucket with every request.
+func KeyByIP(r *http.Request) (string, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host, host != ""
+}
+
+// KeyByAPIKey counts requests against the X-API-Key header. The key is
+// hashed so that it never reaches the store in clear. It is not checked
+// here: a client sending a new key with every request is never limited, so
+// use it only behind a gateway that rejects unknown keys and otherwise
+// count authenticated keys through KeyByPrincipal.
+func KeyByAPIKey(r *http.Request) (string, bool) {
+	key := r.Header.Get(APIKeyHeader)
+	if key == "" {
+		return "", false
+	}
+	sum := sha256.Sum256([]byte(key))
+	return "key:" + hex.EncodeToString(sum[:16]), true
+}
+
+// KeyByPrincipal counts requests against the principal an authentication
+// middleware stored with WithPrincipal.
+func KeyByPrincipal(r *http.Request) (string, bool) {
+	p, ok := PrincipalFrom(r.Context())
+	return "principal:" + p, ok
+}
+
+// FirstKey uses the first of keys that identifies the client.
+func FirstKey(keys ...KeyFunc) KeyFunc {
+	return func(r *http.Request) (string, bool) {
+		for _, key := range keys {
+			if k, ok := key(r); ok {
+				return k, true
+			}
+		}
+		return "", false
+	}
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal string) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+func PrincipalFrom(ctx context.Context) (string, bool) {
+	p, ok := ctx.Value(principalKey{}).(string)
+	return p, ok && p != ""
+}
+
+// RateLimit rejects clients that exceed the limit of the route they call
+// with a 429 rate_limited problem and a Retry-After header. Every limited response carries the
+// RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and RateLimit-Policy
+// headers of the IETF draft. Requests are let through when the store fails.
+func RateLimit(store RateLimitStore, limits RateLimits, key KeyFunc, logger applicationoutbound.Logger) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client, ok := key(r)
+			if !ok {
+				h.ServeHTTP(w, r)
+				return
+			}
+			route := findRoute(r)
+			limit, ok := limits.Routes[route]
+			if !ok {
+				limit = limits.Default
+				route = "default"
+			}
+
+			res, err := store.Allow(r.Context(), route+"|"+client, limit)
+			if err != nil {
+				logger.WithContext(r.Context()).Error("rate limit store: %s", err)
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			header := w.Header()
+			header.Set("RateLimit-Limit", strconv.Itoa(limit.Requests))
+			header.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			header.Set("RateLimit-Reset", strconv.Itoa(seconds(res.Reset)))
+			header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Requests, seconds(limit.Window)))
+			if !res.Allowed {
+				header.Set("Retry-After", strconv.Itoa(seconds(res.RetryAfter)))
+				apiutil.WriteProblem(w, apiutil.NewProblem(r, http.StatusTooManyRequests, domainerror.CodeRateLimited,
+					fmt.Sprintf("rate limit of %d requests per %s exceeded", limit.Requests, limit.Window)))
+				return
+			}
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+// findRoute resolves the pattern the request will be routed to, which chi
+// only fills in after routing.
+func findRoute(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.Routes == nil {
+		return ""
+	}
+	pattern := rctx.Routes.Find(chi.NewRouteContext(), r.Method, r.URL.Path)
+	if pattern == "" {
+		return ""
+	}
+	return r.Method + " " + pattern
+}
+
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+	applicationoutbound "user-domain/internal/application/outbound"
+)
+
+const (
+	LastWriteHeader = "X-Last-Write-LSN"
+	lastWriteCookie = "last_write_lsn"
+	// Replicas normally catch up within seconds, past this the cookie only
+	// costs bytes.
+	lastWriteMaxAge = 5 * time.Minute
+)
+
+type WriteTracker interface {
+	Begin(ctx context.Context, lastWrite string) context.Context
+	LastWrite(ctx context.Context) (string, bool, error)
+	// Primary returns ctx with its reads served by the primary.
+	Primary(ctx context.Context) context.Context
+}
+
+// ReadYourWrites hands the write position a client last saw, taken from the
+// X-Last-Write-LSN header or the last_write_lsn cookie, to the tracker, and
+// returns the new position in both after a request that wrote.
+func ReadYourWrites(tracker WriteTracker, logger applicationoutbound.Logger) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lastWrite := r.Header.Get(LastWriteHeader)
+			if lastWrite == "" {
+				if c, err := r.Cookie(lastWriteCookie); err == nil {
+					lastWrite = c.Value
+				}
+			}
+			ctx := tracker.Begin(r.Context(), lastWrite)
+			wrapped := &lastWriteWriter{ResponseWriter: w, ctx: ctx, tracker: tracker, logger: logger}
+			h.ServeHTTP(wrapped, r.WithContext(ctx))
+			// A handler that wrote nothing leaves the headers to net/http.
+			wrapped.setLastWrite()
+		})
+	}
+}
+
+// lastWriteWriter adds the write position right before the headers go out,
+// by then the handler has committed its changes.
+type lastWriteWriter struct {
+	http.ResponseWriter
+	ctx     context.Context
+	tracker WriteTracker
+	logger  applicationoutbound.Logger
+	done    bool
+}
+
+func (w *lastWriteWriter) WriteHeader(statusCode int) {
+	w.setLastWrite()
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *lastWriteWriter) Write(b []byte) (int, error) {
+	w.setLastWrite()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *lastWriteWriter) setLastWrite() {
+	if w.done {
+		return
+	}
+	w.done = true
+	lsn, ok, err := w.tracker.LastWrite(w.ctx)
+	if err != nil {
+		w.logger.WithContext(w.ctx).Warn("read-your-writes position unavailable: %s", err)
+		return
+	}
+	if !ok {
+		return
+	}
+	w.Header().Set(LastWriteHeader, lsn)
+	http.SetCookie(w, &http.Cookie{
+		Name:     lastWriteCookie,
+		Value:    lsn,
+		Path:     "/",
+		MaxAge:   int(lastWriteMaxAge.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
@@ -1,11 +1,100 @@
 package middleware
 
 import (
+	"fmt"
+	"net"
 	"net/http"
-
-	"github.com/go-chi/chi/v5/middleware"
+	"net/netip"
+	"strings"
 )
 
-var RealIP = func(h http.Handler) http.Handler {
-	return middleware.RealIP(h)
+// ParseTrustedProxies parses a comma-separated list of proxy addresses and
+// CIDR ranges.
+func ParseTrustedProxies(s string) ([]netip.Prefix, error) {
+	var proxies []netip.Prefix
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				return nil, fmt.Errorf("trusted proxy %q: %w", entry, err)
+			}
+			proxies = append(proxies, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q: %w", entry, err)
+		}
+		proxies = append(proxies, prefix.Masked())
+	}
+	return proxies, nil
+}
+
+// RealIP replaces RemoteAddr with the client address a trusted proxy
+// forwarded in X-Forwarded-For or X-Real-IP. Anybody can send those headers,
+// so they are ignored on requests that do not come from one of trusted, and
+// X-Forwarded-For is read from the right, where the proxies appended, up to
+// the first address that is not a trusted proxy. With no trusted proxies
+// RemoteAddr is left alone.
+func RealIP(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ip, ok := forwardedIP(r, trusted); ok {
+				r.RemoteAddr = ip.String()
+			}
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+func forwardedIP(r *http.Request, trusted []netip.Prefix) (netip.Addr, bool) {
+	peer, ok := remoteIP(r.RemoteAddr)
+	if !ok || !isTrusted(peer, trusted) {
+		return netip.Addr{}, false
+	}
+	if hops := r.Header.Values("X-Forwarded-For"); len(hops) > 0 {
+		addrs := strings.Split(strings.Join(hops, ","), ",")
+		var client netip.Addr
+		for i := len(addrs) - 1; i >= 0; i-- {
+			ip, err := netip.ParseAddr(strings.TrimSpace(addrs[i]))
+			if err != nil {
+				return netip.Addr{}, false
+			}
+			client = ip.Unmap()
+			if !isTrusted(client, trusted) {
+				break
+			}
+		}
+		return client, true
+	}
+	ip, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP")))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return ip.Unmap(), true
+}
+
+func remoteIP(remoteAddr string) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return ip.Unmap(), true
+}
+
+func isTrusted(ip netip.Addr, trusted []netip.Prefix) bool {
+	for _, p := range trusted {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
 }
@@ -1,45 +1,272 @@
 package http
 
 import (
+	"errors"
 	"net/http"
+	"net/netip"
+	"user-domain/infrastructure/config"
+	"user-domain/infrastructure/database"
+	"user-domain/infrastructure/encryption"
+	"user-domain/infrastructure/graphql"
+	"user-domain/infrastructure/health"
 	"user-domain/infrastructure/http/handler"
 	"user-domain/infrastructure/http/middleware"
+	"user-domain/infrastructure/metrics"
+	"user-domain/infrastructure/persistence/cache"
+	"user-domain/infrastructure/persistence/memory"
+	"user-domain/infrastructure/persistence/postgres/transaction"
 	postgresuser "user-domain/infrastructure/persistence/postgres/user"
+	"user-domain/infrastructure/persistence/sqlite"
+	"user-domain/infrastructure/tracing"
+	"user-domain/internal/application/controller/apiutil"
 	"user-domain/internal/application/controller/parameter"
 	controlleruser "user-domain/internal/application/controller/user"
 	"user-domain/internal/application/inbound"
 	"user-domain/internal/application/logger"
 	"user-domain/internal/application/outbound"
+	repositorytransaction "user-domain/internal/application/repository/transaction"
 	repositoryuser "user-domain/internal/application/repository/user"
+	domainerror "user-domain/internal/domain/error"
+	"user-domain/internal/domain/inport"
 	domainuser "user-domain/internal/domain/user"
 
+	"github.com/getkin/kin-openapi/routers"
 	"github.com/go-chi/chi/v5"
 	"gorm.io/gorm"
 )
 
-func BuildRouter(db *gorm.DB, logger outbound.Logger) *chi.Mux {
+type options struct {
+	metrics *metrics.Metrics
+	health  *health.Health
+	writes  middleware.WriteTracker
+	idem    middleware.IdempotencyStore
+	limits  *rateLimit
+	spec    *specValidation
+	service inport.UserService
+	pii     *encryption.Envelope
+	proxies []netip.Prefix
+}
+
+type specValidation struct {
+	router            routers.Router
+	validateResponses bool
+}
+
+type rateLimit struct {
+	store  middleware.RateLimitStore
+	limits middleware.RateLimits
+	key    middleware.KeyFunc
+}
+
+type Option func(*options)
+
+// WithMetrics records request metrics and serves them on /metrics.
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(o *options) {
+		o.metrics = m
+	}
+}
+
+// WithHealth serves the liveness and readiness probes on /healthz and /readyz.
+func WithHealth(h *health.Health) Option {
+	return func(o *options) {
+		o.health = h
+	}
+}
+
+// WithReadYourWrites pins reads of a client that just wrote to the primary
+// until the replicas have caught up, see database.UseReplicas.
+func WithReadYourWrites(t middleware.WriteTracker) Option {
+	return func(o *options) {
+		o.writes = t
+	}
+}
+
+// WithIdempotency lets clients retry mutating requests safely by sending an
+// Idempotency-Key header.
+func WithIdempotency(store middleware.IdempotencyStore) Option {
+	return func(o *options) {
+		o.idem = store
+	}
+}
+
+// WithRateLimit rejects clients, as told apart by key, that go over their
+// per-route limit.
+func WithRateLimit(store middleware.RateLimitStore, limits middleware.RateLimits, key middleware.KeyFunc) Option {
+	return func(o *options) {
+		o.limits = &rateLimit{store: store, limits: limits, key: key}
+	}
+}
+
+// WithOpenAPIValidation checks API requests, and with validateResponses
+// also responses, against the spec router was built from.
+func WithOpenAPIValidation(router routers.Router, validateResponses bool) Option {
+	return func(o *options) {
+		o.spec = &specValidation{router: router, validateResponses: validateResponses}
+	}
+}
+
+// WithUserService serves s instead of a service built by NewUserService,
+// so that other transports can share it.
+func WithUserService(s inport.UserService) Option {
+	return func(o *options) {
+		o.service = s
+	}
+}
+
+// WithTrustedProxies takes the client address from the X-Forwarded-For and
+// X-Real-IP headers of requests sent by proxies, which rate limiting and
+// idempotency keys then count against. Without it the headers are ignored.
+func WithTrustedProxies(proxies []netip.Prefix) Option {
+	return func(o *options) {
+		o.proxies = proxies
+	}
+}
+
+// WithEncryption stores the email and phone of users encrypted by e.
+func WithEncryption(e *encryption.Envelope) Option {
+	return func(o *options) {
+		o.pii = e
+	}
+}
+
+func BuildRouter(cfg *config.Config, db *gorm.DB, logger outbound.Logger, opts ...Option) *chi.Mux {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	userService := o.service
+	if userService == nil {
+		userService = newUserService(cfg, db, logger, o)
+	}
+
 	r := chi.NewRouter()
 	r.Use(middleware.RequestID)
-	r.Use(middleware.Recoverer)
-	r.Use(middleware.RealIP)
-	r.Use(middleware.LoggingMiddleware(logger))
-	r.Route("/api/v1", func(r chi.Router) {
-		buildUserSubRouter(r, db, logger)
+	// Probes are hit every few seconds, keep them out of traces, metrics and
+	// request logs.
+	if o.health != nil {
+		r.With(middleware.Recoverer).Method(http.MethodGet, "/healthz", o.health.LivenessHandler())
+		r.With(middleware.Recoverer).Method(http.MethodGet, "/readyz", o.health.ReadinessHandler())
+	}
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Tracing)
+		if o.metrics != nil {
+			r.Use(middleware.Metrics(o.metrics))
+		}
+		r.Use(middleware.Recoverer)
+		r.Use(middleware.RealIP(o.proxies))
+		r.Use(middleware.LoggingMiddleware(logger))
+		if o.limits != nil {
+			r.Use(middleware.RateLimit(o.limits.store, o.limits.limits, o.limits.key, logger))
+		}
+		if o.writes != nil {
+			r.Use(middleware.ReadYourWrites(o.writes, logger))
+		}
+		if o.metrics != nil {
+			r.Method(http.MethodGet, "/metrics", o.metrics.Handler())
+		}
+		r.Route("/api/v1", func(r chi.Router) {
+			if o.spec != nil {
+				r.Use(middleware.OpenAPIValidation(o.spec.router, o.spec.validateResponses, logger))
+			}
+			if o.idem != nil {
+				r.Use(middleware.Idempotency(o.idem, middleware.FirstKey(middleware.KeyByPrincipal, middleware.KeyByIP), logger))
+			}
+			buildUserSubRouter(r, userService, logger)
+		})
+		if cfg.GraphQLEnabled {
+			r.Handle("/graphql", graphql.NewHandler(userService, logger, graphql.Limits{
+				MaxComplexity: cfg.GraphQLMaxComplexity,
+				MaxDepth:      cfg.GraphQLMaxDepth,
+			}))
+		}
 	})
 	return r
 }
 
-func buildUserSubRouter(r chi.Router, db *gorm.DB, loggerOutbound outbound.Logger) {
-	userPersistence := postgresuser.NewUserRepo(db)
+func buildUserSubRouter(r chi.Router, userService inport.UserService, loggerOutbound outbound.Logger) {
+	userControler := controlleruser.NewUserControler(userService, loggerOutbound)
+
+	handler.HandlerWithOptions(&userControllerWrap{UserApi: userControler}, handler.ChiServerOptions{
+		BaseRouter:       r,
+		ErrorHandlerFunc: paramError,
+	})
+}
+
+// NewUserService wires the user use cases to the database, or to memory if
+// cfg says so, the way BuildRouter does; only WithMetrics and
+// WithEncryption are taken from opts.
+func NewUserService(cfg *config.Config, db *gorm.DB, logger outbound.Logger, opts ...Option) inport.UserService {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return newUserService(cfg, db, logger, o)
+}
+
+func newUserService(cfg *config.Config, db *gorm.DB, loggerOutbound outbound.Logger, o *options) inport.UserService {
+	var userPersistence outbound.UserRepo
+	var txPersistence outbound.TransactionManager
+	var repoOpts []postgresuser.Option
+	if o.pii != nil {
+		repoOpts = append(repoOpts, postgresuser.WithEncryption(o.pii))
+	}
+	switch {
+	case cfg.PersistenceBackend == "memory":
+		userPersistence = memory.NewUserRepo()
+		txPersistence = memory.NewTransactionManager()
+	case cfg.DBDriver == database.DriverSQLite:
+		userPersistence = sqlite.NewUserRepo(db, repoOpts...)
+		txPersistence = transaction.NewManager(db)
+	default:
+		userPersistence = postgresuser.NewUserRepo(db, repoOpts...)
+		txPersistence = transaction.NewManager(db)
+	}
+	if cfg.UserCacheEnabled {
+		var cacheOpts []cache.Option
+		if o.writes != nil {
+			cacheOpts = append(cacheOpts, cache.WithPrimaryReads(o.writes.Primary))
+		}
+		userCache := cache.NewUserRepo(userPersistence, txPersistence, cfg.UserCacheSize, cfg.UserCacheTTL, cacheOpts...)
+		if o.metrics != nil {
+			registerCacheMetrics(o.metrics, userCache)
+		}
+		userPersistence = userCache
+	}
 	userRepo := repositoryuser.NewUserRepo(userPersistence)
+	txManager := repositorytransaction.NewTransactionManager(txPersistence)
 
 	loggerOutport := logger.NewLogger(loggerOutbound)
-	userService := domainuser.NewUserService(userRepo, loggerOutport)
+	userService := tracing.NewUserService(domainuser.NewUserService(userRepo, txManager, loggerOutport))
+	if o.metrics != nil {
+		userService = metrics.NewUserService(userService, o.metrics)
+	}
+	return userService
+}
 
-	userControler := controlleruser.NewUserControler(userService, loggerOutbound)
+// paramError answers path and query parameters the generated handler
+// cannot parse.
+func paramError(w http.ResponseWriter, r *http.Request, err error) {
+	p := apiutil.NewProblem(r, http.StatusBadRequest, domainerror.CodeValidation, "request parameters are invalid")
+	var paramErr *handler.InvalidParamFormatError
+	if errors.As(err, &paramErr) {
+		p.Errors = []domainerror.FieldError{{Field: paramErr.ParamName, Message: paramErr.Err.Error()}}
+	}
+	apiutil.WriteProblem(w, p)
+}
 
-	handler.HandlerWithOptions(&userControllerWrap{UserApi: userControler}, handler.ChiServerOptions{
-		BaseRouter: r,
+func registerCacheMetrics(m *metrics.Metrics, c cache.UserRepo) {
+	m.RegisterCounterFunc("user_cache", "hits_total", "Lookups served from the in-process user cache.", func() float64 {
+		return float64(c.Stats().Hits)
+	})
+	m.RegisterCounterFunc("user_cache", "misses_total", "Lookups that had to go to the user repository.", func() float64 {
+		return float64(c.Stats().Misses)
+	})
+	m.RegisterCounterFunc("user_cache", "evictions_total", "Entries evicted from the user cache because it was full.", func() float64 {
+		return float64(c.Stats().Evictions)
+	})
+	m.RegisterGaugeFunc("user_cache", "entries", "Number of users currently cached.", func() float64 {
+		return float64(c.Stats().Size)
 	})
 }
 
@@ -69,3 +296,15 @@ func (cW *userControllerWrap) GetUsersUserId(w http.ResponseWriter, r *http.Requ
 func (cW *userControllerWrap) DeleteUsersUserId(w http.ResponseWriter, r *http.Request, userID string) {
 	cW.UserApi.DeleteUsersUserId(w, r, userID)
 }
+
+func (cW *userControllerWrap) GetUsersUserIdPersonalData(w http.ResponseWriter, r *http.Request, userID string) {
+	cW.UserApi.GetUsersUserIdPersonalData(w, r, userID)
+}
+
+func (cW *userControllerWrap) DeleteUsersUserIdPersonalData(w http.ResponseWriter, r *http.Request, userID string) {
+	cW.UserApi.DeleteUsersUserIdPersonalData(w, r, userID)
+}
+
+func (cW *userControllerWrap) PostUsersUserIdRestore(w http.ResponseWriter, r *http.Request, userID string) {
+	cW.UserApi.PostUsersUserIdRestore(w, r, userID)
+}
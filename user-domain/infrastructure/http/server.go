@@ -0,0 +1,64 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+	"user-domain/infrastructure/lifecycle"
+)
+
+type Server struct {
+	httpServer *http.Server
+	errCh      chan error
+}
+
+func NewServer(port string, h http.Handler) *Server {
+	return &Server{
+		httpServer: &http.Server{
+			Handler:      h,
+			Addr:         fmt.Sprintf(":%s", port),
+			ReadTimeout:  5 * time.Second,
+			WriteTimeout: 10 * time.Second,
+			IdleTimeout:  120 * time.Second,
+		},
+		errCh: make(chan error, 1),
+	}
+}
+
+// Start binds the listener synchronously so that a busy port fails startup,
+// then serves in the background.
+func (s *Server) Start(ctx context.Context) error {
+	ln, err := (&net.ListenConfig{}).Listen(ctx, "tcp", s.httpServer.Addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := s.httpServer.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.errCh <- err
+		}
+		close(s.errCh)
+	}()
+	return nil
+}
+
+// Stop stops accepting connections and waits for in-flight requests until ctx
+// expires.
+func (s *Server) Stop(ctx context.Context) error {
+	return s.httpServer.Shutdown(ctx)
+}
+
+// Err reports a failure of the serve loop after Start returned.
+func (s *Server) Err() <-chan error {
+	return s.errCh
+}
+
+func (s *Server) Hook() lifecycle.Hook {
+	return lifecycle.Hook{
+		Name:    "http server",
+		OnStart: s.Start,
+		OnStop:  s.Stop,
+	}
+}
@@ -0,0 +1,12 @@
+package idempotency
+
+import (
+	"context"
+	"user-domain/infrastructure/http/middleware"
+)
+
+// Store is a middleware.IdempotencyStore whose expired keys can be purged.
+type Store interface {
+	middleware.IdempotencyStore
+	DeleteExpired(ctx context.Context) (int64, error)
+}
@@ -0,0 +1,212 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	"user-domain/infrastructure/http/middleware"
+	application_mock "user-domain/internal/application/mocks/outbound"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func newHandler(t *testing.T, store middleware.IdempotencyStore, h http.HandlerFunc) http.Handler {
+	logger := application_mock.NewLogger(t)
+	logger.On("WithContext", mock.Anything).Return(logger).Maybe()
+	logger.On("Error", mock.Anything, mock.Anything).Maybe()
+	return middleware.Idempotency(store, middleware.FirstKey(middleware.KeyByPrincipal, middleware.KeyByIP), logger)(h)
+}
+
+func post(h http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(middleware.IdempotencyKeyHeader, key)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotencyReplaysStoredResponse(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	h := newHandler(t, NewMemoryStore(time.Hour, time.Minute), func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Location", "/api/v1/users/1")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":"1"}`))
+	})
+
+	first := post(h, "key-1", `{"name":"An"}`)
+	require.Equal(t, http.StatusCreated, first.Code)
+	require.Empty(t, first.Header().Get(middleware.IdempotentReplayedHeader))
+
+	again := post(h, "key-1", `{"name":"An"}`)
+	require.Equal(t, http.StatusCreated, again.Code)
+	require.Equal(t, "true", again.Header().Get(middleware.IdempotentReplayedHeader))
+	require.Equal(t, "/api/v1/users/1", again.Header().Get("Location"))
+	require.JSONEq(t, `{"id":"1"}`, again.Body.String())
+	require.EqualValues(t, 1, calls.Load())
+
+	require.Equal(t, http.StatusUnprocessableEntity, post(h, "key-1", `{"name":"Binh"}`).Code)
+
+	post(h, "", `{"name":"An"}`)
+	post(h, "key-2", `{"name":"An"}`)
+	require.EqualValues(t, 3, calls.Load())
+}
+
+func TestIdempotencyKeysAreScopedToTheClient(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	h := newHandler(t, NewMemoryStore(time.Hour, time.Minute), func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusCreated)
+	})
+	from := func(remoteAddr, principal string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users", strings.NewReader(`{"name":"An"}`))
+		req.Header.Set(middleware.IdempotencyKeyHeader, "key-1")
+		req.RemoteAddr = remoteAddr
+		if principal != "" {
+			req = req.WithContext(middleware.WithPrincipal(req.Context(), principal))
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	require.Empty(t, from("10.0.0.1:1234", "").Header().Get(middleware.IdempotentReplayedHeader))
+	require.Empty(t, from("10.0.0.2:1234", "").Header().Get(middleware.IdempotentReplayedHeader))
+	require.Equal(t, "true", from("10.0.0.1:4321", "").Header().Get(middleware.IdempotentReplayedHeader))
+	// A principal is the client wherever it connects from.
+	require.Empty(t, from("10.0.0.1:1234", "alice").Header().Get(middleware.IdempotentReplayedHeader))
+	require.Equal(t, "true", from("10.0.0.3:1234", "alice").Header().Get(middleware.IdempotentReplayedHeader))
+	require.EqualValues(t, 3, calls.Load())
+}
+
+func TestIdempotencyRejectsConcurrentDuplicate(t *testing.T) {
+	t.Parallel()
+	started, release := make(chan struct{}), make(chan struct{})
+	h := newHandler(t, NewMemoryStore(time.Hour, time.Minute), func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- post(h, "key", "{}") }()
+	<-started
+
+	dup := post(h, "key", "{}")
+	require.Equal(t, http.StatusConflict, dup.Code)
+	require.Equal(t, "1", dup.Header().Get("Retry-After"))
+
+	close(release)
+	require.Equal(t, http.StatusCreated, (<-done).Code)
+	require.Equal(t, http.StatusCreated, post(h, "key", "{}").Code)
+}
+
+func TestIdempotencyDoesNotStoreServerErrors(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	h := newHandler(t, NewMemoryStore(time.Hour, time.Minute), func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	require.Equal(t, http.StatusInternalServerError, post(h, "key", "{}").Code)
+	require.Equal(t, http.StatusCreated, post(h, "key", "{}").Code)
+	require.Equal(t, "true", post(h, "key", "{}").Header().Get(middleware.IdempotentReplayedHeader))
+	require.EqualValues(t, 2, calls.Load())
+}
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	s := NewMemoryStore(time.Hour, time.Minute).(*memoryStore)
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_, claimed, err := s.Claim(ctx, "key", "first", "a")
+	require.NoError(t, err)
+	require.True(t, claimed)
+
+	// An unfinished request stops blocking the key after the lock timeout,
+	// and can no longer settle it once another request took it over.
+	now = now.Add(2 * time.Minute)
+	_, claimed, _ = s.Claim(ctx, "key", "second", "a")
+	require.True(t, claimed)
+	require.NoError(t, s.Release(ctx, "key", "first"))
+	require.NoError(t, s.Complete(ctx, "key", "first", &middleware.IdempotentResponse{Fingerprint: "a", Status: http.StatusAccepted}))
+	require.NoError(t, s.Complete(ctx, "key", "second", &middleware.IdempotentResponse{Fingerprint: "a", Status: http.StatusCreated}))
+	require.NoError(t, s.Complete(ctx, "key", "second", &middleware.IdempotentResponse{Fingerprint: "a", Status: http.StatusAccepted}))
+
+	resp, claimed, _ := s.Claim(ctx, "key", "third", "a")
+	require.False(t, claimed)
+	require.True(t, resp.Completed)
+	require.Equal(t, http.StatusCreated, resp.Status)
+
+	now = now.Add(2 * time.Hour)
+	n, err := s.DeleteExpired(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, n)
+}
+
+func TestPostgresStoreClaim(t *testing.T) {
+	t.Parallel()
+	conn, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{SkipDefaultTransaction: true})
+	require.NoError(t, err)
+	s := NewPostgresStore(db, time.Hour, time.Minute)
+	ctx := context.Background()
+
+	sqlMock.ExpectExec(`INSERT INTO idempotency_keys`).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, claimed, err := s.Claim(ctx, "key", "owner", "a")
+	require.NoError(t, err)
+	require.True(t, claimed)
+
+	sqlMock.ExpectExec(`INSERT INTO idempotency_keys`).WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectQuery(`SELECT \* FROM "idempotency_keys"`).WillReturnRows(
+		sqlmock.NewRows([]string{"key", "fingerprint", "status", "header", "body", "completed_at", "locked_at", "expires_at"}).
+			AddRow("key", "a", 201, []byte(`{"Location":["/api/v1/users/1"]}`), []byte(`{}`), time.Now(), time.Now(), time.Now().Add(time.Hour)),
+	)
+	resp, claimed, err := s.Claim(ctx, "key", "other", "a")
+	require.NoError(t, err)
+	require.False(t, claimed)
+	require.True(t, resp.Completed)
+	require.Equal(t, http.StatusCreated, resp.Status)
+	require.Equal(t, "/api/v1/users/1", resp.Header.Get("Location"))
+	require.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestPostgresStoreSettlesOnlyOwnLock(t *testing.T) {
+	t.Parallel()
+	conn, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{SkipDefaultTransaction: true})
+	require.NoError(t, err)
+	s := NewPostgresStore(db, time.Hour, time.Minute)
+	ctx := context.Background()
+
+	sqlMock.ExpectExec(`UPDATE "idempotency_keys" SET .* WHERE key = \$\d+ AND owner = \$\d+ AND completed_at IS NULL`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "key", "owner").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	require.NoError(t, s.Complete(ctx, "key", "owner", &middleware.IdempotentResponse{Status: http.StatusCreated}))
+
+	sqlMock.ExpectExec(`DELETE FROM "idempotency_keys" WHERE key = \$1 AND owner = \$2 AND completed_at IS NULL`).
+		WithArgs("key", "owner").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	require.NoError(t, s.Release(ctx, "key", "owner"))
+	require.NoError(t, sqlMock.ExpectationsWereMet())
+}
@@ -0,0 +1,101 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+	"user-domain/infrastructure/http/middleware"
+)
+
+type entry struct {
+	owner     string
+	resp      middleware.IdempotentResponse
+	lockedAt  time.Time
+	expiresAt time.Time
+}
+
+type memoryStore struct {
+	ttl         time.Duration
+	lockTimeout time.Duration
+	now         func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+// NewMemoryStore keeps idempotency keys in process. It is only suitable
+// for a single instance; keys are forgotten on restart.
+func NewMemoryStore(ttl, lockTimeout time.Duration) Store {
+	return &memoryStore{ttl: ttl, lockTimeout: lockTimeout, now: time.Now, entries: map[string]*entry{}}
+}
+
+func (s *memoryStore) Claim(_ context.Context, key, owner, fingerprint string) (*middleware.IdempotentResponse, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if e, ok := s.entries[key]; ok && !s.reclaimable(e, now) {
+		resp := e.resp
+		return &resp, false, nil
+	}
+	s.entries[key] = &entry{
+		owner:     owner,
+		resp:      middleware.IdempotentResponse{Fingerprint: fingerprint},
+		lockedAt:  now,
+		expiresAt: now.Add(s.ttl),
+	}
+	return nil, true, nil
+}
+
+func (s *memoryStore) Complete(_ context.Context, key, owner string, resp *middleware.IdempotentResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.owned(key, owner); ok {
+		e.resp = *resp
+		e.resp.Completed = true
+	}
+	return nil
+}
+
+func (s *memoryStore) Release(_ context.Context, key, owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.owned(key, owner); ok {
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+// owned returns the entry of key if owner holds its lock.
+func (s *memoryStore) owned(key, owner string) (*entry, bool) {
+	e, ok := s.entries[key]
+	if !ok || e.owner != owner || e.resp.Completed {
+		return nil, false
+	}
+	return e, true
+}
+
+// DeleteExpired drops keys past their ttl.
+func (s *memoryStore) DeleteExpired(context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	now := s.now()
+	for key, e := range s.entries {
+		if now.After(e.expiresAt) {
+			delete(s.entries, key)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// reclaimable reports whether e expired or holds the lock of a request that
+// has been running for longer than lockTimeout, most likely on an instance
+// that died.
+func (s *memoryStore) reclaimable(e *entry, now time.Time) bool {
+	if now.After(e.expiresAt) {
+		return true
+	}
+	return !e.resp.Completed && now.Sub(e.lockedAt) > s.lockTimeout
+}
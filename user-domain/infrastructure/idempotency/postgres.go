@@ -0,0 +1,114 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+	"user-domain/infrastructure/http/middleware"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+type idempotencyKey struct {
+	Key         string `gorm:"primaryKey"`
+	Owner       string
+	Fingerprint string
+	Status      int
+	Header      []byte
+	Body        []byte
+	CompletedAt *time.Time
+	LockedAt    time.Time
+	ExpiresAt   time.Time
+}
+
+func (idempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
+
+type postgresStore struct {
+	db          *gorm.DB
+	ttl         time.Duration
+	lockTimeout time.Duration
+}
+
+// NewPostgresStore keeps idempotency keys in the idempotency_keys table so
+// that retries landing on another instance are recognised. The row inserted
+// by Claim is the lock; the primary key makes concurrent claims race safely.
+func NewPostgresStore(db *gorm.DB, ttl, lockTimeout time.Duration) Store {
+	return &postgresStore{db: db, ttl: ttl, lockTimeout: lockTimeout}
+}
+
+func (s *postgresStore) Claim(ctx context.Context, key, owner, fingerprint string) (*middleware.IdempotentResponse, bool, error) {
+	// Insert the lock, or take over a row that expired or whose owner
+	// stopped finishing it. Zero rows affected means someone else holds it.
+	res := s.db.WithContext(ctx).Exec(`
+INSERT INTO idempotency_keys (key, owner, fingerprint, locked_at, expires_at)
+VALUES (?, ?, ?, now(), now() + make_interval(secs => ?))
+ON CONFLICT (key) DO UPDATE
+SET owner = EXCLUDED.owner, fingerprint = EXCLUDED.fingerprint, status = 0, header = NULL, body = NULL,
+    completed_at = NULL, locked_at = EXCLUDED.locked_at, expires_at = EXCLUDED.expires_at
+WHERE idempotency_keys.expires_at < now()
+   OR (idempotency_keys.completed_at IS NULL AND idempotency_keys.locked_at < now() - make_interval(secs => ?))`,
+		key, owner, fingerprint, s.ttl.Seconds(), s.lockTimeout.Seconds())
+	if res.Error != nil {
+		return nil, false, res.Error
+	}
+	if res.RowsAffected > 0 {
+		return nil, true, nil
+	}
+
+	// Read from the primary, a replica may not have the row yet.
+	var row idempotencyKey
+	err := s.db.WithContext(ctx).Clauses(dbresolver.Write).Where("key = ?", key).Take(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Purged between the insert and the read, let the client retry.
+		return &middleware.IdempotentResponse{Fingerprint: fingerprint}, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	resp := &middleware.IdempotentResponse{
+		Fingerprint: row.Fingerprint,
+		Completed:   row.CompletedAt != nil,
+		Status:      row.Status,
+		Body:        row.Body,
+	}
+	if len(row.Header) > 0 {
+		if err := json.Unmarshal(row.Header, &resp.Header); err != nil {
+			return nil, false, err
+		}
+	}
+	return resp, false, nil
+}
+
+func (s *postgresStore) Complete(ctx context.Context, key, owner string, resp *middleware.IdempotentResponse) error {
+	header, err := json.Marshal(headerOrEmpty(resp.Header))
+	if err != nil {
+		return err
+	}
+	return s.db.WithContext(ctx).Model(&idempotencyKey{}).Where("key = ? AND owner = ? AND completed_at IS NULL", key, owner).Updates(map[string]interface{}{
+		"status":       resp.Status,
+		"header":       header,
+		"body":         resp.Body,
+		"completed_at": time.Now(),
+	}).Error
+}
+
+func (s *postgresStore) Release(ctx context.Context, key, owner string) error {
+	return s.db.WithContext(ctx).Where("key = ? AND owner = ? AND completed_at IS NULL", key, owner).Delete(&idempotencyKey{}).Error
+}
+
+func (s *postgresStore) DeleteExpired(ctx context.Context) (int64, error) {
+	res := s.db.WithContext(ctx).Where("expires_at < now()").Delete(&idempotencyKey{})
+	return res.RowsAffected, res.Error
+}
+
+func headerOrEmpty(h http.Header) http.Header {
+	if h == nil {
+		return http.Header{}
+	}
+	return h
+}
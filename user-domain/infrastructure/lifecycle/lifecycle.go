@@ -0,0 +1,86 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"user-domain/internal/application/outbound"
+)
+
+type Hook struct {
+	Name string
+	// OnStart must not block, long running work belongs in a goroutine that
+	// OnStop is able to end.
+	OnStart func(ctx context.Context) error
+	OnStop  func(ctx context.Context) error
+}
+
+// Lifecycle starts hooks in the order they were appended and stops the ones
+// that started in reverse order, so a component is always stopped before the
+// dependencies it was registered after.
+type Lifecycle struct {
+	mu      sync.Mutex
+	hooks   []Hook
+	started int
+	logger  outbound.Logger
+}
+
+func New(logger outbound.Logger) *Lifecycle {
+	return &Lifecycle{logger: logger}
+}
+
+func (l *Lifecycle) Append(h Hook) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hooks = append(l.hooks, h)
+}
+
+// Start runs every OnStart hook. If one fails the hooks that already started
+// are stopped again before the error is returned.
+func (l *Lifecycle) Start(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for l.started < len(l.hooks) {
+		h := l.hooks[l.started]
+		if h.OnStart != nil {
+			l.logger.WithContext(ctx).Info("starting %s", h.Name)
+			if err := h.OnStart(ctx); err != nil {
+				startErr := fmt.Errorf("start %s: %w", h.Name, err)
+				return errors.Join(startErr, l.stop(ctx))
+			}
+		}
+		l.started++
+	}
+	return nil
+}
+
+// Stop runs the OnStop hooks of every started component even if some of them
+// fail, and returns all failures joined together. A hook without OnStart
+// holds a resource that is live once appended, such as a connection pool,
+// and is stopped even if Start never ran, so that Stop also cleans up after
+// a setup that failed half way.
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stop(ctx)
+}
+
+func (l *Lifecycle) stop(ctx context.Context) error {
+	var errs []error
+	hooks, started := l.hooks, l.started
+	l.hooks, l.started = nil, 0
+	for i := len(hooks) - 1; i >= 0; i-- {
+		h := hooks[i]
+		if h.OnStop == nil || i >= started && h.OnStart != nil {
+			continue
+		}
+		l.logger.WithContext(ctx).Info("stopping %s", h.Name)
+		if err := h.OnStop(ctx); err != nil {
+			l.logger.WithContext(ctx).Error("stop %s: %s", h.Name, err)
+			errs = append(errs, fmt.Errorf("stop %s: %w", h.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
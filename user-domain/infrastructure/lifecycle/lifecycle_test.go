@@ -0,0 +1,99 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	appmock "user-domain/internal/application/mocks/outbound"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newLifecycle(t *testing.T) *Lifecycle {
+	logger := appmock.NewLogger(t)
+	logger.On("WithContext", mock.Anything).Return(logger).Maybe()
+	logger.On("Info", mock.Anything, mock.Anything).Maybe()
+	logger.On("Error", mock.Anything, mock.Anything, mock.Anything).Maybe()
+	return New(logger)
+}
+
+func recordingHook(name string, calls *[]string, startErr, stopErr error) Hook {
+	return Hook{
+		Name: name,
+		OnStart: func(context.Context) error {
+			*calls = append(*calls, "start "+name)
+			return startErr
+		},
+		OnStop: func(context.Context) error {
+			*calls = append(*calls, "stop "+name)
+			return stopErr
+		},
+	}
+}
+
+func TestStartAndStopOrder(t *testing.T) {
+	t.Parallel()
+	lc := newLifecycle(t)
+	var calls []string
+	lc.Append(recordingHook("db", &calls, nil, nil))
+	lc.Append(Hook{Name: "cache"})
+	lc.Append(recordingHook("http", &calls, nil, nil))
+
+	require.NoError(t, lc.Start(t.Context()))
+	require.NoError(t, lc.Stop(t.Context()))
+	require.Equal(t, []string{"start db", "start http", "stop http", "stop db"}, calls)
+
+	// a second stop is a no-op
+	require.NoError(t, lc.Stop(t.Context()))
+	require.Len(t, calls, 4)
+}
+
+func TestStartFailureStopsStartedHooks(t *testing.T) {
+	t.Parallel()
+	lc := newLifecycle(t)
+	var calls []string
+	lc.Append(recordingHook("db", &calls, nil, nil))
+	lc.Append(recordingHook("http", &calls, errors.New("address in use"), nil))
+	lc.Append(recordingHook("worker", &calls, nil, nil))
+
+	err := lc.Start(t.Context())
+	require.ErrorContains(t, err, "start http: address in use")
+	require.Equal(t, []string{"start db", "start http", "stop db"}, calls)
+}
+
+func TestStopRunsEveryHookAndJoinsErrors(t *testing.T) {
+	t.Parallel()
+	lc := newLifecycle(t)
+	var calls []string
+	errDB := errors.New("db close")
+	errHTTP := errors.New("drain timeout")
+	lc.Append(recordingHook("db", &calls, nil, errDB))
+	lc.Append(recordingHook("http", &calls, nil, errHTTP))
+
+	require.NoError(t, lc.Start(t.Context()))
+	err := lc.Stop(t.Context())
+	require.ErrorIs(t, err, errDB)
+	require.ErrorIs(t, err, errHTTP)
+	require.Equal(t, []string{"start db", "start http", "stop http", "stop db"}, calls)
+}
+
+func TestStopBeforeStartReleasesResources(t *testing.T) {
+	t.Parallel()
+	lc := newLifecycle(t)
+	var calls []string
+	lc.Append(Hook{Name: "tracing", OnStop: func(context.Context) error {
+		calls = append(calls, "stop tracing")
+		return nil
+	}})
+	lc.Append(recordingHook("http", &calls, nil, nil))
+	lc.Append(Hook{Name: "pool", OnStop: func(context.Context) error {
+		calls = append(calls, "stop pool")
+		return nil
+	}})
+
+	require.NoError(t, lc.Stop(t.Context()))
+	require.Equal(t, []string{"stop pool", "stop tracing"}, calls)
+	require.NoError(t, lc.Stop(t.Context()))
+	require.Len(t, calls, 2)
+}
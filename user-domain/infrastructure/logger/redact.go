@@ -0,0 +1,190 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	applicationoutbound "user-domain/internal/application/outbound"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Redacted replaces what a Redactor removes.
+const Redacted = "[REDACTED]"
+
+// defaultFields are the field names, compared case-insensitively, whose
+// values are never logged.
+var defaultFields = []string{
+	"password", "secret", "token", "access_token", "refresh_token", "api_key",
+	"authorization", "cookie", "set-cookie", "x-api-key", "email", "phone",
+}
+
+// defaultPatterns find personal data and credentials in messages and field
+// values. Where a pattern has a group only the group is redacted, so that
+// what it follows stays readable.
+var defaultPatterns = []string{
+	// Emails, also when percent-encoded in a URL.
+	`[A-Za-z0-9._%+\-]+(?:@|%40)[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,}`,
+	// Phone numbers, which start with a country code, +84 912 345 678, or a
+	// trunk 0, 090-123-4567, or else are grouped 3-3-4, 415.555.0100. Digit
+	// groups may be separated by a space, dot or dash. Bare runs of digits,
+	// ids, amounts and timestamps, are not phone numbers, and neither is
+	// what follows a dot, dash or slash. UUIDs, which may start like one,
+	// are matched first and left alone.
+	`(?:^|[^\w.+/-])(?:[0-9A-Fa-f]{8}(?:-[0-9A-Fa-f]{4}){3}-[0-9A-Fa-f]{12}|((?:\+|%2B)\d{1,3}(?:[ .-]?\(?\d{2,4}\)?){3,5}|\(?0[1-9]\d{1,2}\)?(?:[ .-]?\d{3,4}){2}|\d{3}[ .-]\d{3}[ .-]\d{4})\b)`,
+	// Bearer tokens and JWTs.
+	`(?i)\bbearer\s+([A-Za-z0-9\-._~+/]+=*)`,
+	`\beyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]*`,
+	// Credentials in query strings.
+	`(?i)[?&](?:access_token|refresh_token|token|api_key|apikey|key|password|secret)=([^&\s"]+)`,
+}
+
+// Redactor removes personal data and credentials from log entries: the
+// values of fields with sensitive names, and whatever its patterns match in
+// messages and other values.
+type Redactor struct {
+	fields   map[string]bool
+	patterns []*regexp.Regexp
+}
+
+// NewRedactor returns a Redactor of the default fields and patterns plus
+// fields, comma-separated, and patterns, space-separated regular
+// expressions.
+func NewRedactor(fields, patterns string) (*Redactor, error) {
+	r := &Redactor{fields: map[string]bool{}}
+	for _, f := range append(defaultFields, strings.Split(fields, ",")...) {
+		if f = strings.TrimSpace(f); f != "" {
+			r.fields[strings.ToLower(f)] = true
+		}
+	}
+	for _, p := range append(defaultPatterns, strings.Fields(patterns)...) {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("redaction pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// DefaultRedactor redacts the default fields and patterns.
+func DefaultRedactor() *Redactor {
+	r, err := NewRedactor("", "")
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// String returns s with every match of the patterns redacted.
+func (r *Redactor) String(s string) string {
+	for _, re := range r.patterns {
+		if re.NumSubexp() == 0 {
+			s = re.ReplaceAllLiteralString(s, Redacted)
+			continue
+		}
+		s = re.ReplaceAllStringFunc(s, func(match string) string {
+			loc := re.FindStringSubmatchIndex(match)
+			if loc[2] < 0 {
+				return match
+			}
+			return match[:loc[2]] + Redacted + match[loc[3]:]
+		})
+	}
+	return s
+}
+
+// Field returns f with its value redacted.
+func (r *Redactor) Field(f zapcore.Field) zapcore.Field {
+	if r.fields[strings.ToLower(f.Key)] {
+		return zap.String(f.Key, Redacted)
+	}
+	switch f.Type {
+	case zapcore.StringType:
+		f.String = r.String(f.String)
+	case zapcore.ByteStringType:
+		f = zap.ByteString(f.Key, []byte(r.String(string(f.Interface.([]byte)))))
+	case zapcore.ErrorType:
+		f = zap.String(f.Key, r.String(f.Interface.(error).Error()))
+	case zapcore.StringerType:
+		f = zap.String(f.Key, r.String(stringer(f.Interface)))
+	case zapcore.ReflectType:
+		// Maps and structs are redacted in their JSON form, unless that no
+		// longer parses.
+		b, err := json.Marshal(f.Interface)
+		if err != nil {
+			return zap.String(f.Key, r.String(fmt.Sprint(f.Interface)))
+		}
+		if s := r.String(string(b)); s != string(b) {
+			if !json.Valid([]byte(s)) {
+				return zap.String(f.Key, s)
+			}
+			f = zap.Reflect(f.Key, json.RawMessage(s))
+		}
+	}
+	return f
+}
+
+func (r *Redactor) Fields(fields []zapcore.Field) []zapcore.Field {
+	redacted := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		redacted[i] = r.Field(f)
+	}
+	return redacted
+}
+
+// stringer calls String the way zap does, a nil pointer gives "<nil>".
+func stringer(v any) (s string) {
+	defer func() {
+		if recover() != nil {
+			s = "<nil>"
+		}
+	}()
+	return v.(fmt.Stringer).String()
+}
+
+// redactingCore redacts entries before core writes them.
+type redactingCore struct {
+	zapcore.Core
+	redactor *Redactor
+}
+
+func newRedactingCore(core zapcore.Core, r *Redactor) zapcore.Core {
+	// Replace rather than stack redactors.
+	if rc, ok := core.(*redactingCore); ok {
+		core = rc.Core
+	}
+	return &redactingCore{Core: core, redactor: r}
+}
+
+func (c *redactingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactingCore{Core: c.Core.With(c.redactor.Fields(fields)), redactor: c.redactor}
+}
+
+func (c *redactingCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *redactingCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = c.redactor.String(ent.Message)
+	return c.Core.Write(ent, c.redactor.Fields(fields))
+}
+
+// WithRedactor returns l redacting with r instead of the redactor it was
+// created with. Loggers that do not come from this package are returned
+// as they are.
+func WithRedactor(l applicationoutbound.Logger, r *Redactor) applicationoutbound.Logger {
+	zl, ok := l.(*logger)
+	if !ok {
+		return l
+	}
+	return &logger{zap: zl.zap.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return newRedactingCore(core, r)
+	}))}
+}
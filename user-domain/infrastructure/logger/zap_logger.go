@@ -3,17 +3,24 @@ package logger
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 
+	"user-domain/infrastructure/config"
 	applicationoutbound "user-domain/internal/application/outbound"
 	domainoutport "user-domain/internal/domain/outport"
 
 	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
-const requestID = "request_id"
+const (
+	requestID = "request_id"
+	traceID   = "trace_id"
+	spanID    = "span_id"
+)
 
 type logger struct {
 	zap *zap.Logger
@@ -60,11 +67,20 @@ func (l *logger) Error(msg string, args ...any) { l.log(zapcore.ErrorLevel, msg,
 // ---- Context binding ----
 
 func (l *logger) WithContext(ctx context.Context) applicationoutbound.Logger {
-	rID := middleware.GetReqID(ctx)
-	if rID == "" {
+	var fields []zap.Field
+	if rID := middleware.GetReqID(ctx); rID != "" {
+		fields = append(fields, zap.String(requestID, rID))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields = append(fields,
+			zap.String(traceID, sc.TraceID().String()),
+			zap.String(spanID, sc.SpanID().String()),
+		)
+	}
+	if len(fields) == 0 {
 		return l
 	}
-	return &logger{zap: l.zap.With(zap.String(requestID, rID))}
+	return &logger{zap: l.zap.With(fields...)}
 }
 
 // ---- Helpers ----
@@ -85,6 +101,9 @@ func (l *logger) Sync() {
 
 // ---- Factory ----
 
+// NewLogger logs to stdout, as JSON if ENV names production, see
+// config.IsProduction. Entries are redacted by
+// the DefaultRedactor, see WithRedactor to configure it.
 func NewLogger() applicationoutbound.Logger {
 	env := os.Getenv("ENV")
 
@@ -92,7 +111,7 @@ func NewLogger() applicationoutbound.Logger {
 	var encoder zapcore.Encoder
 	var level zapcore.Level
 
-	if env == "prod" {
+	if config.IsProduction(env) {
 		encoderCfg = zapcore.EncoderConfig{
 			TimeKey:       "time",
 			LevelKey:      "level",
@@ -113,8 +132,17 @@ func NewLogger() applicationoutbound.Logger {
 		level = zap.DebugLevel
 	}
 
-	core := zapcore.NewCore(encoder, zapcore.AddSync(os.Stdout), level)
+	core := newRedactingCore(zapcore.NewCore(encoder, zapcore.AddSync(os.Stdout), level), DefaultRedactor())
 	z := zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1))
 
 	return &logger{zap: z}
 }
+
+// NewConsoleLogger writes warnings and errors to w in the development
+// format, for command-line tools whose stdout is their output.
+func NewConsoleLogger(w io.Writer) applicationoutbound.Logger {
+	encoderCfg := zap.NewDevelopmentEncoderConfig()
+	encoderCfg.EncodeTime = zapcore.ISO8601TimeEncoder
+	core := newRedactingCore(zapcore.NewCore(zapcore.NewConsoleEncoder(encoderCfg), zapcore.AddSync(w), zap.WarnLevel), DefaultRedactor())
+	return &logger{zap: zap.New(core)}
+}
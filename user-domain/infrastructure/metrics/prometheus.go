@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "user_domain"
+
+type Metrics struct {
+	registry      *prometheus.Registry
+	httpDuration  *prometheus.HistogramVec
+	queryDuration *prometheus.HistogramVec
+	userEvents    *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of HTTP requests by route template and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "db",
+			Name:      "query_duration_seconds",
+			Help:      "Duration of SQL statements issued through gorm.",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation", "result"}),
+		userEvents: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "users",
+			Name:      "events_total",
+			Help:      "Successful user mutations by kind.",
+		}, []string{"event"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpDuration,
+		m.queryDuration,
+		m.userEvents,
+	)
+	return m
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+func (m *Metrics) ObserveHTTPRequest(method, route string, status int, elapsed time.Duration) {
+	m.httpDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(elapsed.Seconds())
+}
+
+func (m *Metrics) ObserveQuery(_ context.Context, sql string, elapsed time.Duration, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	m.queryDuration.WithLabelValues(queryOperation(sql), result).Observe(elapsed.Seconds())
+}
+
+// RegisterDBStats exports the connection pool statistics of db.
+func (m *Metrics) RegisterDBStats(db *sql.DB, name string) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterCounterFunc exports a monotonic value that is owned elsewhere, such
+// as the hit and miss counters of the user cache.
+func (m *Metrics) RegisterCounterFunc(subsystem, name, help string, fn func() float64) {
+	m.registry.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      name,
+		Help:      help,
+	}, fn))
+}
+
+func (m *Metrics) RegisterGaugeFunc(subsystem, name, help string, fn func() float64) {
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      name,
+		Help:      help,
+	}, fn))
+}
+
+func (m *Metrics) incUserEvent(event string) {
+	m.userEvents.WithLabelValues(event).Inc()
+}
+
+func queryOperation(sql string) string {
+	op, _, _ := strings.Cut(strings.TrimSpace(sql), " ")
+	switch op = strings.ToLower(op); op {
+	case "select", "insert", "update", "delete":
+		return op
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"user-domain/infrastructure/http/middleware"
+	domainmock "user-domain/internal/domain/mocks/inport"
+	"user-domain/internal/entity"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPDurationIsLabelledByRouteTemplate(t *testing.T) {
+	t.Parallel()
+	m := New()
+	r := chi.NewRouter()
+	r.Use(middleware.Metrics(m))
+	r.Get("/users/{user_id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	for _, id := range []string{"1", "2", "3"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/"+id, nil))
+	}
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nope", nil))
+
+	require.Equal(t, 2, testutil.CollectAndCount(m.httpDuration))
+	body := scrape(t, m)
+	require.Contains(t, body, `user_domain_http_request_duration_seconds_count{method="GET",route="/users/{user_id}",status="404"} 3`)
+	require.Contains(t, body, `route="unmatched"`)
+}
+
+func TestObserveQuery(t *testing.T) {
+	t.Parallel()
+	m := New()
+	m.ObserveQuery(t.Context(), `SELECT * FROM "users"`, time.Millisecond, nil)
+	m.ObserveQuery(t.Context(), `INSERT INTO "users" ("id") VALUES ($1)`, time.Millisecond, errors.New("boom"))
+	m.ObserveQuery(t.Context(), `SAVEPOINT sp1`, time.Millisecond, nil)
+
+	body := scrape(t, m)
+	require.Contains(t, body, `user_domain_db_query_duration_seconds_count{operation="select",result="ok"} 1`)
+	require.Contains(t, body, `user_domain_db_query_duration_seconds_count{operation="insert",result="error"} 1`)
+	require.Contains(t, body, `user_domain_db_query_duration_seconds_count{operation="other",result="ok"} 1`)
+}
+
+func TestUserServiceCountsSuccessfulMutations(t *testing.T) {
+	t.Parallel()
+	m := New()
+	next := domainmock.NewUserService(t)
+	next.On("CreateUser", mock.Anything, mock.Anything).Return(nil).Twice()
+	next.On("DeleteUser", mock.Anything, "1").Return(errors.New("boom")).Once()
+	svc := NewUserService(next, m)
+
+	require.NoError(t, svc.CreateUser(t.Context(), &entity.User{}))
+	require.NoError(t, svc.CreateUser(t.Context(), &entity.User{}))
+	require.Error(t, svc.DeleteUser(t.Context(), "1"))
+
+	require.Equal(t, float64(2), testutil.ToFloat64(m.userEvents.WithLabelValues(userCreated)))
+	require.Equal(t, float64(0), testutil.ToFloat64(m.userEvents.WithLabelValues(userDeleted)))
+}
+
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	return strings.TrimSpace(w.Body.String())
+}
@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"context"
+	"user-domain/internal/domain/inport"
+	"user-domain/internal/entity"
+)
+
+const (
+	userCreated  = "created"
+	userUpdated  = "updated"
+	userDeleted  = "deleted"
+	userRestored = "restored"
+	userErased   = "erased"
+)
+
+type userService struct {
+	inport.UserService
+	metrics *Metrics
+}
+
+func (s *userService) CreateUser(ctx context.Context, user *entity.User) error {
+	err := s.UserService.CreateUser(ctx, user)
+	if err == nil {
+		s.metrics.incUserEvent(userCreated)
+	}
+	return err
+}
+
+func (s *userService) UpdateUser(ctx context.Context, user *entity.User) error {
+	err := s.UserService.UpdateUser(ctx, user)
+	if err == nil {
+		s.metrics.incUserEvent(userUpdated)
+	}
+	return err
+}
+
+func (s *userService) DeleteUser(ctx context.Context, id string) error {
+	err := s.UserService.DeleteUser(ctx, id)
+	if err == nil {
+		s.metrics.incUserEvent(userDeleted)
+	}
+	return err
+}
+
+func (s *userService) RestoreUser(ctx context.Context, id string) error {
+	err := s.UserService.RestoreUser(ctx, id)
+	if err == nil {
+		s.metrics.incUserEvent(userRestored)
+	}
+	return err
+}
+
+func (s *userService) ErasePersonalData(ctx context.Context, id string) error {
+	err := s.UserService.ErasePersonalData(ctx, id)
+	if err == nil {
+		s.metrics.incUserEvent(userErased)
+	}
+	return err
+}
+
+// NewUserService counts successful user mutations.
+func NewUserService(next inport.UserService, m *Metrics) inport.UserService {
+	return &userService{UserService: next, metrics: m}
+}
@@ -18,16 +18,31 @@ func (_m *ServerInterface) DeleteUsersUserId(w http.ResponseWriter, r *http.Requ
 	_m.Called(w, r, userId)
 }
 
+// DeleteUsersUserIdPersonalData provides a mock function with given fields: w, r, userId
+func (_m *ServerInterface) DeleteUsersUserIdPersonalData(w http.ResponseWriter, r *http.Request, userId string) {
+	_m.Called(w, r, userId)
+}
+
 // GetUsersUserId provides a mock function with given fields: w, r, userId
 func (_m *ServerInterface) GetUsersUserId(w http.ResponseWriter, r *http.Request, userId string) {
 	_m.Called(w, r, userId)
 }
 
+// GetUsersUserIdPersonalData provides a mock function with given fields: w, r, userId
+func (_m *ServerInterface) GetUsersUserIdPersonalData(w http.ResponseWriter, r *http.Request, userId string) {
+	_m.Called(w, r, userId)
+}
+
 // PostUsers provides a mock function with given fields: w, r
 func (_m *ServerInterface) PostUsers(w http.ResponseWriter, r *http.Request) {
 	_m.Called(w, r)
 }
 
+// PostUsersUserIdRestore provides a mock function with given fields: w, r, userId
+func (_m *ServerInterface) PostUsersUserIdRestore(w http.ResponseWriter, r *http.Request, userId string) {
+	_m.Called(w, r, userId)
+}
+
 // PutUsersUserId provides a mock function with given fields: w, r, userId
 func (_m *ServerInterface) PutUsersUserId(w http.ResponseWriter, r *http.Request, userId string) {
 	_m.Called(w, r, userId)
@@ -0,0 +1,17 @@
+package cache
+
+import (
+	"testing"
+	"time"
+	"user-domain/infrastructure/persistence/memory"
+	"user-domain/infrastructure/persistence/usertest"
+	"user-domain/internal/application/outbound"
+)
+
+// The cache must not change what its repository does.
+func TestContract(t *testing.T) {
+	t.Parallel()
+	usertest.Run(t, func(*testing.T) outbound.UserRepo {
+		return NewUserRepo(memory.NewUserRepo(), memory.NewTransactionManager(), 100, time.Minute)
+	})
+}
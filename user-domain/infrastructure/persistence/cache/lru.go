@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type lruEntry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+// lru is a size-bounded least-recently-used map whose entries also expire
+// after ttl. A zero ttl keeps entries until they are evicted.
+type lru[K comparable, V any] struct {
+	mu        sync.Mutex
+	capacity  int
+	ttl       time.Duration
+	ll        *list.List
+	items     map[K]*list.Element
+	evictions uint64
+	// epoch is bumped by every remove, so that a value loaded before one is
+	// not added after it.
+	epoch uint64
+	now   func() time.Time
+}
+
+func newLRU[K comparable, V any](capacity int, ttl time.Duration) *lru[K, V] {
+	return &lru[K, V]{
+		capacity: capacity,
+		ttl:      ttl,
+		ll:       list.New(),
+		items:    make(map[K]*list.Element, capacity),
+		now:      time.Now,
+	}
+}
+
+func (c *lru[K, V]) get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	el, ok := c.items[key]
+	if !ok {
+		return zero, false
+	}
+	e := el.Value.(*lruEntry[K, V])
+	if c.ttl > 0 && !c.now().Before(e.expiresAt) {
+		c.removeElement(el)
+		return zero, false
+	}
+	c.ll.MoveToFront(el)
+	return e.value, true
+}
+
+func (c *lru[K, V]) add(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.put(key, value)
+}
+
+// addIfEpoch adds key unless remove has been called since currentEpoch
+// returned epoch, and reports whether it did.
+func (c *lru[K, V]) addIfEpoch(key K, value V, epoch uint64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.epoch != epoch {
+		return false
+	}
+	c.put(key, value)
+	return true
+}
+
+func (c *lru[K, V]) currentEpoch() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.epoch
+}
+
+func (c *lru[K, V]) put(key K, value V) {
+	expiresAt := c.now().Add(c.ttl)
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*lruEntry[K, V])
+		e.value = value
+		e.expiresAt = expiresAt
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.ll.PushFront(&lruEntry[K, V]{key: key, value: value, expiresAt: expiresAt})
+	for c.capacity > 0 && c.ll.Len() > c.capacity {
+		c.removeElement(c.ll.Back())
+		c.evictions++
+	}
+}
+
+func (c *lru[K, V]) remove(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.epoch++
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+}
+
+func (c *lru[K, V]) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+func (c *lru[K, V]) evicted() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.evictions
+}
+
+func (c *lru[K, V]) removeElement(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*lruEntry[K, V]).key)
+}
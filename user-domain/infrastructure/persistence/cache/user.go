@@ -0,0 +1,197 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+	"user-domain/internal/application/outbound"
+	"user-domain/internal/entity"
+
+	"golang.org/x/sync/singleflight"
+)
+
+type Stats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Size      int
+}
+
+type UserRepo interface {
+	outbound.UserRepo
+	Stats() Stats
+}
+
+// userRepo caches GetUserByID results in process and coalesces concurrent
+// lookups of the same id into a single call to the wrapped repository.
+type userRepo struct {
+	next    outbound.UserRepo
+	tx      outbound.TransactionManager
+	primary func(ctx context.Context) context.Context
+	entries *lru[string, entity.User]
+	group   singleflight.Group
+	hits    atomic.Uint64
+	misses  atomic.Uint64
+}
+
+func (c *userRepo) CreateUser(ctx context.Context, user *entity.User) error {
+	return c.next.CreateUser(ctx, user)
+}
+
+func (c *userRepo) UpdateUser(ctx context.Context, user *entity.User) error {
+	defer c.invalidateAfterCommit(ctx, user.ID)
+	return c.next.UpdateUser(ctx, user)
+}
+
+func (c *userRepo) DeleteUser(ctx context.Context, id string) error {
+	defer c.invalidateAfterCommit(ctx, id)
+	return c.next.DeleteUser(ctx, id)
+}
+
+func (c *userRepo) RestoreUser(ctx context.Context, id string) error {
+	defer c.invalidateAfterCommit(ctx, id)
+	return c.next.RestoreUser(ctx, id)
+}
+
+func (c *userRepo) GetUserByID(ctx context.Context, id string) (*entity.User, error) {
+	// Inside a transaction the caller may see its own uncommitted writes,
+	// which must neither be served from nor leak into the cache.
+	if c.tx.InTransaction(ctx) {
+		return c.next.GetUserByID(ctx, id)
+	}
+	if u, ok := c.entries.get(id); ok {
+		c.hits.Add(1)
+		return &u, nil
+	}
+	c.misses.Add(1)
+
+	// The shared load must not be cancelled by whichever caller happened to
+	// start it, each caller still gives up on its own context below.
+	loadCtx := context.WithoutCancel(ctx)
+	ch := c.group.DoChan(id, func() (interface{}, error) {
+		// A load which started before an invalidation must not put the
+		// stale row back into the cache.
+		epoch := c.entries.currentEpoch()
+		u, err := c.next.GetUserByID(c.primary(loadCtx), id)
+		if err != nil {
+			return nil, err
+		}
+		c.entries.addIfEpoch(id, *u, epoch)
+		return *u, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		u := res.Val.(entity.User)
+		return &u, nil
+	}
+}
+
+// GetUsersByIDs serves the cached users and loads the others in one call.
+func (c *userRepo) GetUsersByIDs(ctx context.Context, ids []string) ([]*entity.User, error) {
+	if c.tx.InTransaction(ctx) {
+		return c.next.GetUsersByIDs(ctx, ids)
+	}
+	users := make([]*entity.User, 0, len(ids))
+	var missing []string
+	for _, id := range ids {
+		if u, ok := c.entries.get(id); ok {
+			c.hits.Add(1)
+			users = append(users, &u)
+			continue
+		}
+		c.misses.Add(1)
+		missing = append(missing, id)
+	}
+	if len(missing) == 0 {
+		return users, nil
+	}
+
+	epoch := c.entries.currentEpoch()
+	loaded, err := c.next.GetUsersByIDs(c.primary(ctx), missing)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range loaded {
+		c.entries.addIfEpoch(u.ID, *u, epoch)
+		users = append(users, u)
+	}
+	return users, nil
+}
+
+func (c *userRepo) GetUserByEmail(ctx context.Context, email string) (*entity.User, error) {
+	return c.next.GetUserByEmail(ctx, email)
+}
+
+func (c *userRepo) ListUsers(ctx context.Context, offset, limit int) ([]*entity.User, error) {
+	return c.next.ListUsers(ctx, offset, limit)
+}
+
+func (c *userRepo) GetPersonalData(ctx context.Context, id string) (*entity.PersonalData, error) {
+	return c.next.GetPersonalData(ctx, id)
+}
+
+func (c *userRepo) EraseUser(ctx context.Context, id string) error {
+	defer c.invalidateAfterCommit(ctx, id)
+	return c.next.EraseUser(ctx, id)
+}
+
+func (c *userRepo) RecordPersonalDataRequest(ctx context.Context, req *entity.PersonalDataRequest) error {
+	return c.next.RecordPersonalDataRequest(ctx, req)
+}
+
+func (c *userRepo) Stats() Stats {
+	return Stats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.entries.evicted(),
+		Size:      c.entries.len(),
+	}
+}
+
+// invalidateAfterCommit drops id once the write made with ctx is visible to
+// other readers. Dropping it earlier would let a concurrent read outside the
+// transaction put the old row back until the entry expires.
+func (c *userRepo) invalidateAfterCommit(ctx context.Context, id string) {
+	c.tx.AfterCommit(ctx, func() { c.invalidate(id) })
+}
+
+func (c *userRepo) invalidate(id string) {
+	c.entries.remove(id)
+	c.group.Forget(id)
+}
+
+// Option configures the cache.
+type Option func(*userRepo)
+
+// WithPrimaryReads fills the cache from reads made with the context primary
+// returns, which a replicated database serves from its primary. A lagging
+// replica would otherwise put back a row a write has just replaced, and the
+// cache would serve it to every client, those reading their own writes
+// included, until it expires.
+func WithPrimaryReads(primary func(ctx context.Context) context.Context) Option {
+	return func(c *userRepo) {
+		c.primary = primary
+	}
+}
+
+// NewUserRepo caches next. tx is the manager of the transactions next takes
+// part in; reads inside one bypass the cache and writes invalidate it when
+// it commits.
+func NewUserRepo(next outbound.UserRepo, tx outbound.TransactionManager, size int, ttl time.Duration, opts ...Option) UserRepo {
+	c := &userRepo{
+		next:    next,
+		tx:      tx,
+		primary: func(ctx context.Context) context.Context { return ctx },
+		entries: newLRU[string, entity.User](size, ttl),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
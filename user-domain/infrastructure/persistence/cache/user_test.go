@@ -0,0 +1,304 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+	"user-domain/infrastructure/persistence/memory"
+	application_mock "user-domain/internal/application/mocks/outbound"
+	"user-domain/internal/entity"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newCachedRepo(t *testing.T, size int, ttl time.Duration) (*userRepo, *application_mock.UserRepo) {
+	next := application_mock.NewUserRepo(t)
+	return NewUserRepo(next, memory.NewTransactionManager(), size, ttl).(*userRepo), next
+}
+
+func TestGetUserByIDCachesHits(t *testing.T) {
+	t.Parallel()
+	repo, next := newCachedRepo(t, 10, time.Minute)
+	next.On("GetUserByID", mock.Anything, "1").Return(&entity.User{ID: "1", Name: "Alice"}, nil).Once()
+
+	for i := 0; i < 3; i++ {
+		u, err := repo.GetUserByID(t.Context(), "1")
+		require.NoError(t, err)
+		require.Equal(t, "Alice", u.Name)
+	}
+
+	stats := repo.Stats()
+	require.Equal(t, uint64(2), stats.Hits)
+	require.Equal(t, uint64(1), stats.Misses)
+	require.Equal(t, 1, stats.Size)
+}
+
+func TestGetUserByIDReturnsCopies(t *testing.T) {
+	t.Parallel()
+	repo, next := newCachedRepo(t, 10, time.Minute)
+	next.On("GetUserByID", mock.Anything, "1").Return(&entity.User{ID: "1", Name: "Alice"}, nil).Once()
+
+	u, err := repo.GetUserByID(t.Context(), "1")
+	require.NoError(t, err)
+	u.Name = "changed by caller"
+
+	u, err = repo.GetUserByID(t.Context(), "1")
+	require.NoError(t, err)
+	require.Equal(t, "Alice", u.Name)
+}
+
+func TestGetUserByIDDoesNotCacheErrors(t *testing.T) {
+	t.Parallel()
+	repo, next := newCachedRepo(t, 10, time.Minute)
+	next.On("GetUserByID", mock.Anything, "1").Return((*entity.User)(nil), errors.New("boom")).Twice()
+
+	for i := 0; i < 2; i++ {
+		_, err := repo.GetUserByID(t.Context(), "1")
+		require.Error(t, err)
+	}
+	require.Equal(t, 0, repo.Stats().Size)
+}
+
+func TestWritesInvalidate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		write func(r *userRepo, next *application_mock.UserRepo) error
+	}{
+		{
+			name: "update",
+			write: func(r *userRepo, next *application_mock.UserRepo) error {
+				next.On("UpdateUser", mock.Anything, mock.Anything).Return(nil).Once()
+				return r.UpdateUser(t.Context(), &entity.User{ID: "1", Name: "Bob"})
+			},
+		},
+		{
+			name: "delete",
+			write: func(r *userRepo, next *application_mock.UserRepo) error {
+				next.On("DeleteUser", mock.Anything, "1").Return(nil).Once()
+				return r.DeleteUser(t.Context(), "1")
+			},
+		},
+		{
+			name: "restore",
+			write: func(r *userRepo, next *application_mock.UserRepo) error {
+				next.On("RestoreUser", mock.Anything, "1").Return(nil).Once()
+				return r.RestoreUser(t.Context(), "1")
+			},
+		},
+		{
+			name: "erase",
+			write: func(r *userRepo, next *application_mock.UserRepo) error {
+				next.On("EraseUser", mock.Anything, "1").Return(nil).Once()
+				return r.EraseUser(t.Context(), "1")
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			repo, next := newCachedRepo(t, 10, time.Minute)
+			next.On("GetUserByID", mock.Anything, "1").Return(&entity.User{ID: "1", Name: "Alice"}, nil).Twice()
+
+			_, err := repo.GetUserByID(t.Context(), "1")
+			require.NoError(t, err)
+			require.NoError(t, tt.write(repo, next))
+			_, err = repo.GetUserByID(t.Context(), "1")
+			require.NoError(t, err)
+			require.Equal(t, uint64(2), repo.Stats().Misses)
+		})
+	}
+}
+
+// A read outside a transaction that is still open sees, and caches, the row
+// as it was. The entry must go once the transaction commits.
+func TestWritesInvalidateAfterCommit(t *testing.T) {
+	t.Parallel()
+	writes := map[string]func(ctx context.Context, r *userRepo, next *application_mock.UserRepo) error{
+		"update": func(ctx context.Context, r *userRepo, next *application_mock.UserRepo) error {
+			next.On("UpdateUser", mock.Anything, mock.Anything).Return(nil).Once()
+			return r.UpdateUser(ctx, &entity.User{ID: "1", Name: "Bob"})
+		},
+		"delete": func(ctx context.Context, r *userRepo, next *application_mock.UserRepo) error {
+			next.On("DeleteUser", mock.Anything, "1").Return(nil).Once()
+			return r.DeleteUser(ctx, "1")
+		},
+		"restore": func(ctx context.Context, r *userRepo, next *application_mock.UserRepo) error {
+			next.On("RestoreUser", mock.Anything, "1").Return(nil).Once()
+			return r.RestoreUser(ctx, "1")
+		},
+		"erase": func(ctx context.Context, r *userRepo, next *application_mock.UserRepo) error {
+			next.On("EraseUser", mock.Anything, "1").Return(nil).Once()
+			return r.EraseUser(ctx, "1")
+		},
+	}
+	for name, write := range writes {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			repo, next := newCachedRepo(t, 10, time.Minute)
+			next.On("GetUserByID", mock.Anything, "1").Return(&entity.User{ID: "1", Name: "Alice"}, nil).Twice()
+
+			err := repo.tx.Do(t.Context(), func(ctx context.Context) error {
+				if err := write(ctx, repo, next); err != nil {
+					return err
+				}
+				_, err := repo.GetUserByID(t.Context(), "1")
+				require.NoError(t, err)
+				require.Equal(t, 1, repo.Stats().Size, "the uncommitted write must not drop the entry yet")
+				return nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, 0, repo.Stats().Size)
+			_, err = repo.GetUserByID(t.Context(), "1")
+			require.NoError(t, err)
+			require.Equal(t, uint64(2), repo.Stats().Misses)
+		})
+	}
+}
+
+func TestGetUserByIDBypassesCacheInTransaction(t *testing.T) {
+	t.Parallel()
+	repo, next := newCachedRepo(t, 10, time.Minute)
+	next.On("GetUserByID", mock.Anything, "1").Return(&entity.User{ID: "1", Name: "Alice"}, nil).Once()
+
+	require.NoError(t, repo.tx.Do(t.Context(), func(ctx context.Context) error {
+		_, err := repo.GetUserByID(ctx, "1")
+		return err
+	}))
+	require.Equal(t, 0, repo.Stats().Size)
+}
+
+func TestWithPrimaryReads(t *testing.T) {
+	t.Parallel()
+	type primaryKey struct{}
+	next := application_mock.NewUserRepo(t)
+	repo := NewUserRepo(next, memory.NewTransactionManager(), 10, time.Minute, WithPrimaryReads(func(ctx context.Context) context.Context {
+		return context.WithValue(ctx, primaryKey{}, true)
+	}))
+	fromPrimary := mock.MatchedBy(func(ctx context.Context) bool { return ctx.Value(primaryKey{}) == true })
+	next.On("GetUserByID", fromPrimary, "1").Return(&entity.User{ID: "1"}, nil).Once()
+	next.On("GetUsersByIDs", fromPrimary, []string{"2"}).Return([]*entity.User{{ID: "2"}}, nil).Once()
+
+	_, err := repo.GetUserByID(t.Context(), "1")
+	require.NoError(t, err)
+	users, err := repo.GetUsersByIDs(t.Context(), []string{"1", "2"})
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+}
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	t.Parallel()
+	c := newLRU[string, int](2, 0)
+	c.add("a", 1)
+	c.add("b", 2)
+	_, _ = c.get("a")
+	c.add("c", 3)
+
+	_, ok := c.get("b")
+	require.False(t, ok)
+	_, ok = c.get("a")
+	require.True(t, ok)
+	_, ok = c.get("c")
+	require.True(t, ok)
+	require.Equal(t, uint64(1), c.evicted())
+}
+
+func TestLRUExpiresEntries(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	c := newLRU[string, int](2, time.Minute)
+	c.now = func() time.Time { return now }
+	c.add("a", 1)
+
+	_, ok := c.get("a")
+	require.True(t, ok)
+
+	now = now.Add(time.Minute)
+	_, ok = c.get("a")
+	require.False(t, ok)
+	require.Equal(t, 0, c.len())
+}
+
+func TestLRUAddIfEpoch(t *testing.T) {
+	t.Parallel()
+	c := newLRU[string, int](2, 0)
+	epoch := c.currentEpoch()
+	require.True(t, c.addIfEpoch("a", 1, epoch))
+
+	c.remove("b")
+	require.False(t, c.addIfEpoch("a", 2, epoch), "a remove since the epoch was read must win")
+	v, ok := c.get("a")
+	require.True(t, ok)
+	require.Equal(t, 1, v)
+	require.True(t, c.addIfEpoch("a", 2, c.currentEpoch()))
+}
+
+func TestGetUserByIDCoalescesConcurrentLookups(t *testing.T) {
+	t.Parallel()
+	repo, next := newCachedRepo(t, 10, time.Minute)
+	release := make(chan struct{})
+	next.On("GetUserByID", mock.Anything, "1").
+		Run(func(mock.Arguments) { <-release }).
+		Return(&entity.User{ID: "1", Name: "Alice"}, nil).
+		Once()
+
+	const callers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.GetUserByID(context.Background(), "1")
+			errs <- err
+		}()
+	}
+	require.Eventually(t, func() bool { return repo.Stats().Misses == callers }, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+}
+
+func TestGetUserByIDHonoursCallerContext(t *testing.T) {
+	t.Parallel()
+	repo, next := newCachedRepo(t, 10, time.Minute)
+	release := make(chan struct{})
+	next.On("GetUserByID", mock.Anything, "1").
+		Run(func(mock.Arguments) { <-release }).
+		Return(&entity.User{ID: "1", Name: "Alice"}, nil).
+		Once()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	_, err := repo.GetUserByID(ctx, "1")
+	require.ErrorIs(t, err, context.Canceled)
+
+	close(release)
+	require.Eventually(t, func() bool { return repo.Stats().Size == 1 }, time.Second, time.Millisecond)
+}
+
+func TestGetUsersByIDsLoadsOnlyMisses(t *testing.T) {
+	t.Parallel()
+	repo, next := newCachedRepo(t, 10, time.Minute)
+	next.On("GetUserByID", mock.Anything, "1").Return(&entity.User{ID: "1", Name: "Alice"}, nil).Once()
+	next.On("GetUsersByIDs", mock.Anything, []string{"2", "3"}).Return([]*entity.User{{ID: "2", Name: "Bob"}}, nil).Once()
+
+	_, err := repo.GetUserByID(t.Context(), "1")
+	require.NoError(t, err)
+	users, err := repo.GetUsersByIDs(t.Context(), []string{"1", "2", "3"})
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+
+	// Bob is cached now, only the unknown id is looked up again.
+	next.On("GetUsersByIDs", mock.Anything, []string{"3"}).Return([]*entity.User{}, nil).Once()
+	users, err = repo.GetUsersByIDs(t.Context(), []string{"2", "3"})
+	require.NoError(t, err)
+	require.Equal(t, "Bob", users[0].Name)
+}
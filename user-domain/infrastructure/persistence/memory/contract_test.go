@@ -0,0 +1,12 @@
+package memory
+
+import (
+	"testing"
+	"user-domain/infrastructure/persistence/usertest"
+	"user-domain/internal/application/outbound"
+)
+
+func TestContract(t *testing.T) {
+	t.Parallel()
+	usertest.Run(t, func(*testing.T) outbound.UserRepo { return NewUserRepo() })
+}
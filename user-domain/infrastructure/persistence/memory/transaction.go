@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"user-domain/internal/application/outbound"
+)
+
+type txKey struct{}
+
+// hooks holds the functions to run once the outermost Do returns.
+type hooks struct {
+	mu  sync.Mutex
+	fns []func()
+}
+
+type manager struct{}
+
+// NewTransactionManager returns a TransactionManager for the memory
+// repositories. Each repository call is atomic on its own, but fn is not:
+// calls it made before failing are not rolled back.
+func NewTransactionManager() outbound.TransactionManager {
+	return manager{}
+}
+
+func (manager) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, nested := ctx.Value(txKey{}).(*hooks); nested {
+		return fn(ctx)
+	}
+	h := &hooks{}
+	err := fn(context.WithValue(ctx, txKey{}, h))
+	// Nothing is rolled back, so the hooks run whether fn failed or not.
+	for _, f := range h.fns {
+		f()
+	}
+	return err
+}
+
+func (manager) AfterCommit(ctx context.Context, fn func()) {
+	h, ok := ctx.Value(txKey{}).(*hooks)
+	if !ok {
+		fn()
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.fns = append(h.fns, fn)
+}
+
+func (manager) InTransaction(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(*hooks)
+	return ok
+}
@@ -0,0 +1,255 @@
+// Package memory keeps users in process. It behaves like the postgres
+// adapter, so unit tests and local demos can use it in place of a database.
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+	"user-domain/internal/application/outbound"
+	domainerror "user-domain/internal/domain/error"
+	"user-domain/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+type record struct {
+	user    entity.User
+	deleted bool
+	// The times GetPersonalData reports; deletedAt and erasedAt are zero
+	// unless the user was deleted or erased.
+	createdAt, updatedAt, deletedAt, erasedAt time.Time
+}
+
+type userRepo struct {
+	mu    sync.RWMutex
+	users map[string]*record
+	// order holds the IDs in creation order, the order ListUsers pages
+	// through like the postgres adapter's ORDER BY created_at.
+	order []string
+	// emails indexes the users that are not deleted, it is the unique index
+	// on users.email.
+	emails map[string]string
+	// requests holds the personal data requests by user ID, oldest first.
+	requests map[string][]entity.PersonalDataRequest
+}
+
+// NewUserRepo returns an empty repository that is safe for concurrent use.
+// Like the postgres adapter it assigns IDs on create, soft-deletes, and
+// rejects an email that another user that is not deleted already has.
+func NewUserRepo() outbound.UserRepo {
+	return &userRepo{users: map[string]*record{}, emails: map[string]string{}, requests: map[string][]entity.PersonalDataRequest{}}
+}
+
+func (r *userRepo) CreateUser(_ context.Context, user *entity.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.emails[user.Email]; ok {
+		return emailTaken()
+	}
+	u := *user
+	u.ID = uuid.NewString()
+	now := time.Now()
+	r.users[u.ID] = &record{user: u, createdAt: now, updatedAt: now}
+	r.order = append(r.order, u.ID)
+	r.emails[u.Email] = u.ID
+	user.ID = u.ID
+	return nil
+}
+
+// UpdateUser sets the fields of user that are not empty, as gorm's Updates
+// does.
+func (r *userRepo) UpdateUser(_ context.Context, user *entity.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rec, ok := r.users[user.ID]
+	if !ok || rec.deleted {
+		return notFound(user.ID)
+	}
+	if user.Email != "" && user.Email != rec.user.Email {
+		if _, ok := r.emails[user.Email]; ok {
+			return emailTaken()
+		}
+		delete(r.emails, rec.user.Email)
+		r.emails[user.Email] = user.ID
+		rec.user.Email = user.Email
+	}
+	if user.Name != "" {
+		rec.user.Name = user.Name
+	}
+	if user.Phone != "" {
+		rec.user.Phone = user.Phone
+	}
+	if user.Address != "" {
+		rec.user.Address = user.Address
+	}
+	rec.updatedAt = time.Now()
+	return nil
+}
+
+// DeleteUser soft-deletes the user, deleting a user that does not exist or
+// is already deleted does nothing.
+func (r *userRepo) DeleteUser(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if rec, ok := r.users[id]; ok && !rec.deleted {
+		rec.deleted = true
+		rec.deletedAt = time.Now()
+		rec.updatedAt = rec.deletedAt
+		delete(r.emails, rec.user.Email)
+	}
+	return nil
+}
+
+func (r *userRepo) RestoreUser(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rec, ok := r.users[id]
+	if !ok || !rec.erasedAt.IsZero() {
+		return notFound(id)
+	}
+	if !rec.deleted {
+		return nil
+	}
+	if _, ok := r.emails[rec.user.Email]; ok {
+		return emailTaken()
+	}
+	rec.deleted = false
+	rec.deletedAt = time.Time{}
+	rec.updatedAt = time.Now()
+	r.emails[rec.user.Email] = id
+	return nil
+}
+
+func (r *userRepo) GetUserByID(_ context.Context, id string) (*entity.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rec, ok := r.users[id]
+	if !ok || rec.deleted {
+		return nil, notFound(id)
+	}
+	u := rec.user
+	return &u, nil
+}
+
+// GetUsersByIDs returns the users that exist, in the order of ids, each
+// once.
+func (r *userRepo) GetUsersByIDs(_ context.Context, ids []string) ([]*entity.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	users := []*entity.User{}
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		rec, ok := r.users[id]
+		if !ok || rec.deleted || seen[id] {
+			continue
+		}
+		seen[id] = true
+		u := rec.user
+		users = append(users, &u)
+	}
+	return users, nil
+}
+
+func (r *userRepo) GetUserByEmail(_ context.Context, email string) (*entity.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	id, ok := r.emails[email]
+	if !ok {
+		return nil, domainerror.New(domainerror.ErrCodeNotFound, domainerror.CodeUserNotFound, "no user has this email")
+	}
+	u := r.users[id].user
+	return &u, nil
+}
+
+// ListUsers pages through the users that are not deleted, oldest first. A
+// negative limit means no limit.
+func (r *userRepo) ListUsers(_ context.Context, offset, limit int) ([]*entity.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	users := []*entity.User{}
+	for _, id := range r.order {
+		if limit >= 0 && len(users) == limit {
+			break
+		}
+		rec := r.users[id]
+		if rec.deleted {
+			continue
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		u := rec.user
+		users = append(users, &u)
+	}
+	return users, nil
+}
+
+// GetPersonalData returns the user, deleted or erased too.
+func (r *userRepo) GetPersonalData(_ context.Context, id string) (*entity.PersonalData, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rec, ok := r.users[id]
+	if !ok {
+		return nil, notFound(id)
+	}
+	data := &entity.PersonalData{
+		User:      rec.user,
+		CreatedAt: rec.createdAt,
+		UpdatedAt: rec.updatedAt,
+		Requests:  append([]entity.PersonalDataRequest{}, r.requests[id]...),
+	}
+	if rec.deleted {
+		deletedAt := rec.deletedAt
+		data.DeletedAt = &deletedAt
+	}
+	if !rec.erasedAt.IsZero() {
+		erasedAt := rec.erasedAt
+		data.ErasedAt = &erasedAt
+	}
+	return data, nil
+}
+
+// EraseUser overwrites the user's personal data and deletes it, erasing a
+// user twice only moves the time it was erased.
+func (r *userRepo) EraseUser(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rec, ok := r.users[id]
+	if !ok {
+		return notFound(id)
+	}
+	now := time.Now()
+	if !rec.deleted {
+		rec.deleted = true
+		rec.deletedAt = now
+		delete(r.emails, rec.user.Email)
+	}
+	rec.user = entity.ErasedUser(id)
+	rec.erasedAt = now
+	rec.updatedAt = now
+	return nil
+}
+
+// RecordPersonalDataRequest rejects a user that does not exist, as the
+// foreign key of the postgres adapter does.
+func (r *userRepo) RecordPersonalDataRequest(_ context.Context, req *entity.PersonalDataRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[req.UserID]; !ok {
+		return notFound(req.UserID)
+	}
+	req.ID = uuid.NewString()
+	req.RequestedAt = time.Now()
+	r.requests[req.UserID] = append(r.requests[req.UserID], *req)
+	return nil
+}
+
+func notFound(id string) error {
+	return domainerror.New(domainerror.ErrCodeNotFound, domainerror.CodeUserNotFound, "user "+id+" was not found")
+}
+
+func emailTaken() error {
+	return domainerror.New(domainerror.ErrCodeConflict, domainerror.CodeEmailTaken, "email is already in use")
+}
@@ -0,0 +1,114 @@
+package memory
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	domainerror "user-domain/internal/domain/error"
+	"user-domain/internal/entity"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserRepo(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	repo := NewUserRepo()
+
+	an := &entity.User{ID: "ignored", Name: "An", Email: "an@example.com"}
+	require.NoError(t, repo.CreateUser(ctx, an))
+	require.NotEqual(t, "ignored", an.ID)
+	err := repo.CreateUser(ctx, &entity.User{Name: "An again", Email: "an@example.com"})
+	require.Equal(t, domainerror.CodeEmailTaken, domainerror.CodeOf(err))
+
+	// Callers cannot change stored users behind the repository's back.
+	got, err := repo.GetUserByID(ctx, an.ID)
+	require.NoError(t, err)
+	got.Name = "changed"
+	got, err = repo.GetUserByEmail(ctx, "an@example.com")
+	require.NoError(t, err)
+	require.Equal(t, "An", got.Name)
+
+	require.NoError(t, repo.UpdateUser(ctx, &entity.User{ID: an.ID, Phone: "+84901234567"}))
+	got, err = repo.GetUserByID(ctx, an.ID)
+	require.NoError(t, err)
+	require.Equal(t, entity.User{ID: an.ID, Name: "An", Email: "an@example.com", Phone: "+84901234567"}, *got)
+	err = repo.UpdateUser(ctx, &entity.User{ID: "missing", Name: "x"})
+	require.Equal(t, domainerror.CodeUserNotFound, domainerror.CodeOf(err))
+
+	// Deleting frees the email, restoring needs it back.
+	require.NoError(t, repo.DeleteUser(ctx, an.ID))
+	require.NoError(t, repo.DeleteUser(ctx, an.ID))
+	_, err = repo.GetUserByID(ctx, an.ID)
+	require.ErrorIs(t, err, domainerror.ErrCodeNotFound)
+	other := &entity.User{Name: "Other An", Email: "an@example.com"}
+	require.NoError(t, repo.CreateUser(ctx, other))
+	err = repo.RestoreUser(ctx, an.ID)
+	require.ErrorIs(t, err, domainerror.ErrCodeConflict)
+	require.NoError(t, repo.DeleteUser(ctx, other.ID))
+	require.NoError(t, repo.RestoreUser(ctx, an.ID))
+	require.NoError(t, repo.RestoreUser(ctx, an.ID))
+	err = repo.RestoreUser(ctx, "missing")
+	require.Equal(t, domainerror.CodeUserNotFound, domainerror.CodeOf(err))
+
+	users, err := repo.GetUsersByIDs(ctx, []string{other.ID, an.ID, "missing", an.ID})
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	require.Equal(t, an.ID, users[0].ID)
+}
+
+func TestListUsersPagesInCreationOrder(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	repo := NewUserRepo()
+	var ids []string
+	for i := range 5 {
+		u := &entity.User{Name: fmt.Sprint(i), Email: fmt.Sprintf("%d@example.com", i)}
+		require.NoError(t, repo.CreateUser(ctx, u))
+		ids = append(ids, u.ID)
+	}
+	require.NoError(t, repo.DeleteUser(ctx, ids[1]))
+
+	page := func(offset, limit int) []string {
+		users, err := repo.ListUsers(ctx, offset, limit)
+		require.NoError(t, err)
+		var got []string
+		for _, u := range users {
+			got = append(got, u.ID)
+		}
+		return got
+	}
+	require.Equal(t, []string{ids[0], ids[2]}, page(0, 2))
+	require.Equal(t, []string{ids[3], ids[4]}, page(2, 2))
+	require.Empty(t, page(4, 2))
+	require.Equal(t, []string{ids[0], ids[2], ids[3], ids[4]}, page(0, -1))
+}
+
+func TestConcurrentCreates(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	repo := NewUserRepo()
+	var wg sync.WaitGroup
+	errs := make(chan error, 50)
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Every other goroutine races for the same email.
+			errs <- repo.CreateUser(ctx, &entity.User{Name: "n", Email: fmt.Sprintf("%d@example.com", i%25)})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	conflicts := 0
+	for err := range errs {
+		if err != nil {
+			require.Equal(t, domainerror.CodeEmailTaken, domainerror.CodeOf(err))
+			conflicts++
+		}
+	}
+	require.Equal(t, 25, conflicts)
+	users, err := repo.ListUsers(ctx, 0, 100)
+	require.NoError(t, err)
+	require.Len(t, users, 25)
+}
@@ -12,51 +12,58 @@ import (
 )
 
 var (
-	Q               = new(Query)
-	SchemaMigration *schemaMigration
-	User            *user
+	Q                   = new(Query)
+	PersonalDataRequest *personalDataRequest
+	SchemaMigration     *schemaMigration
+	User                *user
 )
 
 func SetDefault(db *gorm.DB) {
 	*Q = *Use(db)
+	PersonalDataRequest = &Q.PersonalDataRequest
 	SchemaMigration = &Q.SchemaMigration
 	User = &Q.User
 }
 
 func Use(db *gorm.DB) *Query {
 	return &Query{
-		db:              db,
-		SchemaMigration: newSchemaMigration(db),
-		User:            newUser(db),
+		db:                  db,
+		PersonalDataRequest: newPersonalDataRequest(db),
+		SchemaMigration:     newSchemaMigration(db),
+		User:                newUser(db),
 	}
 }
 
 type Query struct {
 	db *gorm.DB
 
-	SchemaMigration schemaMigration
-	User            user
+	PersonalDataRequest personalDataRequest
+	SchemaMigration     schemaMigration
+	User                user
 }
 
 func (q *Query) Available() bool { return q.db != nil }
 
 func (q *Query) clone(db *gorm.DB) *Query {
 	return &Query{
-		db:              db,
-		SchemaMigration: q.SchemaMigration.clone(db),
-		User:            q.User.clone(db),
+		db:                  db,
+		PersonalDataRequest: q.PersonalDataRequest.clone(db),
+		SchemaMigration:     q.SchemaMigration.clone(db),
+		User:                q.User.clone(db),
 	}
 }
 
 type queryCtx struct {
-	SchemaMigration ISchemaMigrationDo
-	User            IUserDo
+	PersonalDataRequest IPersonalDataRequestDo
+	SchemaMigration     ISchemaMigrationDo
+	User                IUserDo
 }
 
 func (q *Query) WithContext(ctx context.Context) *queryCtx {
 	return &queryCtx{
-		SchemaMigration: q.SchemaMigration.WithContext(ctx),
-		User:            q.User.WithContext(ctx),
+		PersonalDataRequest: q.PersonalDataRequest.WithContext(ctx),
+		SchemaMigration:     q.SchemaMigration.WithContext(ctx),
+		User:                q.User.WithContext(ctx),
 	}
 }
 
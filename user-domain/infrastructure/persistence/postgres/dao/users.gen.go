@@ -34,6 +34,8 @@ func newUser(db *gorm.DB) user {
 	_user.Email = field.NewString(tableName, "email")
 	_user.Phone = field.NewString(tableName, "phone")
 	_user.Name = field.NewString(tableName, "name")
+	_user.ErasedAt = field.NewTime(tableName, "erased_at")
+	_user.EmailIndex = field.NewString(tableName, "email_index")
 
 	_user.fillFieldMap()
 
@@ -43,14 +45,16 @@ func newUser(db *gorm.DB) user {
 type user struct {
 	userDo
 
-	ALL       field.Asterisk
-	ID        field.String
-	CreatedAt field.Time
-	UpdatedAt field.Time
-	DeletedAt field.Field
-	Email     field.String
-	Phone     field.String
-	Name      field.String
+	ALL        field.Asterisk
+	ID         field.String
+	CreatedAt  field.Time
+	UpdatedAt  field.Time
+	DeletedAt  field.Field
+	Email      field.String
+	Phone      field.String
+	Name       field.String
+	ErasedAt   field.Time
+	EmailIndex field.String
 
 	fieldMap map[string]field.Expr
 }
@@ -74,6 +78,8 @@ func (u *user) updateTableName(table string) *user {
 	u.Email = field.NewString(table, "email")
 	u.Phone = field.NewString(table, "phone")
 	u.Name = field.NewString(table, "name")
+	u.ErasedAt = field.NewTime(table, "erased_at")
+	u.EmailIndex = field.NewString(table, "email_index")
 
 	u.fillFieldMap()
 
@@ -90,7 +96,7 @@ func (u *user) GetFieldByName(fieldName string) (field.OrderExpr, bool) {
 }
 
 func (u *user) fillFieldMap() {
-	u.fieldMap = make(map[string]field.Expr, 7)
+	u.fieldMap = make(map[string]field.Expr, 9)
 	u.fieldMap["id"] = u.ID
 	u.fieldMap["created_at"] = u.CreatedAt
 	u.fieldMap["updated_at"] = u.UpdatedAt
@@ -98,6 +104,8 @@ func (u *user) fillFieldMap() {
 	u.fieldMap["email"] = u.Email
 	u.fieldMap["phone"] = u.Phone
 	u.fieldMap["name"] = u.Name
+	u.fieldMap["erased_at"] = u.ErasedAt
+	u.fieldMap["email_index"] = u.EmailIndex
 }
 
 func (u user) clone(db *gorm.DB) user {
@@ -0,0 +1,82 @@
+package transaction
+
+import (
+	"context"
+	"sync"
+	"user-domain/internal/application/outbound"
+
+	"gorm.io/gorm"
+)
+
+type txKey struct{}
+
+type hooksKey struct{}
+
+// hooks holds the functions to run once the outermost transaction commits.
+type hooks struct {
+	mu  sync.Mutex
+	fns []func()
+}
+
+type manager struct {
+	db *gorm.DB
+}
+
+// NewManager returns a TransactionManager that binds a gorm transaction into
+// the context it hands to fn. Nested calls join the outer transaction
+// through a savepoint.
+func NewManager(db *gorm.DB) outbound.TransactionManager {
+	return &manager{db: db}
+}
+
+func (m *manager) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	h, nested := ctx.Value(hooksKey{}).(*hooks)
+	if !nested {
+		h = &hooks{}
+		ctx = context.WithValue(ctx, hooksKey{}, h)
+	}
+	err := DB(ctx, m.db).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+	if err != nil || nested {
+		return err
+	}
+	for _, f := range h.fns {
+		f()
+	}
+	return nil
+}
+
+// AfterCommit runs fn once the transaction bound to ctx has committed, and
+// not at all if it rolls back. Outside a transaction fn runs straight away.
+func (m *manager) AfterCommit(ctx context.Context, fn func()) {
+	h, ok := ctx.Value(hooksKey{}).(*hooks)
+	if !ok {
+		fn()
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.fns = append(h.fns, fn)
+}
+
+func (m *manager) InTransaction(ctx context.Context) bool {
+	_, ok := FromContext(ctx)
+	return ok
+}
+
+// DB returns the transaction bound to ctx by Do, or db when there is none,
+// in both cases scoped to ctx. Adapters call it instead of using their own
+// handle so that they take part in a surrounding transaction.
+func DB(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
+
+// FromContext returns the transaction bound to ctx by Do.
+func FromContext(ctx context.Context) (*gorm.DB, bool) {
+	tx, ok := ctx.Value(txKey{}).(*gorm.DB)
+	return tx, ok
+}
@@ -0,0 +1,124 @@
+package transaction_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"user-domain/infrastructure/persistence/postgres/transaction"
+	userpersistence "user-domain/infrastructure/persistence/postgres/user"
+	"user-domain/internal/entity"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func newGorm(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, mock.ExpectationsWereMet()) })
+	g, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{SkipDefaultTransaction: true})
+	require.NoError(t, err)
+	return g, mock
+}
+
+func TestDoCommitsRepositoryCalls(t *testing.T) {
+	t.Parallel()
+	db, mock := newGorm(t)
+	repo := userpersistence.NewUserRepo(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "users"`).WillReturnRows(sqlmock.NewRows([]string{"created_at"}))
+	mock.ExpectExec(`UPDATE "users"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := transaction.NewManager(db).Do(context.Background(), func(ctx context.Context) error {
+		_, ok := transaction.FromContext(ctx)
+		require.True(t, ok)
+		if err := repo.CreateUser(ctx, &entity.User{Name: "An", Email: "an@example.com"}); err != nil {
+			return err
+		}
+		return repo.UpdateUser(ctx, &entity.User{ID: "1", Name: "Binh"})
+	})
+	require.NoError(t, err)
+}
+
+func TestDoRollsBackOnError(t *testing.T) {
+	t.Parallel()
+	db, mock := newGorm(t)
+	repo := userpersistence.NewUserRepo(db)
+	failed := errors.New("second step failed")
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "users"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	err := transaction.NewManager(db).Do(context.Background(), func(ctx context.Context) error {
+		if err := repo.UpdateUser(ctx, &entity.User{ID: "1", Name: "Binh"}); err != nil {
+			return err
+		}
+		return failed
+	})
+	require.ErrorIs(t, err, failed)
+}
+
+func TestNestedDoUsesSavepoint(t *testing.T) {
+	t.Parallel()
+	db, mock := newGorm(t)
+	failed := errors.New("inner failed")
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SAVEPOINT`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`ROLLBACK TO SAVEPOINT`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	m := transaction.NewManager(db)
+	err := m.Do(context.Background(), func(ctx context.Context) error {
+		outer, _ := transaction.FromContext(ctx)
+		err := m.Do(ctx, func(ctx context.Context) error {
+			inner, _ := transaction.FromContext(ctx)
+			require.Equal(t, outer.Statement.ConnPool, inner.Statement.ConnPool)
+			return failed
+		})
+		require.ErrorIs(t, err, failed)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestAfterCommit(t *testing.T) {
+	t.Parallel()
+	db, mock := newGorm(t)
+	m := transaction.NewManager(db)
+	var ran []string
+
+	m.AfterCommit(context.Background(), func() { ran = append(ran, "outside") })
+	require.Equal(t, []string{"outside"}, ran)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SAVEPOINT`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	err := m.Do(context.Background(), func(ctx context.Context) error {
+		require.True(t, m.InTransaction(ctx))
+		err := m.Do(ctx, func(ctx context.Context) error {
+			m.AfterCommit(ctx, func() { ran = append(ran, "inner") })
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"outside"}, ran, "hooks wait for the outermost commit")
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"outside", "inner"}, ran)
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	err = m.Do(context.Background(), func(ctx context.Context) error {
+		m.AfterCommit(ctx, func() { ran = append(ran, "rolled back") })
+		return errors.New("failed")
+	})
+	require.Error(t, err)
+	require.Equal(t, []string{"outside", "inner"}, ran)
+	require.False(t, m.InTransaction(context.Background()))
+}
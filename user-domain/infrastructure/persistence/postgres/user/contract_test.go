@@ -0,0 +1,143 @@
+package postgres_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"os"
+	"sync"
+	"testing"
+	"time"
+	"user-domain/infrastructure/database/migration"
+	"user-domain/infrastructure/encryption"
+	userpersistence "user-domain/infrastructure/persistence/postgres/user"
+	"user-domain/infrastructure/persistence/usertest"
+	"user-domain/internal/application/outbound"
+	"user-domain/internal/entity"
+
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	tcpostgres "github.com/testcontainers/testcontainers-go/modules/postgres"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// scratch is the postgres container the tests share when TEST_POSTGRES_DSN
+// is not set. It is started by the first test that needs it.
+var scratch struct {
+	once      sync.Once
+	container *tcpostgres.PostgresContainer
+	dsn       string
+	err       error
+}
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if scratch.container != nil {
+		_ = testcontainers.TerminateContainer(scratch.container)
+	}
+	os.Exit(code)
+}
+
+// testDSN returns TEST_POSTGRES_DSN, or else the address of a postgres
+// container started for these tests. t is skipped only when neither is
+// possible because there is no docker to start the container in.
+func testDSN(t *testing.T) string {
+	t.Helper()
+	if dsn := os.Getenv("TEST_POSTGRES_DSN"); dsn != "" {
+		return dsn
+	}
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+	scratch.once.Do(func() {
+		ctx := context.Background()
+		scratch.container, scratch.err = tcpostgres.Run(ctx, "postgres:16",
+			tcpostgres.WithDatabase("users_test"),
+			tcpostgres.BasicWaitStrategies(),
+		)
+		if scratch.err != nil {
+			return
+		}
+		scratch.dsn, scratch.err = scratch.container.ConnectionString(ctx, "sslmode=disable")
+	})
+	require.NoError(t, scratch.err)
+	return scratch.dsn
+}
+
+// openTestDB migrates the database testDSN returns. Tests using it empty the
+// user tables, never point TEST_POSTGRES_DSN at a database whose data
+// matters.
+func openTestDB(t *testing.T) (*sql.DB, *gorm.DB) {
+	t.Helper()
+	db, err := sql.Open("postgres", testDSN(t))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	m, err := migration.New(t.Context(), db, "postgres", "", time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, m.Up(t.Context()))
+	require.NoError(t, m.Close())
+
+	g, err := gorm.Open(postgres.New(postgres.Config{Conn: db}))
+	require.NoError(t, err)
+	return db, g
+}
+
+func truncate(t *testing.T, db *sql.DB) {
+	t.Helper()
+	_, err := db.ExecContext(t.Context(), `TRUNCATE "personal_data_requests", "users"`)
+	require.NoError(t, err)
+}
+
+func TestContract(t *testing.T) {
+	db, g := openTestDB(t)
+	usertest.Run(t, func(t *testing.T) outbound.UserRepo {
+		truncate(t, db)
+		return userpersistence.NewUserRepo(g)
+	})
+}
+
+// TestContractEncrypted runs the suite with email and phone encrypted, and
+// checks that they are not stored in plaintext.
+func TestContractEncrypted(t *testing.T) {
+	db, g := openTestDB(t)
+	envelope := newEnvelope(t)
+	usertest.Run(t, func(t *testing.T) outbound.UserRepo {
+		truncate(t, db)
+		t.Cleanup(func() {
+			var plaintext int64
+			require.NoError(t, g.Table("users").Where("erased_at IS NULL AND email LIKE ?", "%@%").Count(&plaintext).Error)
+			require.Zero(t, plaintext, "emails are stored in plaintext")
+		})
+		return userpersistence.NewUserRepo(g, userpersistence.WithEncryption(envelope))
+	})
+}
+
+// TestRotatePostgres pages through a uuid id column, which sqlite does not
+// have.
+func TestRotatePostgres(t *testing.T) {
+	db, g := openTestDB(t)
+	truncate(t, db)
+
+	plain := userpersistence.NewUserRepo(g)
+	for _, name := range []string{"an", "binh", "chi"} {
+		require.NoError(t, plain.CreateUser(t.Context(), &entity.User{Name: name, Email: name + "@example.com", Phone: "0901234567"}))
+	}
+	envelope := newEnvelope(t)
+
+	stats, err := userpersistence.NewKeyRotator(g, envelope).Rotate(t.Context(), 2, nil)
+	require.NoError(t, err)
+	require.Equal(t, userpersistence.RotateStats{Scanned: 3, Rotated: 3}, stats)
+
+	u, err := userpersistence.NewUserRepo(g, userpersistence.WithEncryption(envelope)).GetUserByEmail(t.Context(), "binh@example.com")
+	require.NoError(t, err)
+	require.Equal(t, "0901234567", u.Phone)
+}
+
+func newEnvelope(t *testing.T) *encryption.Envelope {
+	t.Helper()
+	kms, err := encryption.NewLocalKMS("k1:"+base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32)), "")
+	require.NoError(t, err)
+	return encryption.New(kms, bytes.Repeat([]byte{9}, 32))
+}
@@ -1,6 +1,10 @@
 package postgres
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"user-domain/infrastructure/encryption"
 	"user-domain/infrastructure/persistence/postgres/model"
 	"user-domain/internal/entity"
 )
@@ -30,3 +34,101 @@ func CreateUsersEntityFromUsesrModel(usersModel []*model.User) []*entity.User {
 	}
 	return result
 }
+
+func CreatePersonalDataFromModels(u *model.User, requests []*model.PersonalDataRequest) *entity.PersonalData {
+	data := &entity.PersonalData{
+		User:      *CreateUserEntityFromUserModel(u),
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.UpdatedAt,
+		Requests:  make([]entity.PersonalDataRequest, 0, len(requests)),
+	}
+	if u.DeletedAt.Valid {
+		data.DeletedAt = &u.DeletedAt.Time
+	}
+	if !u.ErasedAt.IsZero() {
+		data.ErasedAt = &u.ErasedAt
+	}
+	for _, r := range requests {
+		data.Requests = append(data.Requests, entity.PersonalDataRequest{
+			ID:          r.ID,
+			UserID:      r.UserID,
+			Kind:        r.Kind,
+			RequestedAt: r.RequestedAt,
+		})
+	}
+	return data
+}
+
+func CreatePersonalDataRequestModel(r *entity.PersonalDataRequest) *model.PersonalDataRequest {
+	return &model.PersonalDataRequest{
+		ID:     r.ID,
+		UserID: r.UserID,
+		Kind:   r.Kind,
+	}
+}
+
+// pii encrypts the columns of a user holding personal data, email and phone,
+// on their way to the database and decrypts them on their way back. Without
+// an envelope they are stored in plaintext; values stored before encryption
+// was turned on are read as they are either way.
+type pii struct {
+	envelope *encryption.Envelope
+}
+
+// seal encrypts the email and phone of u, which must have its ID, and sets
+// its email index. Empty fields stay empty, so that Updates skips them.
+func (p pii) seal(ctx context.Context, u *model.User) error {
+	if p.envelope == nil {
+		return nil
+	}
+	u.EmailIndex = p.envelope.Index(u.Email)
+	var err error
+	if u.Email, err = p.envelope.Encrypt(ctx, u.Email, aad(u.ID, "email")); err != nil {
+		return fmt.Errorf("encrypt email of user %s: %w", u.ID, err)
+	}
+	if u.Phone, err = p.envelope.Encrypt(ctx, u.Phone, aad(u.ID, "phone")); err != nil {
+		return fmt.Errorf("encrypt phone of user %s: %w", u.ID, err)
+	}
+	return nil
+}
+
+// SealUser returns u as the repository stores it with e: its email and
+// phone encrypted and its email index set. With a nil e they stay in
+// plaintext. Bulk loaders writing around the repository use it.
+func SealUser(ctx context.Context, e *encryption.Envelope, u *entity.User) (*model.User, error) {
+	m := CreateRepoEntityFromUserEntity(u)
+	if err := (pii{envelope: e}).seal(ctx, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// open decrypts the email and phone of users in place.
+func (p pii) open(ctx context.Context, users ...*model.User) error {
+	for _, u := range users {
+		var err error
+		if u.Email, err = p.decrypt(ctx, u.Email, aad(u.ID, "email")); err != nil {
+			return fmt.Errorf("decrypt email of user %s: %w", u.ID, err)
+		}
+		if u.Phone, err = p.decrypt(ctx, u.Phone, aad(u.ID, "phone")); err != nil {
+			return fmt.Errorf("decrypt phone of user %s: %w", u.ID, err)
+		}
+	}
+	return nil
+}
+
+func (p pii) decrypt(ctx context.Context, value, aad string) (string, error) {
+	if p.envelope == nil {
+		if encryption.IsEncrypted(value) {
+			return "", errors.New("value is encrypted but no master key is configured")
+		}
+		return value, nil
+	}
+	return p.envelope.Decrypt(ctx, value, aad)
+}
+
+// aad binds an encrypted value to its row and column, so that it cannot be
+// copied to another one.
+func aad(id, column string) string {
+	return "users/" + id + "/" + column
+}
@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"user-domain/infrastructure/encryption"
+	"user-domain/infrastructure/persistence/postgres/dao"
+	"user-domain/infrastructure/persistence/postgres/model"
+	"user-domain/infrastructure/persistence/util"
+
+	"gorm.io/gen"
+	"gorm.io/gen/field"
+	"gorm.io/gorm"
+)
+
+// KeyRotator re-encrypts the personal data of users that is not encrypted
+// with the active master key, including data stored in plaintext before
+// encryption was turned on.
+type KeyRotator struct {
+	query dao.Query
+	pii   pii
+}
+
+// RotateStats counts the users a rotation went through and those of them
+// it re-encrypted.
+type RotateStats struct {
+	Scanned int
+	Rotated int
+}
+
+func NewKeyRotator(db *gorm.DB, e *encryption.Envelope) *KeyRotator {
+	return &KeyRotator{query: *dao.Use(db), pii: pii{envelope: e}}
+}
+
+// Rotate goes through the users, deleted ones included, batchSize at a
+// time in id order, and calls progress after every batch. Erased users hold
+// no personal data and are skipped. A row written while Rotate has it in
+// hand is left alone, the write has encrypted it already.
+func (r *KeyRotator) Rotate(ctx context.Context, batchSize int, progress func(RotateStats)) (RotateStats, error) {
+	if r.pii.envelope == nil {
+		return RotateStats{}, errors.New("rotate keys: no master key is configured")
+	}
+	var stats RotateStats
+	userQery := r.query.User
+	last := ""
+	for {
+		conds := []gen.Condition{userQery.ErasedAt.IsNull()}
+		// The first page has no lower bound: "" is not a valid uuid.
+		if last != "" {
+			conds = append(conds, userQery.ID.Gt(last))
+		}
+		batch, err := userQery.WithContext(ctx).Unscoped().
+			Where(conds...).
+			Order(userQery.ID).Limit(batchSize).Find()
+		if err != nil {
+			return stats, fmt.Errorf("read users after %q: %s %w", last, err.Error(), util.MapErrorToHTTPStatus(err))
+		}
+		for _, u := range batch {
+			rotated, err := r.rotate(ctx, u)
+			if err != nil {
+				return stats, err
+			}
+			stats.Scanned++
+			if rotated {
+				stats.Rotated++
+			}
+		}
+		if progress != nil {
+			progress(stats)
+		}
+		if len(batch) < batchSize {
+			return stats, nil
+		}
+		last = batch[len(batch)-1].ID
+	}
+}
+
+// rotate re-encrypts u unless it is current already.
+func (r *KeyRotator) rotate(ctx context.Context, u *model.User) (bool, error) {
+	e := r.pii.envelope
+	if e.IsCurrent(u.Email) && e.IsCurrent(u.Phone) {
+		return false, nil
+	}
+	stored := *u
+	if err := r.pii.open(ctx, u); err != nil {
+		return false, err
+	}
+	if err := r.pii.seal(ctx, u); err != nil {
+		return false, err
+	}
+
+	userQery := r.query.User
+	// Only if the row still holds what was read; a phone may be NULL.
+	unchanged := []gen.Condition{userQery.ID.Eq(u.ID), userQery.Email.Eq(stored.Email), userQery.Phone.Eq(stored.Phone)}
+	if stored.Phone == "" {
+		unchanged[2] = field.Or(userQery.Phone.IsNull(), userQery.Phone.Eq(""))
+	}
+	// UpdateColumnSimple leaves updated_at alone, nothing about the user
+	// changes.
+	info, err := userQery.WithContext(ctx).Unscoped().Where(unchanged...).UpdateColumnSimple(
+		userQery.Email.Value(u.Email),
+		userQery.Phone.Value(u.Phone),
+		userQery.EmailIndex.Value(u.EmailIndex),
+	)
+	if err != nil {
+		return false, emailTaken(fmt.Errorf("re-encrypt user %s: %s %w", u.ID, err.Error(), util.MapErrorToHTTPStatus(err)))
+	}
+	return info.RowsAffected > 0, nil
+}
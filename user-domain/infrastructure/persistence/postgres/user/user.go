@@ -2,72 +2,248 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
+	"user-domain/infrastructure/encryption"
 	"user-domain/infrastructure/persistence/postgres/dao"
 	"user-domain/infrastructure/persistence/postgres/model"
+	"user-domain/infrastructure/persistence/postgres/transaction"
 	"user-domain/infrastructure/persistence/util"
 	"user-domain/internal/application/outbound"
+	domainerror "user-domain/internal/domain/error"
 	"user-domain/internal/entity"
 
 	"github.com/google/uuid"
+	"gorm.io/gen/field"
 	"gorm.io/gorm"
 )
 
 type userRepo struct {
 	query dao.Query
+	pii   pii
+}
+
+// users returns the user query bound to the transaction in ctx, if any.
+func (d *userRepo) users(ctx context.Context) dao.IUserDo {
+	if tx, ok := transaction.FromContext(ctx); ok {
+		return dao.Use(tx).User.WithContext(ctx)
+	}
+	return d.query.User.WithContext(ctx)
+}
+
+// requests is users for the personal data requests.
+func (d *userRepo) requests(ctx context.Context) dao.IPersonalDataRequestDo {
+	if tx, ok := transaction.FromContext(ctx); ok {
+		return dao.Use(tx).PersonalDataRequest.WithContext(ctx)
+	}
+	return d.query.PersonalDataRequest.WithContext(ctx)
 }
 
 func (d *userRepo) CreateUser(ctx context.Context, user *entity.User) error {
 	u := CreateRepoEntityFromUserEntity(user)
 	u.ID = uuid.NewString()
-	userQery := d.query.User
-	err := userQery.WithContext(ctx).Create(u)
+	if err := d.pii.seal(ctx, u); err != nil {
+		return err
+	}
+	// erased_at is only ever set by EraseUser, and email_index is left NULL
+	// rather than empty without encryption, for its unique index.
+	omit := []field.Expr{d.query.User.ErasedAt}
+	if u.EmailIndex == "" {
+		omit = append(omit, d.query.User.EmailIndex)
+	}
+	err := d.users(ctx).Omit(omit...).Create(u)
 	if err != nil {
-		return fmt.Errorf("create user with email %s: %s %w", user.Email, err.Error(), util.MapErrorToHTTPStatus(err))
+		return emailTaken(fmt.Errorf("create user with email %s: %s %w", user.Email, err.Error(), util.MapErrorToHTTPStatus(err)))
 	}
+	user.ID = u.ID
 	return nil
 }
 
 func (d *userRepo) UpdateUser(ctx context.Context, user *entity.User) error {
 	u := CreateRepoEntityFromUserEntity(user)
-	userQery := d.query.User
-	_, err := userQery.WithContext(ctx).Updates(u)
-	if err != nil {
+	if err := d.pii.seal(ctx, u); err != nil {
 		return err
 	}
+	info, err := d.users(ctx).Updates(u)
+	if err != nil {
+		return emailTaken(fmt.Errorf("update user %s: %s %w", user.ID, err.Error(), util.MapErrorToHTTPStatus(err)))
+	}
+	if info.RowsAffected == 0 {
+		return domainerror.New(domainerror.ErrCodeNotFound, domainerror.CodeUserNotFound, "user "+user.ID+" was not found")
+	}
 	return nil
 }
 
 func (d *userRepo) DeleteUser(ctx context.Context, id string) error {
-	userQery := d.query.User
-	_, err := userQery.Delete(&model.User{ID: id})
+	_, err := d.users(ctx).Delete(&model.User{ID: id})
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+func (d *userRepo) RestoreUser(ctx context.Context, id string) error {
+	userQery := d.query.User
+	info, err := d.users(ctx).Unscoped().Where(userQery.ID.Eq(id), userQery.ErasedAt.IsNull()).Update(userQery.DeletedAt, nil)
+	if err != nil {
+		return emailTaken(fmt.Errorf("restore user %s: %s %w", id, err.Error(), util.MapErrorToHTTPStatus(err)))
+	}
+	if info.RowsAffected == 0 {
+		return domainerror.New(domainerror.ErrCodeNotFound, domainerror.CodeUserNotFound, "user "+id+" was not found")
+	}
+	return nil
+}
+
 func (d *userRepo) GetUserByID(ctx context.Context, id string) (*entity.User, error) {
 	userQery := d.query.User
-	userM, err := userQery.Where(userQery.ID.Eq(id)).First()
+	userM, err := d.users(ctx).Where(userQery.ID.Eq(id)).First()
+	if err != nil {
+		err = fmt.Errorf("get user with id %s: %s %w", id, err.Error(), util.MapErrorToHTTPStatus(err))
+		if errors.Is(err, domainerror.ErrCodeNotFound) {
+			return nil, domainerror.New(domainerror.ErrCodeNotFound, domainerror.CodeUserNotFound, "user "+id+" was not found").WithCause(err)
+		}
+		return nil, err
+	}
+	if err := d.pii.open(ctx, userM); err != nil {
+		return nil, err
+	}
+	return CreateUserEntityFromUserModel(userM), nil
+}
+
+func (d *userRepo) GetUsersByIDs(ctx context.Context, ids []string) ([]*entity.User, error) {
+	userQery := d.query.User
+	usersModel, err := d.users(ctx).Where(userQery.ID.In(ids...)).Find()
+	if err != nil {
+		return nil, fmt.Errorf("get %d users by id: %s %w", len(ids), err.Error(), util.MapErrorToHTTPStatus(err))
+	}
+	if err := d.pii.open(ctx, usersModel...); err != nil {
+		return nil, err
+	}
+	return CreateUsersEntityFromUsesrModel(usersModel), nil
+}
+
+func (d *userRepo) GetUserByEmail(ctx context.Context, email string) (*entity.User, error) {
+	userQery := d.query.User
+	byEmail := userQery.Email.Eq(email)
+	if d.pii.envelope != nil {
+		// Rows stored before encryption was turned on have no index yet; an
+		// encrypted email never equals a plaintext one.
+		byEmail = field.Or(userQery.EmailIndex.Eq(d.pii.envelope.Index(email)), byEmail)
+	}
+	userM, err := d.users(ctx).Where(byEmail).First()
 	if err != nil {
-		return nil, fmt.Errorf("get user with id %s: %s %w", id, err.Error(), util.MapErrorToHTTPStatus(err))
+		err = fmt.Errorf("get user by email: %s %w", err.Error(), util.MapErrorToHTTPStatus(err))
+		if errors.Is(err, domainerror.ErrCodeNotFound) {
+			return nil, domainerror.New(domainerror.ErrCodeNotFound, domainerror.CodeUserNotFound, "no user has this email").WithCause(err)
+		}
+		return nil, err
+	}
+	if err := d.pii.open(ctx, userM); err != nil {
+		return nil, err
 	}
 	return CreateUserEntityFromUserModel(userM), nil
 }
 
 func (d *userRepo) ListUsers(ctx context.Context, offset, limit int) ([]*entity.User, error) {
 	userQery := d.query.User
-	usersModel, err := userQery.Offset(offset).Limit(limit).Find()
+	// Without an order pages could overlap or skip users.
+	usersModel, err := d.users(ctx).Order(userQery.CreatedAt, userQery.ID).Offset(offset).Limit(limit).Find()
 	if err != nil {
 		return nil, err
 	}
+	if err := d.pii.open(ctx, usersModel...); err != nil {
+		return nil, err
+	}
 	return CreateUsersEntityFromUsesrModel(usersModel), nil
 }
 
-func NewUserRepo(db *gorm.DB) outbound.UserRepo {
+func (d *userRepo) GetPersonalData(ctx context.Context, id string) (*entity.PersonalData, error) {
+	userQery := d.query.User
+	userM, err := d.users(ctx).Unscoped().Where(userQery.ID.Eq(id)).First()
+	if err != nil {
+		err = fmt.Errorf("get personal data of user %s: %s %w", id, err.Error(), util.MapErrorToHTTPStatus(err))
+		if errors.Is(err, domainerror.ErrCodeNotFound) {
+			return nil, domainerror.New(domainerror.ErrCodeNotFound, domainerror.CodeUserNotFound, "user "+id+" was not found").WithCause(err)
+		}
+		return nil, err
+	}
+	reqQuery := d.query.PersonalDataRequest
+	requests, err := d.requests(ctx).Where(reqQuery.UserID.Eq(id)).Order(reqQuery.RequestedAt, reqQuery.ID).Find()
+	if err != nil {
+		return nil, fmt.Errorf("get personal data requests of user %s: %s %w", id, err.Error(), util.MapErrorToHTTPStatus(err))
+	}
+	if err := d.pii.open(ctx, userM); err != nil {
+		return nil, err
+	}
+	return CreatePersonalDataFromModels(userM, requests), nil
+}
+
+func (d *userRepo) EraseUser(ctx context.Context, id string) error {
+	userQery := d.query.User
+	erased := entity.ErasedUser(id)
+	info, err := d.users(ctx).Unscoped().Where(userQery.ID.Eq(id)).UpdateSimple(
+		userQery.Name.Value(erased.Name),
+		userQery.Email.Value(erased.Email),
+		userQery.Phone.Value(erased.Phone),
+		userQery.EmailIndex.Null(),
+		userQery.ErasedAt.Value(time.Now()),
+	)
+	if err != nil {
+		return fmt.Errorf("erase user %s: %s %w", id, err.Error(), util.MapErrorToHTTPStatus(err))
+	}
+	if info.RowsAffected == 0 {
+		return domainerror.New(domainerror.ErrCodeNotFound, domainerror.CodeUserNotFound, "user "+id+" was not found")
+	}
+	// Deleting a user that is already deleted changes nothing.
+	if _, err := d.users(ctx).Where(userQery.ID.Eq(id)).Delete(); err != nil {
+		return fmt.Errorf("delete erased user %s: %s %w", id, err.Error(), util.MapErrorToHTTPStatus(err))
+	}
+	return nil
+}
+
+func (d *userRepo) RecordPersonalDataRequest(ctx context.Context, req *entity.PersonalDataRequest) error {
+	m := CreatePersonalDataRequestModel(req)
+	m.ID = uuid.NewString()
+	// Set here rather than by the database, whose clock may be coarser and
+	// is frozen for the length of a transaction, so that requests keep
+	// their order.
+	m.RequestedAt = time.Now()
+	if err := d.requests(ctx).Create(m); err != nil {
+		return fmt.Errorf("record %s request of user %s: %s %w", req.Kind, req.UserID, err.Error(), util.MapErrorToHTTPStatus(err))
+	}
+	req.ID = m.ID
+	req.RequestedAt = m.RequestedAt
+	return nil
+}
+
+// emailTaken gives the only unique indexes on users, the ones on email and
+// on its blind index, their own code.
+func emailTaken(err error) error {
+	if errors.Is(err, domainerror.ErrCodeConflict) {
+		return domainerror.New(domainerror.ErrCodeConflict, domainerror.CodeEmailTaken, "email is already in use").WithCause(err)
+	}
+	return err
+}
+
+// Option configures the repository NewUserRepo returns.
+type Option func(*userRepo)
+
+// WithEncryption stores email and phone encrypted by e, and finds users by
+// the blind index of their email.
+func WithEncryption(e *encryption.Envelope) Option {
+	return func(d *userRepo) {
+		d.pii.envelope = e
+	}
+}
+
+func NewUserRepo(db *gorm.DB, opts ...Option) outbound.UserRepo {
 	query := dao.Use(db)
-	return &userRepo{
+	d := &userRepo{
 		query: *query,
 	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
 }
@@ -87,6 +87,7 @@ func TestCreateUser(t *testing.T) {
 				require.Error(t, err)
 			} else {
 				require.NoError(t, err)
+				require.NotEmpty(t, tt.data.ID)
 			}
 		})
 	}
@@ -0,0 +1,62 @@
+package sqlite_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"testing"
+	"time"
+	"user-domain/infrastructure/database"
+	"user-domain/infrastructure/database/migration"
+	"user-domain/infrastructure/encryption"
+	"user-domain/infrastructure/logger"
+	postgresuser "user-domain/infrastructure/persistence/postgres/user"
+	"user-domain/infrastructure/persistence/sqlite"
+	"user-domain/infrastructure/persistence/usertest"
+	"user-domain/internal/application/outbound"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// TestContract gives every subtest a fresh in-memory database.
+func TestContract(t *testing.T) {
+	t.Parallel()
+	usertest.Run(t, func(t *testing.T) outbound.UserRepo {
+		return sqlite.NewUserRepo(newDB(t))
+	})
+}
+
+// TestContractEncrypted runs the suite with email and phone encrypted, and
+// checks that they are not stored in plaintext.
+func TestContractEncrypted(t *testing.T) {
+	t.Parallel()
+	kms, err := encryption.NewLocalKMS("k1:"+base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32)), "")
+	require.NoError(t, err)
+	envelope := encryption.New(kms, bytes.Repeat([]byte{2}, 32))
+	usertest.Run(t, func(t *testing.T) outbound.UserRepo {
+		db := newDB(t)
+		t.Cleanup(func() {
+			var plaintext int64
+			require.NoError(t, db.Table("users").Where("erased_at IS NULL AND email LIKE ?", "%@%").Count(&plaintext).Error)
+			require.Zero(t, plaintext, "emails are stored in plaintext")
+		})
+		return sqlite.NewUserRepo(db, postgresuser.WithEncryption(envelope))
+	})
+}
+
+func newDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	conn, err := database.OpenSQLite(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	m, err := migration.New(t.Context(), conn, database.DriverSQLite, "", time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, m.Up(t.Context()))
+	require.NoError(t, m.Close())
+
+	db, err := database.NewSQLiteGormFromConn(conn, logger.NewConsoleLogger(io.Discard))
+	require.NoError(t, err)
+	return db
+}
@@ -0,0 +1,19 @@
+// Package sqlite stores users in a sqlite database opened by
+// database.OpenSQLite.
+package sqlite
+
+import (
+	postgresuser "user-domain/infrastructure/persistence/postgres/user"
+	"user-domain/internal/application/outbound"
+
+	"gorm.io/gorm"
+)
+
+// NewUserRepo returns the user repository of a sqlite database. The queries
+// the postgres repository builds are plain enough for sqlite too, and with
+// the error translation database.NewSQLiteGormFromConn turns on a taken
+// email is reported the same way, so the sqlite backend shares it, options
+// included. Use transaction.NewManager of the same db for its transactions.
+func NewUserRepo(db *gorm.DB, opts ...postgresuser.Option) outbound.UserRepo {
+	return postgresuser.NewUserRepo(db, opts...)
+}
@@ -0,0 +1,296 @@
+// Package usertest checks that an outbound.UserRepo behaves the way the
+// service relies on, whatever stores the users. Every adapter runs Run from
+// its own tests.
+package usertest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"user-domain/internal/application/outbound"
+	domainerror "user-domain/internal/domain/error"
+	"user-domain/internal/entity"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Factory returns an empty repository. Subtests run one after another, so
+// adapters backed by a shared database may empty it instead of creating a
+// new one.
+type Factory func(t *testing.T) outbound.UserRepo
+
+// Run checks the contract of outbound.UserRepo against the repositories
+// newRepo returns. Addresses are not checked, not every store keeps them.
+func Run(t *testing.T, newRepo Factory) {
+	t.Helper()
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, ctx context.Context, repo outbound.UserRepo)
+	}{
+		{"CreateAssignsID", testCreateAssignsID},
+		{"CreateRejectsTakenEmail", testCreateRejectsTakenEmail},
+		{"GetNotFound", testGetNotFound},
+		{"GetUsersByIDs", testGetUsersByIDs},
+		{"UpdateSetsGivenFields", testUpdateSetsGivenFields},
+		{"UpdateNotFound", testUpdateNotFound},
+		{"UpdateRejectsTakenEmail", testUpdateRejectsTakenEmail},
+		{"DeleteIsSoft", testDeleteIsSoft},
+		{"Restore", testRestore},
+		{"RestoreRejectsTakenEmail", testRestoreRejectsTakenEmail},
+		{"ListPages", testListPages},
+		{"PersonalData", testPersonalData},
+		{"EraseAnonymises", testEraseAnonymises},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, t.Context(), newRepo(t))
+		})
+	}
+}
+
+func create(t *testing.T, ctx context.Context, repo outbound.UserRepo, name string) *entity.User {
+	t.Helper()
+	u := &entity.User{Name: name, Email: name + "@example.com", Phone: "+84901234567"}
+	require.NoError(t, repo.CreateUser(ctx, u))
+	require.NotEmpty(t, u.ID)
+	return u
+}
+
+func requireNotFound(t *testing.T, err error) {
+	t.Helper()
+	require.ErrorIs(t, err, domainerror.ErrCodeNotFound)
+	require.Equal(t, domainerror.CodeUserNotFound, domainerror.CodeOf(err))
+}
+
+func requireEmailTaken(t *testing.T, err error) {
+	t.Helper()
+	require.ErrorIs(t, err, domainerror.ErrCodeConflict)
+	require.Equal(t, domainerror.CodeEmailTaken, domainerror.CodeOf(err))
+}
+
+func testCreateAssignsID(t *testing.T, ctx context.Context, repo outbound.UserRepo) {
+	u := &entity.User{ID: "chosen-by-caller", Name: "An", Email: "an@example.com", Phone: "+84901234567"}
+	require.NoError(t, repo.CreateUser(ctx, u))
+	require.NotEqual(t, "chosen-by-caller", u.ID)
+
+	byID, err := repo.GetUserByID(ctx, u.ID)
+	require.NoError(t, err)
+	require.Equal(t, u.ID, byID.ID)
+	require.Equal(t, "An", byID.Name)
+	require.Equal(t, "an@example.com", byID.Email)
+	require.Equal(t, "+84901234567", byID.Phone)
+
+	byEmail, err := repo.GetUserByEmail(ctx, "an@example.com")
+	require.NoError(t, err)
+	require.Equal(t, u.ID, byEmail.ID)
+
+	// The caller owns what it gets back.
+	byID.Name = "changed"
+	again, err := repo.GetUserByID(ctx, u.ID)
+	require.NoError(t, err)
+	require.Equal(t, "An", again.Name)
+}
+
+func testCreateRejectsTakenEmail(t *testing.T, ctx context.Context, repo outbound.UserRepo) {
+	create(t, ctx, repo, "an")
+	requireEmailTaken(t, repo.CreateUser(ctx, &entity.User{Name: "Other", Email: "an@example.com"}))
+}
+
+func testGetNotFound(t *testing.T, ctx context.Context, repo outbound.UserRepo) {
+	_, err := repo.GetUserByID(ctx, "00000000-0000-4000-8000-000000000000")
+	requireNotFound(t, err)
+	_, err = repo.GetUserByEmail(ctx, "nobody@example.com")
+	requireNotFound(t, err)
+}
+
+func testGetUsersByIDs(t *testing.T, ctx context.Context, repo outbound.UserRepo) {
+	an, binh, chi := create(t, ctx, repo, "an"), create(t, ctx, repo, "binh"), create(t, ctx, repo, "chi")
+	require.NoError(t, repo.DeleteUser(ctx, chi.ID))
+
+	users, err := repo.GetUsersByIDs(ctx, []string{an.ID, binh.ID, chi.ID, "00000000-0000-4000-8000-000000000000"})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{an.ID, binh.ID}, ids(users))
+
+	users, err = repo.GetUsersByIDs(ctx, []string{"00000000-0000-4000-8000-000000000000"})
+	require.NoError(t, err)
+	require.Empty(t, users)
+}
+
+func testUpdateSetsGivenFields(t *testing.T, ctx context.Context, repo outbound.UserRepo) {
+	u := create(t, ctx, repo, "an")
+	require.NoError(t, repo.UpdateUser(ctx, &entity.User{ID: u.ID, Name: "An Nguyen"}))
+
+	got, err := repo.GetUserByID(ctx, u.ID)
+	require.NoError(t, err)
+	require.Equal(t, "An Nguyen", got.Name)
+	// Empty fields are left alone.
+	require.Equal(t, "an@example.com", got.Email)
+	require.Equal(t, "+84901234567", got.Phone)
+
+	require.NoError(t, repo.UpdateUser(ctx, &entity.User{ID: u.ID, Email: "an.nguyen@example.com"}))
+	_, err = repo.GetUserByEmail(ctx, "an@example.com")
+	requireNotFound(t, err)
+	got, err = repo.GetUserByEmail(ctx, "an.nguyen@example.com")
+	require.NoError(t, err)
+	require.Equal(t, u.ID, got.ID)
+}
+
+func testUpdateNotFound(t *testing.T, ctx context.Context, repo outbound.UserRepo) {
+	requireNotFound(t, repo.UpdateUser(ctx, &entity.User{ID: "00000000-0000-4000-8000-000000000000", Name: "An"}))
+
+	u := create(t, ctx, repo, "an")
+	require.NoError(t, repo.DeleteUser(ctx, u.ID))
+	requireNotFound(t, repo.UpdateUser(ctx, &entity.User{ID: u.ID, Name: "An"}))
+}
+
+func testUpdateRejectsTakenEmail(t *testing.T, ctx context.Context, repo outbound.UserRepo) {
+	create(t, ctx, repo, "an")
+	binh := create(t, ctx, repo, "binh")
+	requireEmailTaken(t, repo.UpdateUser(ctx, &entity.User{ID: binh.ID, Email: "an@example.com"}))
+
+	got, err := repo.GetUserByID(ctx, binh.ID)
+	require.NoError(t, err)
+	require.Equal(t, "binh@example.com", got.Email)
+}
+
+func testDeleteIsSoft(t *testing.T, ctx context.Context, repo outbound.UserRepo) {
+	u := create(t, ctx, repo, "an")
+	require.NoError(t, repo.DeleteUser(ctx, u.ID))
+	// Deleting again, or deleting nobody, is not an error.
+	require.NoError(t, repo.DeleteUser(ctx, u.ID))
+	require.NoError(t, repo.DeleteUser(ctx, "00000000-0000-4000-8000-000000000000"))
+
+	_, err := repo.GetUserByID(ctx, u.ID)
+	requireNotFound(t, err)
+	_, err = repo.GetUserByEmail(ctx, u.Email)
+	requireNotFound(t, err)
+	users, err := repo.ListUsers(ctx, 0, 10)
+	require.NoError(t, err)
+	require.Empty(t, users)
+
+	// A deleted user's email is free again.
+	create(t, ctx, repo, "an")
+}
+
+func testRestore(t *testing.T, ctx context.Context, repo outbound.UserRepo) {
+	u := create(t, ctx, repo, "an")
+	require.NoError(t, repo.DeleteUser(ctx, u.ID))
+	require.NoError(t, repo.RestoreUser(ctx, u.ID))
+
+	got, err := repo.GetUserByID(ctx, u.ID)
+	require.NoError(t, err)
+	require.Equal(t, "an@example.com", got.Email)
+	// Restoring a user that is not deleted does nothing.
+	require.NoError(t, repo.RestoreUser(ctx, u.ID))
+
+	requireNotFound(t, repo.RestoreUser(ctx, "00000000-0000-4000-8000-000000000000"))
+}
+
+func testRestoreRejectsTakenEmail(t *testing.T, ctx context.Context, repo outbound.UserRepo) {
+	u := create(t, ctx, repo, "an")
+	require.NoError(t, repo.DeleteUser(ctx, u.ID))
+	create(t, ctx, repo, "an")
+
+	requireEmailTaken(t, repo.RestoreUser(ctx, u.ID))
+	_, err := repo.GetUserByID(ctx, u.ID)
+	requireNotFound(t, err)
+}
+
+func testListPages(t *testing.T, ctx context.Context, repo outbound.UserRepo) {
+	var created []string
+	for i := range 7 {
+		created = append(created, create(t, ctx, repo, fmt.Sprintf("user%d", i)).ID)
+	}
+	require.NoError(t, repo.DeleteUser(ctx, created[3]))
+	live := append(append([]string{}, created[:3]...), created[4:]...)
+
+	all, err := repo.ListUsers(ctx, 0, 100)
+	require.NoError(t, err)
+	require.ElementsMatch(t, live, ids(all))
+
+	// Pages neither overlap nor skip users, and the order is stable.
+	var paged []string
+	for offset := 0; ; offset += 4 {
+		page, err := repo.ListUsers(ctx, offset, 4)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(page), 4)
+		if len(page) == 0 {
+			break
+		}
+		paged = append(paged, ids(page)...)
+	}
+	require.Equal(t, ids(all), paged)
+}
+
+func testPersonalData(t *testing.T, ctx context.Context, repo outbound.UserRepo) {
+	u := create(t, ctx, repo, "an")
+	req := &entity.PersonalDataRequest{UserID: u.ID, Kind: entity.PersonalDataExport}
+	require.NoError(t, repo.RecordPersonalDataRequest(ctx, req))
+	require.NotEmpty(t, req.ID)
+	require.False(t, req.RequestedAt.IsZero())
+
+	data, err := repo.GetPersonalData(ctx, u.ID)
+	require.NoError(t, err)
+	require.Equal(t, u.ID, data.User.ID)
+	require.Equal(t, "an@example.com", data.User.Email)
+	require.Equal(t, "+84901234567", data.User.Phone)
+	require.False(t, data.CreatedAt.IsZero())
+	require.Nil(t, data.DeletedAt)
+	require.Nil(t, data.ErasedAt)
+	require.Len(t, data.Requests, 1)
+	require.Equal(t, req.ID, data.Requests[0].ID)
+	require.Equal(t, entity.PersonalDataExport, data.Requests[0].Kind)
+
+	// Deleted users are exported too, requests oldest first.
+	require.NoError(t, repo.DeleteUser(ctx, u.ID))
+	require.NoError(t, repo.RecordPersonalDataRequest(ctx, &entity.PersonalDataRequest{UserID: u.ID, Kind: entity.PersonalDataExport}))
+	data, err = repo.GetPersonalData(ctx, u.ID)
+	require.NoError(t, err)
+	require.NotNil(t, data.DeletedAt)
+	require.Len(t, data.Requests, 2)
+	require.Equal(t, req.ID, data.Requests[0].ID)
+
+	_, err = repo.GetPersonalData(ctx, "00000000-0000-4000-8000-000000000000")
+	requireNotFound(t, err)
+}
+
+func testEraseAnonymises(t *testing.T, ctx context.Context, repo outbound.UserRepo) {
+	u := create(t, ctx, repo, "an")
+	require.NoError(t, repo.RecordPersonalDataRequest(ctx, &entity.PersonalDataRequest{UserID: u.ID, Kind: entity.PersonalDataExport}))
+	require.NoError(t, repo.EraseUser(ctx, u.ID))
+
+	_, err := repo.GetUserByID(ctx, u.ID)
+	requireNotFound(t, err)
+	_, err = repo.GetUserByEmail(ctx, "an@example.com")
+	requireNotFound(t, err)
+	data, err := repo.GetPersonalData(ctx, u.ID)
+	require.NoError(t, err)
+	erased := entity.ErasedUser(u.ID)
+	require.Equal(t, erased.Name, data.User.Name)
+	require.Equal(t, erased.Email, data.User.Email)
+	require.Empty(t, data.User.Phone)
+	require.NotNil(t, data.DeletedAt)
+	require.NotNil(t, data.ErasedAt)
+	// What refers to the user is kept.
+	require.Len(t, data.Requests, 1)
+
+	// An erased user is gone for good, and so is their hold on the email.
+	requireNotFound(t, repo.RestoreUser(ctx, u.ID))
+	create(t, ctx, repo, "an")
+	// Erasing again, or erasing a deleted user, is fine.
+	require.NoError(t, repo.EraseUser(ctx, u.ID))
+	binh := create(t, ctx, repo, "binh")
+	require.NoError(t, repo.DeleteUser(ctx, binh.ID))
+	require.NoError(t, repo.EraseUser(ctx, binh.ID))
+	requireNotFound(t, repo.RestoreUser(ctx, binh.ID))
+
+	requireNotFound(t, repo.EraseUser(ctx, "00000000-0000-4000-8000-000000000000"))
+}
+
+func ids(users []*entity.User) []string {
+	out := make([]string, 0, len(users))
+	for _, u := range users {
+		out = append(out, u.ID)
+	}
+	return out
+}
@@ -5,11 +5,16 @@ import (
 	"fmt"
 	domainerror "user-domain/internal/domain/error"
 
+	"github.com/lib/pq"
 	"gorm.io/gorm"
 )
 
-func MapErrorToHTTPStatus(err error) error {
+// uniqueViolation is the SQLSTATE postgres reports when a unique index
+// rejects a row.
+const uniqueViolation = "23505"
 
+func MapErrorToHTTPStatus(err error) error {
+	var pqErr *pq.Error
 	switch {
 	case errors.Is(err, gorm.ErrRecordNotFound):
 		return domainerror.ErrCodeNotFound
@@ -23,7 +28,8 @@ func MapErrorToHTTPStatus(err error) error {
 		errors.Is(err, gorm.ErrForeignKeyViolated),
 		errors.Is(err, gorm.ErrCheckConstraintViolated):
 		return domainerror.ErrCodeInvalidInput
-	case errors.Is(err, gorm.ErrDuplicatedKey):
+	case errors.Is(err, gorm.ErrDuplicatedKey),
+		errors.As(err, &pqErr) && pqErr.Code == uniqueViolation:
 		return domainerror.ErrCodeConflict
 
 	default:
@@ -0,0 +1,37 @@
+package ratelimit
+
+import (
+	"time"
+	"user-domain/infrastructure/http/middleware"
+)
+
+// Both stores implement a token bucket with GCRA: instead of a token count
+// they keep the theoretical arrival time (tat) at which the bucket is full
+// again, which is a single value per client and easy to update atomically.
+
+// gcra decides a request arriving at now against a bucket whose tat is tat
+// and returns the tat to store when the request is allowed.
+func gcra(now, tat time.Time, limit middleware.Limit) (time.Time, middleware.RateLimitResult) {
+	interval := limit.Window / time.Duration(limit.Requests)
+	if tat.Before(now) {
+		tat = now
+	}
+	next := tat.Add(interval)
+	allowAt := next.Add(-limit.Window)
+	if now.Before(allowAt) {
+		return tat, middleware.RateLimitResult{
+			Reset:      tat.Sub(now),
+			RetryAfter: allowAt.Sub(now),
+		}
+	}
+	reset := next.Sub(now)
+	return next, middleware.RateLimitResult{
+		Allowed:   true,
+		Remaining: remaining(reset, interval, limit),
+		Reset:     reset,
+	}
+}
+
+func remaining(reset, interval time.Duration, limit middleware.Limit) int {
+	return int((limit.Window - reset) / interval)
+}
@@ -0,0 +1,49 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+	"user-domain/infrastructure/http/middleware"
+)
+
+const sweepInterval = time.Minute
+
+type memoryStore struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	tats      map[string]time.Time
+	lastSweep time.Time
+}
+
+// NewMemoryStore limits per instance, with N instances behind a load
+// balancer a client effectively gets N times the limit.
+func NewMemoryStore() middleware.RateLimitStore {
+	return &memoryStore{now: time.Now, tats: map[string]time.Time{}}
+}
+
+func (s *memoryStore) Allow(_ context.Context, key string, limit middleware.Limit) (middleware.RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+	tat, res := gcra(now, s.tats[key], limit)
+	s.tats[key] = tat
+	return res, nil
+}
+
+// sweep forgets clients whose bucket is full again, they are
+// indistinguishable from new ones.
+func (s *memoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, tat := range s.tats {
+		if !tat.After(now) {
+			delete(s.tats, key)
+		}
+	}
+}
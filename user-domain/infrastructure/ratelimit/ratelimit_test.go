@@ -0,0 +1,183 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"user-domain/infrastructure/http/middleware"
+	"user-domain/internal/application/controller/apiutil"
+	application_mock "user-domain/internal/application/mocks/outbound"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var limit = middleware.Limit{Requests: 3, Window: 3 * time.Second}
+
+func testStore(t *testing.T, s middleware.RateLimitStore, advance func(time.Duration)) {
+	ctx := context.Background()
+	for want := 2; want >= 0; want-- {
+		res, err := s.Allow(ctx, "client", limit)
+		require.NoError(t, err)
+		require.True(t, res.Allowed)
+		require.Equal(t, want, res.Remaining)
+	}
+
+	res, err := s.Allow(ctx, "client", limit)
+	require.NoError(t, err)
+	require.False(t, res.Allowed)
+	require.Equal(t, time.Second, res.RetryAfter)
+	require.Equal(t, 3*time.Second, res.Reset)
+
+	res, err = s.Allow(ctx, "other", limit)
+	require.NoError(t, err)
+	require.True(t, res.Allowed)
+
+	// One token comes back per second.
+	advance(time.Second)
+	res, err = s.Allow(ctx, "client", limit)
+	require.NoError(t, err)
+	require.True(t, res.Allowed)
+	require.Equal(t, 0, res.Remaining)
+}
+
+func TestMemoryStore(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	s := NewMemoryStore().(*memoryStore)
+	s.now = func() time.Time { return now }
+	testStore(t, s, func(d time.Duration) { now = now.Add(d) })
+}
+
+func TestRedisStore(t *testing.T) {
+	t.Parallel()
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	now := time.UnixMilli(time.Now().UnixMilli())
+	s := NewRedisStore(client, "test:").(*redisStore)
+	s.now = func() time.Time { return now }
+	testStore(t, s, func(d time.Duration) {
+		now = now.Add(d)
+		srv.FastForward(d)
+	})
+	require.True(t, srv.Exists("test:client"))
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	t.Parallel()
+	logger := application_mock.NewLogger(t)
+	routes, err := middleware.ParseRouteLimits("POST /api/v1/users=1/1m")
+	require.NoError(t, err)
+	limits := middleware.RateLimits{Default: middleware.Limit{Requests: 2, Window: time.Minute}, Routes: routes}
+
+	r := chi.NewRouter()
+	r.Use(middleware.RateLimit(NewMemoryStore(), limits, middleware.FirstKey(middleware.KeyByAPIKey, middleware.KeyByIP), logger))
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Post("/users", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusCreated) })
+		r.Get("/users/{userId}", func(w http.ResponseWriter, r *http.Request) {})
+	})
+	do := func(method, path, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		if apiKey != "" {
+			req.Header.Set(middleware.APIKeyHeader, apiKey)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := do(http.MethodPost, "/api/v1/users", "")
+	require.Equal(t, http.StatusCreated, first.Code)
+	require.Equal(t, "1", first.Header().Get("RateLimit-Limit"))
+	require.Equal(t, "0", first.Header().Get("RateLimit-Remaining"))
+	require.Equal(t, "1;w=60", first.Header().Get("RateLimit-Policy"))
+
+	limited := do(http.MethodPost, "/api/v1/users", "")
+	require.Equal(t, http.StatusTooManyRequests, limited.Code)
+	require.Equal(t, "60", limited.Header().Get("Retry-After"))
+	require.Equal(t, apiutil.ProblemContentType, limited.Header().Get("Content-Type"))
+	require.Contains(t, limited.Body.String(), `"code":"rate_limited"`)
+
+	// Other routes and other clients have their own buckets.
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/api/v1/users/1", "").Code)
+	require.Equal(t, "2", do(http.MethodGet, "/api/v1/users/2", "").Header().Get("RateLimit-Limit"))
+	require.Equal(t, http.StatusCreated, do(http.MethodPost, "/api/v1/users", "secret").Code)
+}
+
+func TestRateLimitIgnoresSpoofedForwarding(t *testing.T) {
+	t.Parallel()
+	logger := application_mock.NewLogger(t)
+	proxies, err := middleware.ParseTrustedProxies("10.0.0.0/8, 192.168.1.1")
+	require.NoError(t, err)
+	limits := middleware.RateLimits{Default: middleware.Limit{Requests: 1, Window: time.Minute}}
+
+	r := chi.NewRouter()
+	r.Use(middleware.RealIP(proxies))
+	r.Use(middleware.RateLimit(NewMemoryStore(), limits, middleware.KeyByIP, logger))
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+	do := func(remoteAddr, forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		req.Header.Set("X-Real-IP", forwardedFor)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// A client that connects directly is counted by its own address,
+	// whatever it claims to forward.
+	require.Equal(t, http.StatusOK, do("203.0.113.7:1234", "198.51.100.1"))
+	require.Equal(t, http.StatusTooManyRequests, do("203.0.113.7:1234", "198.51.100.2"))
+
+	// Behind the proxies only the address they appended counts, not what
+	// the client put in front of it.
+	require.Equal(t, http.StatusOK, do("10.0.0.1:1234", "198.51.100.3, 203.0.113.8, 10.0.0.2"))
+	require.Equal(t, http.StatusTooManyRequests, do("192.168.1.1:1234", "198.51.100.4, 203.0.113.8"))
+	require.Equal(t, http.StatusOK, do("10.0.0.1:1234", "203.0.113.9"))
+
+	_, err = middleware.ParseTrustedProxies("10.0.0.0/33")
+	require.Error(t, err)
+}
+
+func TestRateLimitFailsOpen(t *testing.T) {
+	t.Parallel()
+	logger := application_mock.NewLogger(t)
+	logger.On("WithContext", mock.Anything).Return(logger)
+	logger.On("Error", mock.Anything, mock.Anything).Once()
+
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	srv.Close()
+
+	h := middleware.RateLimit(NewRedisStore(client, ""), middleware.RateLimits{Default: limit}, middleware.KeyByIP, logger)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Empty(t, rec.Header().Get("RateLimit-Limit"))
+}
+
+func TestParseLimit(t *testing.T) {
+	t.Parallel()
+	l, err := middleware.ParseLimit("100/1m")
+	require.NoError(t, err)
+	require.Equal(t, middleware.Limit{Requests: 100, Window: time.Minute}, l)
+
+	for _, bad := range []string{"", "100", "0/1m", "x/1m", "10/0s", "10/forever", "10000/1s"} {
+		_, err := middleware.ParseLimit(bad)
+		require.Error(t, err, bad)
+	}
+	_, err = middleware.ParseRouteLimits("/api/v1/users=1/1m")
+	require.Error(t, err)
+}
@@ -0,0 +1,59 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+	"user-domain/infrastructure/http/middleware"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// gcraScript is gcra run inside redis so that concurrent instances update a
+// bucket atomically. Times are unix milliseconds.
+var gcraScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local window = tonumber(ARGV[3])
+local tat = tonumber(redis.call("GET", KEYS[1]) or now)
+if tat < now then
+  tat = now
+end
+local next = tat + interval
+local allow_at = next - window
+if now < allow_at then
+  return {0, tat - now, allow_at - now}
+end
+redis.call("SET", KEYS[1], next, "PX", next - now)
+return {1, next - now, 0}
+`)
+
+type redisStore struct {
+	client redis.Scripter
+	prefix string
+	now    func() time.Time
+}
+
+// NewRedisStore shares buckets between all instances using the same redis.
+func NewRedisStore(client redis.Scripter, prefix string) middleware.RateLimitStore {
+	return &redisStore{client: client, prefix: prefix, now: time.Now}
+}
+
+func (s *redisStore) Allow(ctx context.Context, key string, limit middleware.Limit) (middleware.RateLimitResult, error) {
+	interval := limit.Window / time.Duration(limit.Requests)
+	out, err := gcraScript.Run(ctx, s.client, []string{s.prefix + key},
+		s.now().UnixMilli(), interval.Milliseconds(), limit.Window.Milliseconds(),
+	).Int64Slice()
+	if err != nil {
+		return middleware.RateLimitResult{}, err
+	}
+
+	res := middleware.RateLimitResult{
+		Allowed:    out[0] == 1,
+		Reset:      time.Duration(out[1]) * time.Millisecond,
+		RetryAfter: time.Duration(out[2]) * time.Millisecond,
+	}
+	if res.Allowed {
+		res.Remaining = remaining(res.Reset, interval, limit)
+	}
+	return res, nil
+}
@@ -0,0 +1,85 @@
+package seed
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"user-domain/internal/entity"
+)
+
+// fixtureUser is a user as stored in a fixture file. The keys match the ones
+// userctl imports and exports, so fixtures can be loaded through the API too.
+type fixtureUser struct {
+	ID      string `json:"id,omitempty"`
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Phone   string `json:"phone,omitempty"`
+	Address string `json:"address,omitempty"`
+}
+
+// FixtureWriter writes users as a JSON array, one user per line, without
+// holding them in memory.
+type FixtureWriter struct {
+	w *bufio.Writer
+	n int
+}
+
+func NewFixtureWriter(w io.Writer) *FixtureWriter {
+	return &FixtureWriter{w: bufio.NewWriter(w)}
+}
+
+func (f *FixtureWriter) Write(u *entity.User) error {
+	data, err := json.Marshal(fixtureUser(*u))
+	if err != nil {
+		return err
+	}
+	sep := ",\n  "
+	if f.n == 0 {
+		sep = "[\n  "
+	}
+	f.n++
+	if _, err := f.w.WriteString(sep); err != nil {
+		return err
+	}
+	_, err = f.w.Write(data)
+	return err
+}
+
+// Close ends the array and flushes it, it does not close the underlying
+// writer.
+func (f *FixtureWriter) Close() error {
+	end := "\n]\n"
+	if f.n == 0 {
+		end = "[]\n"
+	}
+	if _, err := f.w.WriteString(end); err != nil {
+		return err
+	}
+	return f.w.Flush()
+}
+
+// ReadFixture reads users written by FixtureWriter.
+func ReadFixture(r io.Reader) ([]*entity.User, error) {
+	var in []fixtureUser
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return nil, fmt.Errorf("read fixture: %w", err)
+	}
+	users := make([]*entity.User, 0, len(in))
+	for _, u := range in {
+		e := entity.User(u)
+		users = append(users, &e)
+	}
+	return users, nil
+}
+
+// LoadFixture reads the fixture file at path, see ReadFixture.
+func LoadFixture(path string) ([]*entity.User, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadFixture(f)
+}
@@ -0,0 +1,89 @@
+// Package seed generates realistic users for demos, load tests and test
+// fixtures.
+package seed
+
+import (
+	"fmt"
+	"iter"
+	"math/rand/v2"
+	"strings"
+	"unicode"
+	"user-domain/internal/entity"
+
+	"github.com/google/uuid"
+	"golang.org/x/text/unicode/norm"
+)
+
+// domains are reserved for documentation, so seeded addresses never reach a
+// real mailbox.
+var domains = []string{"example.com", "example.net", "example.org"}
+
+// Generator makes users. Two generators with the same seed and locales make
+// the same users in the same order.
+type Generator struct {
+	rng     *rand.Rand
+	locales []Locale
+	n       int
+}
+
+// NewGenerator returns a generator that picks a locale per user from
+// locales, or from every locale if none are given.
+func NewGenerator(seed uint64, locales ...Locale) *Generator {
+	if len(locales) == 0 {
+		locales = []Locale{Vietnamese, English}
+	}
+	return &Generator{rng: rand.New(rand.NewPCG(seed, seed)), locales: locales}
+}
+
+// Next returns a new user. Its ID and email are unique among the users of
+// the generator.
+func (g *Generator) Next() *entity.User {
+	g.n++
+	l := pick(g.rng, g.locales)
+	p := l.person(g.rng)
+	return &entity.User{
+		ID:      g.id(),
+		Name:    p.name,
+		Email:   fmt.Sprintf("%s.%s%d@%s", ascii(p.given), ascii(p.family), g.n, pick(g.rng, domains)),
+		Phone:   l.phone(g.rng),
+		Address: l.address(g.rng),
+	}
+}
+
+// Users yields the next n users.
+func (g *Generator) Users(n int) iter.Seq[*entity.User] {
+	return func(yield func(*entity.User) bool) {
+		for range n {
+			if !yield(g.Next()) {
+				return
+			}
+		}
+	}
+}
+
+// id is a version 4 UUID drawn from the seeded source rather than from
+// crypto/rand.
+func (g *Generator) id() string {
+	var b [16]byte
+	for i := range b {
+		b[i] = byte(g.rng.Uint32())
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return uuid.UUID(b).String()
+}
+
+// ascii lowercases s and strips its diacritics, "Đặng" becomes "dang".
+func ascii(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case r == 'đ' || r == 'Đ':
+			b.WriteByte('d')
+		case unicode.Is(unicode.Mn, r):
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
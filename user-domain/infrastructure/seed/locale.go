@@ -0,0 +1,104 @@
+package seed
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+)
+
+// Locale selects the names, phone numbers and addresses a Generator makes.
+type Locale string
+
+const (
+	Vietnamese Locale = "vi"
+	English    Locale = "en"
+)
+
+// ParseLocales reads a comma-separated list such as "vi,en".
+func ParseLocales(s string) ([]Locale, error) {
+	var locales []Locale
+	for _, code := range strings.Split(s, ",") {
+		switch l := Locale(strings.TrimSpace(code)); l {
+		case Vietnamese, English:
+			locales = append(locales, l)
+		default:
+			return nil, fmt.Errorf("unknown locale %q, use vi or en", code)
+		}
+	}
+	return locales, nil
+}
+
+type person struct {
+	name, given, family string
+}
+
+var (
+	viFamily      = []string{"Nguyễn", "Nguyễn", "Nguyễn", "Trần", "Trần", "Lê", "Lê", "Phạm", "Hoàng", "Huỳnh", "Phan", "Vũ", "Võ", "Đặng", "Bùi", "Đỗ", "Hồ", "Ngô", "Dương", "Lý"}
+	viMiddleMen   = []string{"Văn", "Hữu", "Đức", "Minh", "Quốc", "Thanh", "Công", "Gia"}
+	viMiddleWomen = []string{"Thị", "Ngọc", "Thu", "Thanh", "Minh", "Hoài", "Bảo", "Kim"}
+	viGivenMen    = []string{"An", "Bình", "Cường", "Dũng", "Hải", "Hùng", "Khoa", "Long", "Minh", "Nam", "Phúc", "Quân", "Sơn", "Tài", "Thắng", "Tuấn", "Việt", "Khánh"}
+	viGivenWomen  = []string{"Anh", "Chi", "Dung", "Giang", "Hà", "Hạnh", "Hương", "Lan", "Linh", "Mai", "Ngân", "Nhung", "Phương", "Thảo", "Trang", "Vy", "Yến", "Quyên"}
+	viStreets     = []string{"Lê Lợi", "Nguyễn Huệ", "Trần Hưng Đạo", "Hai Bà Trưng", "Điện Biên Phủ", "Lý Thường Kiệt", "Nguyễn Trãi", "Pasteur", "Cách Mạng Tháng Tám", "Võ Văn Tần", "Phan Đình Phùng", "Bạch Đằng"}
+	viCities      = []struct {
+		name      string
+		districts []string
+	}{
+		{"Hà Nội", []string{"Ba Đình", "Hoàn Kiếm", "Đống Đa", "Cầu Giấy", "Hai Bà Trưng"}},
+		{"TP. Hồ Chí Minh", []string{"Quận 1", "Quận 3", "Bình Thạnh", "Phú Nhuận", "Tân Bình"}},
+		{"Đà Nẵng", []string{"Hải Châu", "Sơn Trà", "Thanh Khê"}},
+		{"Hải Phòng", []string{"Lê Chân", "Ngô Quyền"}},
+		{"Cần Thơ", []string{"Ninh Kiều", "Cái Răng"}},
+	}
+	// viMobile are the mobile network prefixes after +84.
+	viMobile = []string{"32", "33", "34", "35", "36", "37", "38", "39", "70", "76", "77", "78", "79", "81", "82", "83", "84", "85", "86", "88", "89", "90", "91", "93", "94", "96", "97", "98"}
+
+	enGiven   = []string{"James", "Mary", "John", "Patricia", "Robert", "Jennifer", "Michael", "Linda", "William", "Elizabeth", "David", "Susan", "Emma", "Oliver", "Olivia", "Noah", "Ava", "Liam", "Sophia", "Lucas"}
+	enFamily  = []string{"Smith", "Johnson", "Williams", "Brown", "Jones", "Garcia", "Miller", "Davis", "Wilson", "Taylor", "Anderson", "Thomas", "Moore", "Martin", "Clark", "Walker", "Hall", "Young"}
+	enStreets = []string{"Oak", "Maple", "Cedar", "Pine", "Elm", "Washington", "Lake", "Hill", "Park", "Main", "Church", "River"}
+	enSuffix  = []string{"St", "Ave", "Rd", "Ln", "Blvd", "Dr"}
+	enCities  = []struct {
+		name, state, zip string
+	}{
+		{"Springfield", "IL", "627"},
+		{"Portland", "OR", "972"},
+		{"Austin", "TX", "787"},
+		{"Madison", "WI", "537"},
+		{"Columbus", "OH", "432"},
+		{"Denver", "CO", "802"},
+	}
+)
+
+func pick[T any](r *rand.Rand, s []T) T {
+	return s[r.IntN(len(s))]
+}
+
+func (l Locale) person(r *rand.Rand) person {
+	if l == Vietnamese {
+		family := pick(r, viFamily)
+		middle, given := pick(r, viMiddleMen), pick(r, viGivenMen)
+		if r.IntN(2) == 0 {
+			middle, given = pick(r, viMiddleWomen), pick(r, viGivenWomen)
+		}
+		// Family name first, the given name is what people go by.
+		return person{name: family + " " + middle + " " + given, given: given, family: family}
+	}
+	given, family := pick(r, enGiven), pick(r, enFamily)
+	return person{name: given + " " + family, given: given, family: family}
+}
+
+func (l Locale) phone(r *rand.Rand) string {
+	if l == Vietnamese {
+		return fmt.Sprintf("+84%s%07d", pick(r, viMobile), r.IntN(10_000_000))
+	}
+	// 555-0100 to 555-0199 are reserved for fiction.
+	return fmt.Sprintf("+1%d5550%03d", 201+r.IntN(789), 100+r.IntN(100))
+}
+
+func (l Locale) address(r *rand.Rand) string {
+	if l == Vietnamese {
+		city := pick(r, viCities)
+		return fmt.Sprintf("%d %s, %s, %s", 1+r.IntN(300), pick(r, viStreets), pick(r, city.districts), city.name)
+	}
+	city := pick(r, enCities)
+	return fmt.Sprintf("%d %s %s, %s, %s %s%02d", 1+r.IntN(9000), pick(r, enStreets), pick(r, enSuffix), city.name, city.state, city.zip, r.IntN(100))
+}
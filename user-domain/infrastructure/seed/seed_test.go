@@ -0,0 +1,165 @@
+package seed
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/base64"
+	"errors"
+	"net/mail"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"user-domain/infrastructure/encryption"
+	"user-domain/infrastructure/persistence/postgres/model"
+	postgresuser "user-domain/infrastructure/persistence/postgres/user"
+	"user-domain/internal/entity"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratorIsDeterministic(t *testing.T) {
+	t.Parallel()
+	a := slices.Collect(NewGenerator(7).Users(50))
+	b := slices.Collect(NewGenerator(7).Users(50))
+	c := slices.Collect(NewGenerator(8).Users(50))
+	require.Equal(t, a, b)
+	require.NotEqual(t, a, c)
+}
+
+func TestGeneratorMakesValidUsers(t *testing.T) {
+	t.Parallel()
+	ids := map[string]bool{}
+	emails := map[string]bool{}
+	for u := range NewGenerator(1).Users(1000) {
+		id, err := uuid.Parse(u.ID)
+		require.NoError(t, err)
+		require.Equal(t, uuid.Version(4), id.Version())
+		_, err = mail.ParseAddress(u.Email)
+		require.NoError(t, err, u.Email)
+		require.Regexp(t, `^[a-z0-9.]+@example\.(com|net|org)$`, u.Email)
+		require.NotEmpty(t, u.Name)
+		require.NotEmpty(t, u.Address)
+		require.False(t, ids[u.ID] || emails[u.Email], "duplicate %s %s", u.ID, u.Email)
+		ids[u.ID], emails[u.Email] = true, true
+	}
+}
+
+func TestLocales(t *testing.T) {
+	t.Parallel()
+	for u := range NewGenerator(1, Vietnamese).Users(100) {
+		require.Regexp(t, `^\+84[0-9]{9}$`, u.Phone)
+		require.Len(t, strings.Fields(u.Name), 3)
+	}
+	for u := range NewGenerator(1, English).Users(100) {
+		require.Regexp(t, `^\+1[2-9][0-9]{2}5550[0-9]{3}$`, u.Phone)
+		require.Regexp(t, `, [A-Z]{2} [0-9]{5}$`, u.Address)
+	}
+
+	locales, err := ParseLocales("vi, en")
+	require.NoError(t, err)
+	require.Equal(t, []Locale{Vietnamese, English}, locales)
+	_, err = ParseLocales("vi,fr")
+	require.ErrorContains(t, err, `unknown locale "fr"`)
+}
+
+func TestASCII(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, "dang", ascii("Đặng"))
+	require.Equal(t, "nguyen", ascii("Nguyễn"))
+	require.Equal(t, "thang", ascii("Thắng"))
+	require.Equal(t, "oliver", ascii("Oliver"))
+}
+
+func TestFixtureRoundTrip(t *testing.T) {
+	t.Parallel()
+	users := slices.Collect(NewGenerator(3).Users(5))
+	var b bytes.Buffer
+	w := NewFixtureWriter(&b)
+	for _, u := range users {
+		require.NoError(t, w.Write(u))
+	}
+	require.NoError(t, w.Close())
+
+	got, err := ReadFixture(&b)
+	require.NoError(t, err)
+	require.Equal(t, users, got)
+
+	b.Reset()
+	require.NoError(t, NewFixtureWriter(&b).Close())
+	got, err = ReadFixture(&b)
+	require.NoError(t, err)
+	require.Empty(t, got)
+}
+
+func TestCopy(t *testing.T) {
+	t.Parallel()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	users := []*entity.User{
+		{ID: "1", Name: "Nguyễn Văn An", Email: "an.nguyen1@example.com", Phone: "+84901234567"},
+		{ID: "2", Name: "Emma Smith", Email: "emma.smith2@example.org"},
+	}
+
+	plain := func(ctx context.Context, u *entity.User) (*model.User, error) {
+		return postgresuser.SealUser(ctx, nil, u)
+	}
+
+	copyIn := regexp.QuoteMeta(`COPY "users" ("id", "email", "phone", "name", "email_index") FROM STDIN`)
+	mock.ExpectBegin()
+	prep := mock.ExpectPrepare(copyIn)
+	prep.ExpectExec().WithArgs("1", "an.nguyen1@example.com", "+84901234567", "Nguyễn Văn An", nil).WillReturnResult(sqlmock.NewResult(0, 1))
+	prep.ExpectExec().WithArgs("2", "emma.smith2@example.org", "", "Emma Smith", nil).WillReturnResult(sqlmock.NewResult(0, 1))
+	prep.ExpectExec().WithoutArgs().WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	n, err := Copy(t.Context(), db, slices.Values(users), plain)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), n)
+
+	// A failing row rolls everything back.
+	mock.ExpectBegin()
+	prep = mock.ExpectPrepare(copyIn)
+	prep.ExpectExec().WillReturnError(errors.New("duplicate key"))
+	mock.ExpectRollback()
+	_, err = Copy(t.Context(), db, slices.Values(users), plain)
+	require.ErrorContains(t, err, "copy user 1: duplicate key")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCopyEncrypts(t *testing.T) {
+	t.Parallel()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	kms, err := encryption.NewLocalKMS("k1:"+base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32)), "")
+	require.NoError(t, err)
+	envelope := encryption.New(kms, bytes.Repeat([]byte{9}, 32))
+	user := &entity.User{ID: "1", Name: "An", Email: "an@example.com", Phone: "0901234567"}
+
+	copyIn := regexp.QuoteMeta(`COPY "users" ("id", "email", "phone", "name", "email_index") FROM STDIN`)
+	mock.ExpectBegin()
+	prep := mock.ExpectPrepare(copyIn)
+	prep.ExpectExec().WithArgs("1", sealed{}, sealed{}, "An", envelope.Index("an@example.com")).WillReturnResult(sqlmock.NewResult(0, 1))
+	prep.ExpectExec().WithoutArgs().WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	_, err = Copy(t.Context(), db, slices.Values([]*entity.User{user}), func(ctx context.Context, u *entity.User) (*model.User, error) {
+		return postgresuser.SealUser(ctx, envelope, u)
+	})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+	require.Equal(t, "an@example.com", user.Email, "the user itself is left as it is")
+}
+
+// sealed matches a value the envelope encrypted.
+type sealed struct{}
+
+func (sealed) Match(v driver.Value) bool {
+	s, ok := v.(string)
+	return ok && encryption.IsEncrypted(s)
+}
@@ -0,0 +1,110 @@
+package tracing
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"user-domain/infrastructure/database"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const gormSpanKey = "otel:span"
+
+var (
+	sqlStringLiteral = regexp.MustCompile(`'(?:[^']|'')*'`)
+	sqlNumberLiteral = regexp.MustCompile(`([^\w$.])-?\d+(?:\.\d+)?\b`)
+)
+
+type gormPlugin struct {
+	system attribute.KeyValue
+}
+
+// NewGormPlugin returns a gorm plugin that records a client span for every
+// statement on a database of driver, one of the database.Driver names.
+// Bound values are never attached and literals inlined into the SQL text are
+// replaced with "?".
+func NewGormPlugin(driver string) gorm.Plugin {
+	switch driver {
+	case database.DriverPostgres:
+		return &gormPlugin{system: semconv.DBSystemNamePostgreSQL}
+	case database.DriverSQLite:
+		return &gormPlugin{system: semconv.DBSystemNameSQLite}
+	}
+	return &gormPlugin{system: semconv.DBSystemNameKey.String(driver)}
+}
+
+func (p *gormPlugin) Name() string {
+	return "otel:tracing"
+}
+
+func (p *gormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("otel:before_create", p.before("create")),
+		cb.Create().After("gorm:create").Register("otel:after_create", after),
+		cb.Query().Before("gorm:query").Register("otel:before_query", p.before("query")),
+		cb.Query().After("gorm:query").Register("otel:after_query", after),
+		cb.Update().Before("gorm:update").Register("otel:before_update", p.before("update")),
+		cb.Update().After("gorm:update").Register("otel:after_update", after),
+		cb.Delete().Before("gorm:delete").Register("otel:before_delete", p.before("delete")),
+		cb.Delete().After("gorm:delete").Register("otel:after_delete", after),
+		cb.Row().Before("gorm:row").Register("otel:before_row", p.before("row")),
+		cb.Row().After("gorm:row").Register("otel:after_row", after),
+		cb.Raw().Before("gorm:raw").Register("otel:before_raw", p.before("raw")),
+		cb.Raw().After("gorm:raw").Register("otel:after_raw", after),
+	)
+}
+
+func (p *gormPlugin) before(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Statement == nil || db.Statement.Context == nil {
+			return
+		}
+		ctx, span := tracer().Start(db.Statement.Context, "gorm."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				p.system,
+				semconv.DBOperationName(operation),
+			),
+		)
+		db.Statement.Context = ctx
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+func after(db *gorm.DB) {
+	v, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := v.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	if table := db.Statement.Table; table != "" {
+		span.SetAttributes(semconv.DBCollectionName(table))
+	}
+	span.SetAttributes(
+		semconv.DBQueryText(SanitizeSQL(db.Statement.SQL.String())),
+		attribute.Int64("db.rows_affected", db.RowsAffected),
+	)
+	if err := db.Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// SanitizeSQL strips string and numeric literals from a statement so that it
+// can be exported without leaking the values it was built with.
+func SanitizeSQL(sql string) string {
+	sql = sqlStringLiteral.ReplaceAllString(sql, "?")
+	sql = sqlNumberLiteral.ReplaceAllString(sql, "${1}?")
+	return strings.TrimSpace(sql)
+}
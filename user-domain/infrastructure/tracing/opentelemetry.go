@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"user-domain/infrastructure/config"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+
+	instrumentationName = "user-domain"
+)
+
+type ShutdownFunc func(ctx context.Context) error
+
+// Setup installs the global tracer provider and the W3C trace context
+// propagator. The returned function flushes pending spans and must be called
+// before the process exits.
+func Setup(ctx context.Context, cfg *config.Config) (ShutdownFunc, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	exporter, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if exporter == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+		semconv.DeploymentEnvironmentName(cfg.Env),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("tracing: build resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.TracingSampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+func newExporter(ctx context.Context, cfg *config.Config) (sdktrace.SpanExporter, error) {
+	switch cfg.TracingExporter {
+	case "", ExporterNone:
+		return nil, nil
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if cfg.TracingEndpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.TracingEndpoint))
+		}
+		if cfg.TracingInsecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("tracing: unknown exporter %q", cfg.TracingExporter)
+	}
+}
+
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
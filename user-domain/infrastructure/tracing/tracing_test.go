@@ -0,0 +1,122 @@
+package tracing
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"user-domain/infrastructure/database"
+	domainmock "user-domain/internal/domain/mocks/inport"
+	"user-domain/internal/entity"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func newRecorder(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return recorder
+}
+
+func TestSanitizeSQL(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{
+			name: "placeholders are kept",
+			sql:  `SELECT * FROM "users" WHERE "users"."id" = $1 LIMIT $2`,
+			want: `SELECT * FROM "users" WHERE "users"."id" = $1 LIMIT $2`,
+		},
+		{
+			name: "string literals",
+			sql:  `SELECT * FROM "users" WHERE email = 'a@b.com' AND name = 'O''Brien'`,
+			want: `SELECT * FROM "users" WHERE email = ? AND name = ?`,
+		},
+		{
+			name: "numeric literals",
+			sql:  `SELECT * FROM "users" LIMIT 10 OFFSET 20`,
+			want: `SELECT * FROM "users" LIMIT ? OFFSET ?`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, SanitizeSQL(tt.sql))
+		})
+	}
+}
+
+func TestUserServiceSpans(t *testing.T) {
+	recorder := newRecorder(t)
+	next := domainmock.NewUserService(t)
+	next.On("GetUserByID", mock.Anything, "42").Return(&entity.User{ID: "42"}, nil).Once()
+	next.On("DeleteUser", mock.Anything, "42").Return(errors.New("boom")).Once()
+	svc := NewUserService(next)
+
+	_, err := svc.GetUserByID(t.Context(), "42")
+	require.NoError(t, err)
+	require.Error(t, svc.DeleteUser(t.Context(), "42"))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	require.Equal(t, "UserService.GetUserByID", spans[0].Name())
+	require.Contains(t, spans[0].Attributes(), userIDKey.String("42"))
+	require.Equal(t, codes.Unset, spans[0].Status().Code)
+	require.Equal(t, "UserService.DeleteUser", spans[1].Name())
+	require.Equal(t, codes.Error, spans[1].Status().Code)
+}
+
+func TestGormPluginRecordsSanitizedStatement(t *testing.T) {
+	recorder := newRecorder(t)
+	conn, m, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}))
+	require.NoError(t, err)
+	require.NoError(t, db.Use(NewGormPlugin(database.DriverPostgres)))
+
+	m.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE email = $1`)).
+		WithArgs("secret@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+
+	var rows []map[string]interface{}
+	require.NoError(t, db.WithContext(t.Context()).Table("users").Where("email = ?", "secret@example.com").Find(&rows).Error)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, "gorm.query", spans[0].Name())
+	require.Contains(t, spans[0].Attributes(), semconv.DBQueryText(`SELECT * FROM "users" WHERE email = $1`))
+	require.Contains(t, spans[0].Attributes(), semconv.DBCollectionName("users"))
+	require.Contains(t, spans[0].Attributes(), semconv.DBSystemNamePostgreSQL)
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestGormPluginNamesTheDriver(t *testing.T) {
+	recorder := newRecorder(t)
+	conn, m, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}))
+	require.NoError(t, err)
+	require.NoError(t, db.Use(NewGormPlugin(database.DriverSQLite)))
+
+	m.ExpectExec(regexp.QuoteMeta(`DELETE FROM users`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	require.NoError(t, db.WithContext(t.Context()).Exec("DELETE FROM users").Error)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Contains(t, spans[0].Attributes(), semconv.DBSystemNameSQLite)
+}
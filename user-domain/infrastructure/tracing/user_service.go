@@ -0,0 +1,99 @@
+package tracing
+
+import (
+	"context"
+	"user-domain/internal/domain/inport"
+	"user-domain/internal/entity"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const userIDKey = attribute.Key("user.id")
+
+type userService struct {
+	next inport.UserService
+}
+
+func (s *userService) CreateUser(ctx context.Context, user *entity.User) (err error) {
+	ctx, span := startSpan(ctx, "UserService.CreateUser")
+	defer func() { endSpan(span, err) }()
+	err = s.next.CreateUser(ctx, user)
+	span.SetAttributes(userIDKey.String(user.ID))
+	return err
+}
+
+func (s *userService) GetUserByID(ctx context.Context, id string) (_ *entity.User, err error) {
+	ctx, span := startSpan(ctx, "UserService.GetUserByID", userIDKey.String(id))
+	defer func() { endSpan(span, err) }()
+	return s.next.GetUserByID(ctx, id)
+}
+
+func (s *userService) GetUsersByIDs(ctx context.Context, ids []string) (_ []*entity.User, err error) {
+	ctx, span := startSpan(ctx, "UserService.GetUsersByIDs", attribute.Int("user.count", len(ids)))
+	defer func() { endSpan(span, err) }()
+	return s.next.GetUsersByIDs(ctx, ids)
+}
+
+func (s *userService) GetUserByEmail(ctx context.Context, email string) (_ *entity.User, err error) {
+	ctx, span := startSpan(ctx, "UserService.GetUserByEmail")
+	defer func() { endSpan(span, err) }()
+	return s.next.GetUserByEmail(ctx, email)
+}
+
+func (s *userService) UpdateUser(ctx context.Context, user *entity.User) (err error) {
+	ctx, span := startSpan(ctx, "UserService.UpdateUser", userIDKey.String(user.ID))
+	defer func() { endSpan(span, err) }()
+	return s.next.UpdateUser(ctx, user)
+}
+
+func (s *userService) DeleteUser(ctx context.Context, id string) (err error) {
+	ctx, span := startSpan(ctx, "UserService.DeleteUser", userIDKey.String(id))
+	defer func() { endSpan(span, err) }()
+	return s.next.DeleteUser(ctx, id)
+}
+
+func (s *userService) RestoreUser(ctx context.Context, id string) (err error) {
+	ctx, span := startSpan(ctx, "UserService.RestoreUser", userIDKey.String(id))
+	defer func() { endSpan(span, err) }()
+	return s.next.RestoreUser(ctx, id)
+}
+
+func (s *userService) ListUsers(ctx context.Context, offset, limit int) (_ []*entity.User, err error) {
+	ctx, span := startSpan(ctx, "UserService.ListUsers",
+		attribute.Int("page.offset", offset),
+		attribute.Int("page.limit", limit),
+	)
+	defer func() { endSpan(span, err) }()
+	return s.next.ListUsers(ctx, offset, limit)
+}
+
+func (s *userService) ExportPersonalData(ctx context.Context, id string) (_ *entity.PersonalData, err error) {
+	ctx, span := startSpan(ctx, "UserService.ExportPersonalData", userIDKey.String(id))
+	defer func() { endSpan(span, err) }()
+	return s.next.ExportPersonalData(ctx, id)
+}
+
+func (s *userService) ErasePersonalData(ctx context.Context, id string) (err error) {
+	ctx, span := startSpan(ctx, "UserService.ErasePersonalData", userIDKey.String(id))
+	defer func() { endSpan(span, err) }()
+	return s.next.ErasePersonalData(ctx, id)
+}
+
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer().Start(ctx, name, trace.WithSpanKind(trace.SpanKindInternal), trace.WithAttributes(attrs...))
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// NewUserService wraps every use case of the domain service in its own span.
+func NewUserService(next inport.UserService) inport.UserService {
+	return &userService{next: next}
+}
@@ -0,0 +1,44 @@
+package apiutil
+
+import (
+	"encoding/json"
+	"net/http"
+	domainerror "user-domain/internal/domain/error"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	ProblemContentType = "application/problem+json"
+	problemTypePrefix  = "urn:user-domain:problem:"
+)
+
+// Problem is an RFC 7807 problem details object extended with the stable
+// error code and the invalid fields, if any.
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	// Instance is the request ID, quote it when reporting a problem.
+	Instance string                   `json:"instance,omitempty"`
+	Code     domainerror.Code         `json:"code"`
+	Errors   []domainerror.FieldError `json:"errors,omitempty"`
+}
+
+func NewProblem(r *http.Request, status int, code domainerror.Code, detail string) *Problem {
+	return &Problem{
+		Type:     problemTypePrefix + string(code),
+		Title:    code.Title(),
+		Status:   status,
+		Detail:   detail,
+		Instance: middleware.GetReqID(r.Context()),
+		Code:     code,
+	}
+}
+
+func WriteProblem(w http.ResponseWriter, p *Problem) {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
@@ -16,11 +16,6 @@ type JSONResponse interface {
 	Failure(err error)
 }
 
-type ErrorResponse struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-}
-
 type jsonResponse struct {
 	request *http.Request
 	w       http.ResponseWriter
@@ -71,6 +66,8 @@ func responseNil(status int, response interface{}) (int, []byte, error) {
 	return status, nil, nil
 }
 
+// Failure answers with a problem+json body. Only the code and a message
+// meant for clients are returned, the error itself is logged.
 func (v *jsonResponse) Failure(err error) {
 	var code int
 	switch {
@@ -87,15 +84,19 @@ func (v *jsonResponse) Failure(err error) {
 		code = http.StatusInternalServerError
 	}
 
-	v.w.WriteHeader(code)
 	if code == http.StatusInternalServerError {
 		v.logger.WithContext(v.request.Context()).Error("error: %s", err)
 	} else {
 		v.logger.WithContext(v.request.Context()).Warn("error: %s", err)
 	}
 
-	_ = json.NewEncoder(v.w).Encode(ErrorResponse{
-		Code:    code,
-		Message: err.Error(),
-	})
+	problem := NewProblem(v.request, code, domainerror.CodeOf(err), domainerror.MessageOf(err))
+	if errors.Is(err, applicationerror.ErrDecode) {
+		problem = NewProblem(v.request, code, domainerror.CodeMalformedRequest, "the request body is not valid JSON")
+	}
+	var domainErr *domainerror.Error
+	if errors.As(err, &domainErr) {
+		problem.Errors = domainErr.Fields
+	}
+	WriteProblem(v.w, problem)
 }
@@ -0,0 +1,34 @@
+package dto
+
+import (
+	"time"
+	"user-domain/internal/entity"
+)
+
+type PersonalDataResponse struct {
+	User      UserResponse                  `json:"user"`
+	CreatedAt time.Time                     `json:"created_at"`
+	UpdatedAt time.Time                     `json:"updated_at"`
+	DeletedAt *time.Time                    `json:"deleted_at,omitempty"`
+	ErasedAt  *time.Time                    `json:"erased_at,omitempty"`
+	Requests  []PersonalDataRequestResponse `json:"requests"`
+}
+
+type PersonalDataRequestResponse struct {
+	ID          string    `json:"id"`
+	Kind        string    `json:"kind"`
+	RequestedAt time.Time `json:"requested_at"`
+}
+
+func (p *PersonalDataResponse) GetFrom(e *entity.PersonalData) {
+	p.User.GetFrom(&e.User)
+	p.CreatedAt = e.CreatedAt
+	p.UpdatedAt = e.UpdatedAt
+	p.DeletedAt = e.DeletedAt
+	p.ErasedAt = e.ErasedAt
+	// No requests is [] rather than null.
+	p.Requests = make([]PersonalDataRequestResponse, 0, len(e.Requests))
+	for _, r := range e.Requests {
+		p.Requests = append(p.Requests, PersonalDataRequestResponse{ID: r.ID, Kind: r.Kind, RequestedAt: r.RequestedAt})
+	}
+}
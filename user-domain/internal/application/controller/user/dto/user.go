@@ -57,6 +57,8 @@ type UsersResponse struct {
 }
 
 func (u *UsersResponse) GetFrom(users []*entity.User) {
+	// An empty page is [] rather than null.
+	u.Item = make([]*UserResponse, 0, len(users))
 	for _, us := range users {
 		uRes := UserResponse{}
 		uRes.GetFrom(us)
@@ -50,6 +50,7 @@ func (h *user) PutUsersUserId(w http.ResponseWriter, r *http.Request, userID str
 	err = h.sv.UpdateUser(r.Context(), &userEntity)
 	if err != nil {
 		responseWriter.Failure(err)
+		return
 	}
 	responseWriter.Success(http.StatusNoContent, nil)
 }
@@ -74,6 +75,38 @@ func (h *user) DeleteUsersUserId(w http.ResponseWriter, r *http.Request, userID
 	}
 }
 
+func (h *user) PostUsersUserIdRestore(w http.ResponseWriter, r *http.Request, userID string) {
+	responseWriter := apiutil.NewJSONResponse(w, r, h.logger)
+	err := h.sv.RestoreUser(r.Context(), userID)
+	if err != nil {
+		responseWriter.Failure(err)
+		return
+	}
+	responseWriter.Success(http.StatusNoContent, nil)
+}
+
+func (h *user) GetUsersUserIdPersonalData(w http.ResponseWriter, r *http.Request, userID string) {
+	responseWriter := apiutil.NewJSONResponse(w, r, h.logger)
+	data, err := h.sv.ExportPersonalData(r.Context(), userID)
+	if err != nil {
+		responseWriter.Failure(err)
+		return
+	}
+	res := dto.PersonalDataResponse{}
+	res.GetFrom(data)
+	responseWriter.Success(http.StatusOK, res)
+}
+
+func (h *user) DeleteUsersUserIdPersonalData(w http.ResponseWriter, r *http.Request, userID string) {
+	responseWriter := apiutil.NewJSONResponse(w, r, h.logger)
+	err := h.sv.ErasePersonalData(r.Context(), userID)
+	if err != nil {
+		responseWriter.Failure(err)
+		return
+	}
+	responseWriter.Success(http.StatusNoContent, nil)
+}
+
 func (h *user) GetUsers(w http.ResponseWriter, r *http.Request, paramObj parameter.UserQueryParams) {
 	responseWriter := apiutil.NewJSONResponse(w, r, h.logger)
 	eUsers, err := h.sv.ListUsers(r.Context(), paramObj.Offset, paramObj.Limit)
@@ -2,20 +2,37 @@ package controller
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
 
+	"user-domain/internal/application/controller/apiutil"
 	appmock "user-domain/internal/application/mocks/outbound"
+	domainerror "user-domain/internal/domain/error"
 	domainmock "user-domain/internal/domain/mocks/inport"
 	"user-domain/internal/entity"
 
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/stretchr/testify/mock"
 )
 
+// headerRecorder counts how often a handler writes the status line, which
+// must be exactly once.
+type headerRecorder struct {
+	*httptest.ResponseRecorder
+	writes int
+}
+
+func (r *headerRecorder) WriteHeader(code int) {
+	r.writes++
+	r.ResponseRecorder.WriteHeader(code)
+}
+
 func newController(t *testing.T) (*user, *domainmock.UserService, *appmock.Logger) {
 	sv := domainmock.NewUserService(t)
 	logger := appmock.NewLogger(t)
@@ -165,11 +182,14 @@ func TestPutUsersUserId(t *testing.T) {
 			}
 
 			req := httptest.NewRequest(http.MethodPut, "/users/"+tt.userID, bodyReader)
-			w := httptest.NewRecorder()
+			w := &headerRecorder{ResponseRecorder: httptest.NewRecorder()}
 			ctrl.PutUsersUserId(w, req, tt.userID)
 			if w.Code != tt.wantCode {
 				t.Fatalf("status = %d, want %d, body=%s", w.Code, tt.wantCode, w.Body.String())
 			}
+			if w.writes != 1 {
+				t.Fatalf("status written %d times, want once", w.writes)
+			}
 		})
 	}
 }
@@ -227,6 +247,46 @@ func TestGetUsersUserId(t *testing.T) {
 	}
 }
 
+func TestFailureWritesProblem(t *testing.T) {
+	t.Parallel()
+	ctrl, sv, logger := newController(t)
+	cause := errors.New("pq: connection refused to 10.0.0.5")
+	sv.On("GetUserByID", mock.Anything, "42").Return((*entity.User)(nil),
+		domainerror.New(domainerror.ErrCodeNotFound, domainerror.CodeUserNotFound, "user 42 was not found").WithCause(cause))
+	logger.On("WithContext", mock.Anything).Return(logger)
+	logger.On("Warn", mock.Anything, mock.Anything)
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req = req.WithContext(context.WithValue(req.Context(), chimiddleware.RequestIDKey, "req-1"))
+	w := httptest.NewRecorder()
+	ctrl.GetUsersUserId(w, req, "42")
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != apiutil.ProblemContentType {
+		t.Fatalf("content type = %q", ct)
+	}
+	var p apiutil.Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	want := apiutil.Problem{
+		Type:     "urn:user-domain:problem:user_not_found",
+		Title:    "User not found",
+		Status:   http.StatusNotFound,
+		Detail:   "user 42 was not found",
+		Instance: "req-1",
+		Code:     domainerror.CodeUserNotFound,
+	}
+	if !reflect.DeepEqual(p, want) {
+		t.Fatalf("problem = %+v, want %+v", p, want)
+	}
+	if bytes.Contains(w.Body.Bytes(), []byte("10.0.0.5")) {
+		t.Fatalf("cause leaked to the client: %s", w.Body.String())
+	}
+}
+
 func TestDeleteUsersUserId(t *testing.T) {
 	t.Parallel()
 
@@ -280,6 +340,146 @@ func TestDeleteUsersUserId(t *testing.T) {
 	}
 }
 
+func TestPostUsersUserIdRestore(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		userID    string
+		mockSetup func(sv *domainmock.UserService)
+		logSetup  func(l *appmock.Logger)
+		wantCode  int
+	}{
+		{
+			name:   "success",
+			userID: "9",
+			mockSetup: func(sv *domainmock.UserService) {
+				sv.On("RestoreUser", mock.Anything, "9").Return(nil)
+			},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:   "not found",
+			userID: "9",
+			mockSetup: func(sv *domainmock.UserService) {
+				sv.On("RestoreUser", mock.Anything, "9").Return(
+					domainerror.New(domainerror.ErrCodeNotFound, domainerror.CodeUserNotFound, "user 9 was not found"))
+			},
+			logSetup: func(l *appmock.Logger) {
+				l.On("WithContext", mock.Anything).Return(l)
+				l.On("Warn", mock.Anything, mock.Anything)
+			},
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl, sv, logger := newController(t)
+			if tt.mockSetup != nil {
+				tt.mockSetup(sv)
+			}
+			if tt.logSetup != nil {
+				tt.logSetup(logger)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/users/"+tt.userID+"/restore", nil)
+			w := httptest.NewRecorder()
+			ctrl.PostUsersUserIdRestore(w, req, tt.userID)
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d, body=%s", w.Code, tt.wantCode, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetUsersUserIdPersonalData(t *testing.T) {
+	t.Parallel()
+	ctrl, sv, _ := newController(t)
+	sv.On("ExportPersonalData", mock.Anything, "42").Return(&entity.PersonalData{
+		User:     entity.User{ID: "42", Name: "Bob", Email: "bob@example.com"},
+		Requests: []entity.PersonalDataRequest{{ID: "r1", UserID: "42", Kind: entity.PersonalDataExport}},
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42/personal-data", nil)
+	w := httptest.NewRecorder()
+	ctrl.GetUsersUserIdPersonalData(w, req, "42")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d, body=%s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var got struct {
+		User struct {
+			Email string `json:"email"`
+		} `json:"user"`
+		DeletedAt *string `json:"deleted_at"`
+		Requests  []struct {
+			ID   string `json:"id"`
+			Kind string `json:"kind"`
+		} `json:"requests"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.User.Email != "bob@example.com" || got.DeletedAt != nil || len(got.Requests) != 1 || got.Requests[0].Kind != "export" {
+		t.Fatalf("body = %s", w.Body.String())
+	}
+}
+
+func TestDeleteUsersUserIdPersonalData(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		userID    string
+		mockSetup func(sv *domainmock.UserService)
+		logSetup  func(l *appmock.Logger)
+		wantCode  int
+	}{
+		{
+			name:   "success",
+			userID: "9",
+			mockSetup: func(sv *domainmock.UserService) {
+				sv.On("ErasePersonalData", mock.Anything, "9").Return(nil)
+			},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:   "not found",
+			userID: "9",
+			mockSetup: func(sv *domainmock.UserService) {
+				sv.On("ErasePersonalData", mock.Anything, "9").Return(
+					domainerror.New(domainerror.ErrCodeNotFound, domainerror.CodeUserNotFound, "user 9 was not found"))
+			},
+			logSetup: func(l *appmock.Logger) {
+				l.On("WithContext", mock.Anything).Return(l)
+				l.On("Warn", mock.Anything, mock.Anything)
+			},
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl, sv, logger := newController(t)
+			if tt.mockSetup != nil {
+				tt.mockSetup(sv)
+			}
+			if tt.logSetup != nil {
+				tt.logSetup(logger)
+			}
+			req := httptest.NewRequest(http.MethodDelete, "/users/"+tt.userID+"/personal-data", nil)
+			w := httptest.NewRecorder()
+			ctrl.DeleteUsersUserIdPersonalData(w, req, tt.userID)
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d, body=%s", w.Code, tt.wantCode, w.Body.String())
+			}
+		})
+	}
+}
+
 func TestGetUsers(t *testing.T) {
 	t.Parallel()
 
@@ -10,5 +10,8 @@ type UserApi interface {
 	PutUsersUserId(w http.ResponseWriter, r *http.Request, userID string)
 	GetUsersUserId(w http.ResponseWriter, r *http.Request, userID string)
 	DeleteUsersUserId(w http.ResponseWriter, r *http.Request, userId string)
+	PostUsersUserIdRestore(w http.ResponseWriter, r *http.Request, userID string)
+	GetUsersUserIdPersonalData(w http.ResponseWriter, r *http.Request, userID string)
+	DeleteUsersUserIdPersonalData(w http.ResponseWriter, r *http.Request, userID string)
 	GetUsers(w http.ResponseWriter, r *http.Request, paramObj parameter.UserQueryParams)
 }
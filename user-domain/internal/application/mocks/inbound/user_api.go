@@ -19,6 +19,11 @@ func (_m *UserApi) DeleteUsersUserId(w http.ResponseWriter, r *http.Request, use
 	_m.Called(w, r, userId)
 }
 
+// DeleteUsersUserIdPersonalData provides a mock function with given fields: w, r, userID
+func (_m *UserApi) DeleteUsersUserIdPersonalData(w http.ResponseWriter, r *http.Request, userID string) {
+	_m.Called(w, r, userID)
+}
+
 // GetUsers provides a mock function with given fields: w, r, paramObj
 func (_m *UserApi) GetUsers(w http.ResponseWriter, r *http.Request, paramObj applicationparameter.UserQueryParams) {
 	_m.Called(w, r, paramObj)
@@ -29,11 +34,21 @@ func (_m *UserApi) GetUsersUserId(w http.ResponseWriter, r *http.Request, userID
 	_m.Called(w, r, userID)
 }
 
+// GetUsersUserIdPersonalData provides a mock function with given fields: w, r, userID
+func (_m *UserApi) GetUsersUserIdPersonalData(w http.ResponseWriter, r *http.Request, userID string) {
+	_m.Called(w, r, userID)
+}
+
 // PostUsers provides a mock function with given fields: w, r
 func (_m *UserApi) PostUsers(w http.ResponseWriter, r *http.Request) {
 	_m.Called(w, r)
 }
 
+// PostUsersUserIdRestore provides a mock function with given fields: w, r, userID
+func (_m *UserApi) PostUsersUserIdRestore(w http.ResponseWriter, r *http.Request, userID string) {
+	_m.Called(w, r, userID)
+}
+
 // PutUsersUserId provides a mock function with given fields: w, r, userID
 func (_m *UserApi) PutUsersUserId(w http.ResponseWriter, r *http.Request, userID string) {
 	_m.Called(w, r, userID)
@@ -50,6 +50,84 @@ func (_m *UserRepo) DeleteUser(ctx context.Context, id string) error {
 	return r0
 }
 
+// EraseUser provides a mock function with given fields: ctx, id
+func (_m *UserRepo) EraseUser(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EraseUser")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetPersonalData provides a mock function with given fields: ctx, id
+func (_m *UserRepo) GetPersonalData(ctx context.Context, id string) (*entity.PersonalData, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPersonalData")
+	}
+
+	var r0 *entity.PersonalData
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*entity.PersonalData, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *entity.PersonalData); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.PersonalData)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserByEmail provides a mock function with given fields: ctx, email
+func (_m *UserRepo) GetUserByEmail(ctx context.Context, email string) (*entity.User, error) {
+	ret := _m.Called(ctx, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserByEmail")
+	}
+
+	var r0 *entity.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*entity.User, error)); ok {
+		return rf(ctx, email)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *entity.User); ok {
+		r0 = rf(ctx, email)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetUserByID provides a mock function with given fields: ctx, id
 func (_m *UserRepo) GetUserByID(ctx context.Context, id string) (*entity.User, error) {
 	ret := _m.Called(ctx, id)
@@ -80,6 +158,36 @@ func (_m *UserRepo) GetUserByID(ctx context.Context, id string) (*entity.User, e
 	return r0, r1
 }
 
+// GetUsersByIDs provides a mock function with given fields: ctx, ids
+func (_m *UserRepo) GetUsersByIDs(ctx context.Context, ids []string) ([]*entity.User, error) {
+	ret := _m.Called(ctx, ids)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUsersByIDs")
+	}
+
+	var r0 []*entity.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string) ([]*entity.User, error)); ok {
+		return rf(ctx, ids)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []*entity.User); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*entity.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ListUsers provides a mock function with given fields: ctx, offset, limit
 func (_m *UserRepo) ListUsers(ctx context.Context, offset int, limit int) ([]*entity.User, error) {
 	ret := _m.Called(ctx, offset, limit)
@@ -110,6 +218,42 @@ func (_m *UserRepo) ListUsers(ctx context.Context, offset int, limit int) ([]*en
 	return r0, r1
 }
 
+// RecordPersonalDataRequest provides a mock function with given fields: ctx, req
+func (_m *UserRepo) RecordPersonalDataRequest(ctx context.Context, req *entity.PersonalDataRequest) error {
+	ret := _m.Called(ctx, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecordPersonalDataRequest")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.PersonalDataRequest) error); ok {
+		r0 = rf(ctx, req)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RestoreUser provides a mock function with given fields: ctx, id
+func (_m *UserRepo) RestoreUser(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestoreUser")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateUser provides a mock function with given fields: ctx, user
 func (_m *UserRepo) UpdateUser(ctx context.Context, user *entity.User) error {
 	ret := _m.Called(ctx, user)
@@ -0,0 +1,13 @@
+package outbound
+
+import "context"
+
+type TransactionManager interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+	// AfterCommit runs fn once the transaction ctx belongs to has committed,
+	// or straight away when ctx belongs to none.
+	AfterCommit(ctx context.Context, fn func())
+	// InTransaction reports whether ctx belongs to a transaction started by
+	// Do.
+	InTransaction(ctx context.Context) bool
+}
@@ -9,6 +9,18 @@ type UserRepo interface {
 	CreateUser(ctx context.Context, user *entity.User) error
 	UpdateUser(ctx context.Context, user *entity.User) error
 	DeleteUser(ctx context.Context, id string) error
+	RestoreUser(ctx context.Context, id string) error
 	GetUserByID(ctx context.Context, id string) (*entity.User, error)
+	GetUsersByIDs(ctx context.Context, ids []string) ([]*entity.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*entity.User, error)
 	ListUsers(ctx context.Context, offset, limit int) ([]*entity.User, error)
+	// GetPersonalData returns the user, deleted or erased too, with the
+	// personal data requests made about it.
+	GetPersonalData(ctx context.Context, id string) (*entity.PersonalData, error)
+	// EraseUser overwrites the user's personal data with entity.ErasedUser
+	// and deletes it so that it cannot be restored. The row itself stays
+	// for whatever refers to it.
+	EraseUser(ctx context.Context, id string) error
+	// RecordPersonalDataRequest stores req, assigning its ID and time.
+	RecordPersonalDataRequest(ctx context.Context, req *entity.PersonalDataRequest) error
 }
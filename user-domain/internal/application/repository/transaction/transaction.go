@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"user-domain/internal/application/outbound"
+	"user-domain/internal/domain/outport"
+)
+
+type transactionManager struct {
+	txOutbound outbound.TransactionManager
+}
+
+func (t *transactionManager) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return t.txOutbound.Do(ctx, fn)
+}
+
+func NewTransactionManager(txOutbound outbound.TransactionManager) outport.TransactionManager {
+	return &transactionManager{
+		txOutbound: txOutbound,
+	}
+}
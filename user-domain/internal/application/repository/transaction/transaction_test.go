@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	application_mock "user-domain/internal/application/mocks/outbound"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDo(t *testing.T) {
+	t.Parallel()
+	failed := errors.New("failed")
+	txMock := application_mock.NewTransactionManager(t)
+	txMock.On("Do", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	})
+
+	called := false
+	err := NewTransactionManager(txMock).Do(context.Background(), func(context.Context) error {
+		called = true
+		return failed
+	})
+	require.True(t, called)
+	require.ErrorIs(t, err, failed)
+}
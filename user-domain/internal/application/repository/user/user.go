@@ -23,14 +23,38 @@ func (u *userRepo) DeleteUser(ctx context.Context, id string) error {
 	return u.userOutbound.DeleteUser(ctx, id)
 }
 
+func (u *userRepo) RestoreUser(ctx context.Context, id string) error {
+	return u.userOutbound.RestoreUser(ctx, id)
+}
+
 func (u *userRepo) GetUserByID(ctx context.Context, id string) (*entity.User, error) {
 	return u.userOutbound.GetUserByID(ctx, id)
 }
 
+func (u *userRepo) GetUsersByIDs(ctx context.Context, ids []string) ([]*entity.User, error) {
+	return u.userOutbound.GetUsersByIDs(ctx, ids)
+}
+
+func (u *userRepo) GetUserByEmail(ctx context.Context, email string) (*entity.User, error) {
+	return u.userOutbound.GetUserByEmail(ctx, email)
+}
+
 func (u *userRepo) ListUsers(ctx context.Context, offset, limit int) ([]*entity.User, error) {
 	return u.userOutbound.ListUsers(ctx, offset, limit)
 }
 
+func (u *userRepo) GetPersonalData(ctx context.Context, id string) (*entity.PersonalData, error) {
+	return u.userOutbound.GetPersonalData(ctx, id)
+}
+
+func (u *userRepo) EraseUser(ctx context.Context, id string) error {
+	return u.userOutbound.EraseUser(ctx, id)
+}
+
+func (u *userRepo) RecordPersonalDataRequest(ctx context.Context, req *entity.PersonalDataRequest) error {
+	return u.userOutbound.RecordPersonalDataRequest(ctx, req)
+}
+
 func NewUserRepo(userOutbound outbound.UserRepo) outport.UserRepository {
 	return &userRepo{
 		userOutbound: userOutbound,
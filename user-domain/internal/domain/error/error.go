@@ -13,3 +13,133 @@ var (
 
 	ErrCodeInternal = errors.New("an unexpected internal server error occurred")
 )
+
+// Code identifies a failure for API clients. Codes are part of the public
+// contract: add new ones freely but never change or reuse an existing one.
+type Code string
+
+const (
+	CodeNotFound     Code = "not_found"
+	CodeInvalidInput Code = "invalid_input"
+	CodeConflict     Code = "conflict"
+	CodeForbidden    Code = "forbidden"
+	CodeInternal     Code = "internal"
+
+	CodeMalformedRequest Code = "malformed_request"
+	CodeValidation       Code = "validation_failed"
+	CodeRateLimited      Code = "rate_limited"
+	CodeIdempotencyReuse Code = "idempotency_key_reused"
+	CodeIdempotencyBusy  Code = "idempotency_key_in_progress"
+	CodeQueryTooComplex  Code = "query_too_complex"
+
+	CodeUserNotFound Code = "user_not_found"
+	CodeEmailTaken   Code = "email_taken"
+)
+
+var titles = map[Code]string{
+	CodeNotFound:         "Resource not found",
+	CodeInvalidInput:     "Invalid input",
+	CodeConflict:         "Conflict",
+	CodeForbidden:        "Forbidden",
+	CodeInternal:         "Internal error",
+	CodeMalformedRequest: "Malformed request",
+	CodeValidation:       "Validation failed",
+	CodeRateLimited:      "Too many requests",
+	CodeIdempotencyReuse: "Idempotency key reused",
+	CodeIdempotencyBusy:  "Request already in progress",
+	CodeQueryTooComplex:  "Query too complex",
+	CodeUserNotFound:     "User not found",
+	CodeEmailTaken:       "Email already in use",
+}
+
+// Title is a short, human-readable summary of code.
+func (c Code) Title() string {
+	if t, ok := titles[c]; ok {
+		return t
+	}
+	return string(c)
+}
+
+// FieldError describes one invalid input field.
+type FieldError struct {
+	Field string `json:"field"`
+	// Location is where the field was sent, e.g. body or query, when it
+	// matters.
+	Location string `json:"location,omitempty"`
+	Message  string `json:"message"`
+}
+
+// Error is a failure with a stable code and a message that is safe to show
+// to clients. Kind is one of the ErrCode* errors, so errors.Is keeps working;
+// Cause is kept for logs only.
+type Error struct {
+	Kind    error
+	Code    Code
+	Message string
+	Fields  []FieldError
+	Cause   error
+}
+
+func New(kind error, code Code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func (e *Error) WithCause(err error) *Error {
+	e.Cause = err
+	return e
+}
+
+func (e *Error) WithFields(fields ...FieldError) *Error {
+	e.Fields = append(e.Fields, fields...)
+	return e
+}
+
+func (e *Error) Error() string {
+	msg := string(e.Code) + ": " + e.Message
+	if e.Cause != nil {
+		msg += ": " + e.Cause.Error()
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Cause == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Cause}
+}
+
+// CodeOf returns the code of the first Error in err's chain, or the generic
+// code of its kind.
+func CodeOf(err error) Code {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	switch {
+	case errors.Is(err, ErrCodeNotFound):
+		return CodeNotFound
+	case errors.Is(err, ErrCodeInvalidInput):
+		return CodeInvalidInput
+	case errors.Is(err, ErrCodeConflict):
+		return CodeConflict
+	case errors.Is(err, ErrCodeForbidden):
+		return CodeForbidden
+	}
+	return CodeInternal
+}
+
+// MessageOf returns a message describing err that is safe to show to
+// clients; it never includes the underlying cause.
+func MessageOf(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Message
+	}
+	for _, kind := range []error{ErrCodeNotFound, ErrCodeInvalidInput, ErrCodeConflict, ErrCodeForbidden} {
+		if errors.Is(err, kind) {
+			return kind.Error()
+		}
+	}
+	return ErrCodeInternal.Error()
+}
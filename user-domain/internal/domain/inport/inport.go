@@ -8,7 +8,20 @@ import (
 type UserService interface {
 	CreateUser(ctx context.Context, user *entity.User) error
 	GetUserByID(ctx context.Context, id string) (*entity.User, error)
+	// GetUsersByIDs returns the users that exist among ids, in no
+	// particular order.
+	GetUsersByIDs(ctx context.Context, ids []string) ([]*entity.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*entity.User, error)
 	UpdateUser(ctx context.Context, user *entity.User) error
 	DeleteUser(ctx context.Context, id string) error
+	// RestoreUser undoes DeleteUser; restoring a user that is not deleted
+	// does nothing.
+	RestoreUser(ctx context.Context, id string) error
 	ListUsers(ctx context.Context, offset, limit int) ([]*entity.User, error)
+	// ExportPersonalData returns everything stored about the user, deleted
+	// or not, and records that it was asked for.
+	ExportPersonalData(ctx context.Context, id string) (*entity.PersonalData, error)
+	// ErasePersonalData anonymises and deletes the user for good, and
+	// records that it was asked for. The user can no longer be restored.
+	ErasePersonalData(ctx context.Context, id string) error
 }
@@ -51,6 +51,84 @@ func (_m *UserService) DeleteUser(ctx context.Context, id string) error {
 	return r0
 }
 
+// ErasePersonalData provides a mock function with given fields: ctx, id
+func (_m *UserService) ErasePersonalData(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ErasePersonalData")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ExportPersonalData provides a mock function with given fields: ctx, id
+func (_m *UserService) ExportPersonalData(ctx context.Context, id string) (*entity.PersonalData, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExportPersonalData")
+	}
+
+	var r0 *entity.PersonalData
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*entity.PersonalData, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *entity.PersonalData); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.PersonalData)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserByEmail provides a mock function with given fields: ctx, email
+func (_m *UserService) GetUserByEmail(ctx context.Context, email string) (*entity.User, error) {
+	ret := _m.Called(ctx, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserByEmail")
+	}
+
+	var r0 *entity.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*entity.User, error)); ok {
+		return rf(ctx, email)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *entity.User); ok {
+		r0 = rf(ctx, email)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetUserByID provides a mock function with given fields: ctx, id
 func (_m *UserService) GetUserByID(ctx context.Context, id string) (*entity.User, error) {
 	ret := _m.Called(ctx, id)
@@ -81,6 +159,36 @@ func (_m *UserService) GetUserByID(ctx context.Context, id string) (*entity.User
 	return r0, r1
 }
 
+// GetUsersByIDs provides a mock function with given fields: ctx, ids
+func (_m *UserService) GetUsersByIDs(ctx context.Context, ids []string) ([]*entity.User, error) {
+	ret := _m.Called(ctx, ids)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUsersByIDs")
+	}
+
+	var r0 []*entity.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string) ([]*entity.User, error)); ok {
+		return rf(ctx, ids)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []*entity.User); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*entity.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ListUsers provides a mock function with given fields: ctx, offset, limit
 func (_m *UserService) ListUsers(ctx context.Context, offset int, limit int) ([]*entity.User, error) {
 	ret := _m.Called(ctx, offset, limit)
@@ -111,6 +219,24 @@ func (_m *UserService) ListUsers(ctx context.Context, offset int, limit int) ([]
 	return r0, r1
 }
 
+// RestoreUser provides a mock function with given fields: ctx, id
+func (_m *UserService) RestoreUser(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestoreUser")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateUser provides a mock function with given fields: ctx, user
 func (_m *UserService) UpdateUser(ctx context.Context, user *entity.User) error {
 	ret := _m.Called(ctx, user)
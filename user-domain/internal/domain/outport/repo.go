@@ -8,7 +8,13 @@ import (
 type UserRepository interface {
 	CreateUser(ctx context.Context, user *entity.User) error
 	GetUserByID(ctx context.Context, id string) (*entity.User, error)
+	GetUsersByIDs(ctx context.Context, ids []string) ([]*entity.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*entity.User, error)
 	UpdateUser(ctx context.Context, user *entity.User) error
 	DeleteUser(ctx context.Context, id string) error
+	RestoreUser(ctx context.Context, id string) error
 	ListUsers(ctx context.Context, offset, limit int) ([]*entity.User, error)
+	GetPersonalData(ctx context.Context, id string) (*entity.PersonalData, error)
+	EraseUser(ctx context.Context, id string) error
+	RecordPersonalDataRequest(ctx context.Context, req *entity.PersonalDataRequest) error
 }
@@ -0,0 +1,10 @@
+package outport
+
+import "context"
+
+// TransactionManager runs fn in a single transaction. Repository calls made
+// with the context passed to fn take part in it; the transaction commits
+// when fn returns nil and rolls back otherwise.
+type TransactionManager interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -8,7 +8,10 @@ import (
 )
 
 type user struct {
-	repo   outport.UserRepository
+	repo outport.UserRepository
+	// tx makes use cases that need several repository calls atomic, run
+	// them inside tx.Do with the context it passes in.
+	tx     outport.TransactionManager
 	logger outport.Logger
 }
 
@@ -28,6 +31,17 @@ func (u *user) GetUserByID(ctx context.Context, id string) (*entity.User, error)
 	return userRes, nil
 }
 
+func (u *user) GetUsersByIDs(ctx context.Context, ids []string) ([]*entity.User, error) {
+	if len(ids) == 0 {
+		return []*entity.User{}, nil
+	}
+	return u.repo.GetUsersByIDs(ctx, ids)
+}
+
+func (u *user) GetUserByEmail(ctx context.Context, email string) (*entity.User, error) {
+	return u.repo.GetUserByEmail(ctx, email)
+}
+
 func (u *user) UpdateUser(ctx context.Context, user *entity.User) error {
 	// implement bussiness logic here
 	err := u.repo.UpdateUser(ctx, user)
@@ -38,6 +52,10 @@ func (u *user) DeleteUser(ctx context.Context, id string) error {
 	return u.repo.DeleteUser(ctx, id)
 }
 
+func (u *user) RestoreUser(ctx context.Context, id string) error {
+	return u.repo.RestoreUser(ctx, id)
+}
+
 func (u *user) ListUsers(ctx context.Context, offset, limit int) ([]*entity.User, error) {
 	entities, err := u.repo.ListUsers(ctx, offset, limit)
 	if err != nil {
@@ -46,6 +64,37 @@ func (u *user) ListUsers(ctx context.Context, offset, limit int) ([]*entity.User
 	return entities, nil
 }
 
-func NewUserService(r outport.UserRepository, logger outport.Logger) inport.UserService {
-	return &user{repo: r, logger: logger}
+func (u *user) ExportPersonalData(ctx context.Context, id string) (*entity.PersonalData, error) {
+	var data *entity.PersonalData
+	err := u.tx.Do(ctx, func(ctx context.Context) error {
+		var err error
+		data, err = u.repo.GetPersonalData(ctx, id)
+		if err != nil {
+			return err
+		}
+		// The export lists itself among the requests.
+		req := entity.PersonalDataRequest{UserID: id, Kind: entity.PersonalDataExport}
+		if err := u.repo.RecordPersonalDataRequest(ctx, &req); err != nil {
+			return err
+		}
+		data.Requests = append(data.Requests, req)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (u *user) ErasePersonalData(ctx context.Context, id string) error {
+	return u.tx.Do(ctx, func(ctx context.Context) error {
+		if err := u.repo.EraseUser(ctx, id); err != nil {
+			return err
+		}
+		return u.repo.RecordPersonalDataRequest(ctx, &entity.PersonalDataRequest{UserID: id, Kind: entity.PersonalDataErasure})
+	})
+}
+
+func NewUserService(r outport.UserRepository, tx outport.TransactionManager, logger outport.Logger) inport.UserService {
+	return &user{repo: r, tx: tx, logger: logger}
 }
@@ -17,7 +17,7 @@ func newSvc(t *testing.T) (context.Context, *domainmock.UserRepository, *domainm
 	ctx := context.Background()
 	repoMock := domainmock.NewUserRepository(t)
 	loggerMock := domainmock.NewLogger(t)
-	svc := NewUserService(repoMock, loggerMock)
+	svc := NewUserService(repoMock, domainmock.NewTransactionManager(t), loggerMock)
 	return ctx, repoMock, loggerMock, svc
 }
 
@@ -260,3 +260,51 @@ func TestListUsers(t *testing.T) {
 		})
 	}
 }
+
+// newTxSvc is newSvc with a transaction manager that runs what it is given.
+func newTxSvc(t *testing.T) (context.Context, *domainmock.UserRepository, domaininport.UserService) {
+	repoMock := domainmock.NewUserRepository(t)
+	txMock := domainmock.NewTransactionManager(t)
+	txMock.On("Do", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	})
+	return context.Background(), repoMock, NewUserService(repoMock, txMock, domainmock.NewLogger(t))
+}
+
+func TestExportPersonalData(t *testing.T) {
+	t.Parallel()
+	ctx, repoMock, svc := newTxSvc(t)
+	repoMock.On("GetPersonalData", mock.Anything, "1").Return(&entity.PersonalData{User: entity.User{ID: "1", Name: "Alice"}}, nil)
+	repoMock.On("RecordPersonalDataRequest", mock.Anything, &entity.PersonalDataRequest{UserID: "1", Kind: entity.PersonalDataExport}).
+		Run(func(args mock.Arguments) { args.Get(1).(*entity.PersonalDataRequest).ID = "r1" }).
+		Return(nil)
+
+	got, err := svc.ExportPersonalData(ctx, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Alice", got.User.Name)
+	// The export lists itself.
+	assert.Equal(t, []entity.PersonalDataRequest{{ID: "r1", UserID: "1", Kind: entity.PersonalDataExport}}, got.Requests)
+}
+
+func TestExportPersonalDataNotFound(t *testing.T) {
+	t.Parallel()
+	ctx, repoMock, svc := newTxSvc(t)
+	repoMock.On("GetPersonalData", mock.Anything, "9").Return((*entity.PersonalData)(nil), errors.New("not found"))
+
+	got, err := svc.ExportPersonalData(ctx, "9")
+	assert.EqualError(t, err, "not found")
+	assert.Nil(t, got)
+	repoMock.AssertNotCalled(t, "RecordPersonalDataRequest", mock.Anything, mock.Anything)
+}
+
+func TestErasePersonalData(t *testing.T) {
+	t.Parallel()
+	ctx, repoMock, svc := newTxSvc(t)
+	repoMock.On("EraseUser", mock.Anything, "1").Return(nil)
+	repoMock.On("RecordPersonalDataRequest", mock.Anything, &entity.PersonalDataRequest{UserID: "1", Kind: entity.PersonalDataErasure}).Return(nil)
+	repoMock.On("EraseUser", mock.Anything, "9").Return(errors.New("not found"))
+
+	assert.NoError(t, svc.ErasePersonalData(ctx, "1"))
+	assert.EqualError(t, svc.ErasePersonalData(ctx, "9"), "not found")
+	repoMock.AssertNumberOfCalls(t, "RecordPersonalDataRequest", 1)
+}
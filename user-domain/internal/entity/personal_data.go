@@ -0,0 +1,40 @@
+package entity
+
+import "time"
+
+// Kinds of PersonalDataRequest.
+const (
+	PersonalDataExport  = "export"
+	PersonalDataErasure = "erasure"
+)
+
+// PersonalData is everything stored about a user, the answer to a
+// data-subject access request.
+type PersonalData struct {
+	User      User
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// DeletedAt and ErasedAt are nil unless the user was deleted or had
+	// their personal data erased.
+	DeletedAt *time.Time
+	ErasedAt  *time.Time
+	// Requests are the data-subject requests made about the user, oldest
+	// first.
+	Requests []PersonalDataRequest
+}
+
+// PersonalDataRequest records that a user's personal data was exported or
+// erased. It holds no personal data itself, so it outlives an erasure.
+type PersonalDataRequest struct {
+	ID          string
+	UserID      string
+	Kind        string
+	RequestedAt time.Time
+}
+
+// ErasedUser is what is left of the user with the given id once their
+// personal data is erased. The email, which must be unique and is
+// required, becomes one that can never be delivered to.
+func ErasedUser(id string) User {
+	return User{ID: id, Name: "erased user", Email: "erased-" + id + "@erased.invalid"}
+}
@@ -0,0 +1,271 @@
+package userclient
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+)
+
+const (
+	requestIDHeader      = "X-Request-Id"
+	idempotencyKeyHeader = "Idempotency-Key"
+	apiKeyHeader         = "X-API-Key"
+
+	defaultPageSize = 100
+)
+
+// UserClient is the user API for Go programs. Unlike the generated Client
+// underneath it, it retries what can safely be retried, bounds every call
+// with a timeout, tags requests with a request ID and turns error responses
+// into *Error.
+type UserClient struct {
+	api     ClientWithResponsesInterface
+	timeout time.Duration
+}
+
+type options struct {
+	doer    HttpRequestDoer
+	timeout time.Duration
+	retry   RetryPolicy
+	editors []RequestEditorFn
+}
+
+type Option func(*options)
+
+// WithDoer sends requests through doer instead of a plain http.Client.
+func WithDoer(doer HttpRequestDoer) Option {
+	return func(o *options) {
+		o.doer = doer
+	}
+}
+
+// WithTimeout bounds every call, retries included. The default is 10s, 0
+// leaves it to the context.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = d
+	}
+}
+
+// WithRetryPolicy replaces DefaultRetryPolicy.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(o *options) {
+		o.retry = p
+	}
+}
+
+// WithAPIKey identifies the client to the API's rate limiter.
+func WithAPIKey(key string) Option {
+	return WithEditor(func(_ context.Context, req *http.Request) error {
+		req.Header.Set(apiKeyHeader, key)
+		return nil
+	})
+}
+
+// WithEditor changes every request before it is sent.
+func WithEditor(fn RequestEditorFn) Option {
+	return func(o *options) {
+		o.editors = append(o.editors, fn)
+	}
+}
+
+// New returns a client of the API under server, e.g.
+// "https://users.example.com/api/v1".
+func New(server string, opts ...Option) (*UserClient, error) {
+	o := &options{doer: http.DefaultClient, timeout: 10 * time.Second, retry: DefaultRetryPolicy}
+	for _, opt := range opts {
+		opt(o)
+	}
+	clientOpts := []ClientOption{
+		WithHTTPClient(&retryDoer{next: o.doer, policy: o.retry}),
+		WithRequestEditorFn(setRequestID),
+	}
+	for _, fn := range o.editors {
+		clientOpts = append(clientOpts, WithRequestEditorFn(fn))
+	}
+	api, err := NewClientWithResponses(server, clientOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return &UserClient{api: api, timeout: o.timeout}, nil
+}
+
+type requestIDKey struct{}
+
+// WithRequestID makes the calls made with ctx carry id, so that they can be
+// found in the server's logs. Without it the ID of the request being served,
+// as set by chi's RequestID middleware, is passed on, or a new one is made.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// setRequestID runs once per call, so retries share the ID.
+func setRequestID(ctx context.Context, req *http.Request) error {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	if id == "" {
+		id = middleware.GetReqID(ctx)
+	}
+	if id == "" {
+		id = uuid.NewString()
+	}
+	req.Header.Set(requestIDHeader, id)
+	return nil
+}
+
+func (c *UserClient) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+func (c *UserClient) GetUser(ctx context.Context, id string) (*UserResponse, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	rsp, err := c.api.GetUsersUserIdWithResponse(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rsp.JSON200 == nil {
+		return nil, newError(rsp.HTTPResponse, rsp.Body)
+	}
+	return rsp.JSON200, nil
+}
+
+// ListUsers returns at most limit users after skipping offset of them.
+func (c *UserClient) ListUsers(ctx context.Context, offset, limit int) ([]UserResponse, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	rsp, err := c.api.GetUsersWithResponse(ctx, &GetUsersParams{Offset: &offset, Limit: &limit})
+	if err != nil {
+		return nil, err
+	}
+	if rsp.JSON200 == nil {
+		return nil, newError(rsp.HTTPResponse, rsp.Body)
+	}
+	return rsp.JSON200.Item, nil
+}
+
+// Users iterates over all users, fetching pageSize of them at a time, 100
+// if pageSize is not positive. It stops at the first error, which it yields.
+func (c *UserClient) Users(ctx context.Context, pageSize int) iter.Seq2[UserResponse, error] {
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	return func(yield func(UserResponse, error) bool) {
+		for offset := 0; ; offset += pageSize {
+			users, err := c.ListUsers(ctx, offset, pageSize)
+			if err != nil {
+				yield(UserResponse{}, err)
+				return
+			}
+			for _, u := range users {
+				if !yield(u, nil) {
+					return
+				}
+			}
+			if len(users) < pageSize {
+				return
+			}
+		}
+	}
+}
+
+// CreateUser creates a user. The request carries a fresh Idempotency-Key so
+// that it can be retried without creating the user twice.
+func (c *UserClient) CreateUser(ctx context.Context, user UserPost) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	rsp, err := c.api.PostUsersWithResponse(ctx, user, idempotencyKey())
+	if err != nil {
+		return err
+	}
+	if rsp.StatusCode() != http.StatusCreated {
+		return newError(rsp.HTTPResponse, rsp.Body)
+	}
+	return nil
+}
+
+func (c *UserClient) UpdateUser(ctx context.Context, id string, user UserPut) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	rsp, err := c.api.PutUsersUserIdWithResponse(ctx, id, user)
+	if err != nil {
+		return err
+	}
+	if rsp.StatusCode() >= http.StatusBadRequest {
+		return newError(rsp.HTTPResponse, rsp.Body)
+	}
+	return nil
+}
+
+func (c *UserClient) DeleteUser(ctx context.Context, id string) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	rsp, err := c.api.DeleteUsersUserIdWithResponse(ctx, id)
+	if err != nil {
+		return err
+	}
+	if rsp.StatusCode() >= http.StatusBadRequest {
+		return newError(rsp.HTTPResponse, rsp.Body)
+	}
+	return nil
+}
+
+// RestoreUser undoes DeleteUser.
+func (c *UserClient) RestoreUser(ctx context.Context, id string) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	rsp, err := c.api.PostUsersUserIdRestoreWithResponse(ctx, id, idempotencyKey())
+	if err != nil {
+		return err
+	}
+	if rsp.StatusCode() >= http.StatusBadRequest {
+		return newError(rsp.HTTPResponse, rsp.Body)
+	}
+	return nil
+}
+
+// idempotencyKey makes a POST safe to retry, the key is the same for all
+// attempts of the call.
+func idempotencyKey() RequestEditorFn {
+	key := uuid.NewString()
+	return func(_ context.Context, req *http.Request) error {
+		req.Header.Set(idempotencyKeyHeader, key)
+		return nil
+	}
+}
+
+// ExportPersonalData returns everything stored about a user, erased or
+// deleted ones included.
+func (c *UserClient) ExportPersonalData(ctx context.Context, id string) (*PersonalDataResponse, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	rsp, err := c.api.GetUsersUserIdPersonalDataWithResponse(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rsp.JSON200 == nil {
+		return nil, newError(rsp.HTTPResponse, rsp.Body)
+	}
+	return rsp.JSON200, nil
+}
+
+// ErasePersonalData anonymises a user and deletes it for good; unlike
+// DeleteUser it cannot be undone.
+func (c *UserClient) ErasePersonalData(ctx context.Context, id string) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	rsp, err := c.api.DeleteUsersUserIdPersonalDataWithResponse(ctx, id)
+	if err != nil {
+		return err
+	}
+	if rsp.StatusCode() >= http.StatusBadRequest {
+		return newError(rsp.HTTPResponse, rsp.Body)
+	}
+	return nil
+}
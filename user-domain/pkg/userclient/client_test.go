@@ -0,0 +1,177 @@
+package userclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+	"user-domain/infrastructure/config"
+	router "user-domain/infrastructure/http"
+	"user-domain/infrastructure/http/middleware"
+	application_mock "user-domain/internal/application/mocks/outbound"
+	domainerror "user-domain/internal/domain/error"
+	domain_mock "user-domain/internal/domain/mocks/inport"
+	"user-domain/internal/entity"
+	"user-domain/schemas"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder sits in front of the router; it remembers the headers of every
+// request and can fail the first ones.
+type recorder struct {
+	next http.Handler
+
+	mu      sync.Mutex
+	headers []http.Header
+	fail    int
+}
+
+func (rec *recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rec.mu.Lock()
+	rec.headers = append(rec.headers, r.Header.Clone())
+	fail := rec.fail > 0
+	rec.fail--
+	rec.mu.Unlock()
+	if fail {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	rec.next.ServeHTTP(w, r)
+}
+
+func newTestClient(t *testing.T, opts ...Option) (*UserClient, *domain_mock.UserService, *recorder) {
+	t.Helper()
+	sv := domain_mock.NewUserService(t)
+	logger := application_mock.NewLogger(t)
+	logger.On("WithContext", mock.Anything).Return(logger).Maybe()
+	logger.On("Info", mock.Anything, mock.Anything).Maybe()
+	logger.On("Warn", mock.Anything, mock.Anything).Maybe()
+	logger.On("Error", mock.Anything, mock.Anything).Maybe()
+
+	spec, err := middleware.LoadOpenAPI(schemas.User, "/api/v1")
+	require.NoError(t, err)
+	rec := &recorder{next: router.BuildRouter(&config.Config{}, nil, logger,
+		router.WithUserService(sv),
+		router.WithOpenAPIValidation(spec, false),
+	)}
+	srv := httptest.NewServer(rec)
+	t.Cleanup(srv.Close)
+
+	opts = append([]Option{WithRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond})}, opts...)
+	c, err := New(srv.URL+"/api/v1", opts...)
+	require.NoError(t, err)
+	return c, sv, rec
+}
+
+func TestGetUser(t *testing.T) {
+	t.Parallel()
+	c, sv, rec := newTestClient(t)
+	sv.On("GetUserByID", mock.Anything, "42").Return(&entity.User{ID: "42", Name: "An", Email: "an@example.com"}, nil)
+
+	u, err := c.GetUser(WithRequestID(context.Background(), "req-1"), "42")
+	require.NoError(t, err)
+	require.Equal(t, "42", u.Id)
+	require.Equal(t, "an@example.com", string(u.Email))
+	require.Equal(t, "req-1", rec.headers[0].Get(requestIDHeader))
+}
+
+func TestErrorsAreTyped(t *testing.T) {
+	t.Parallel()
+	c, sv, _ := newTestClient(t)
+	sv.On("GetUserByID", mock.Anything, "42").Return((*entity.User)(nil),
+		domainerror.New(domainerror.ErrCodeNotFound, domainerror.CodeUserNotFound, "user 42 was not found"))
+
+	_, err := c.GetUser(WithRequestID(context.Background(), "req-1"), "42")
+	require.ErrorIs(t, err, ErrNotFound)
+	require.Equal(t, CodeUserNotFound, CodeOf(err))
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	require.Equal(t, "user 42 was not found", apiErr.Detail)
+	require.Equal(t, "req-1", apiErr.Instance)
+
+	sv.On("CreateUser", mock.Anything, mock.Anything).Return(
+		domainerror.New(domainerror.ErrCodeInvalidInput, domainerror.CodeValidation, "user is invalid").
+			WithFields(domainerror.FieldError{Field: "phone", Message: "is not a phone number"}))
+	err = c.CreateUser(context.Background(), UserPost{Name: "An", Email: "an@example.com"})
+	require.ErrorIs(t, err, ErrInvalidInput)
+	require.Equal(t, CodeValidation, CodeOf(err))
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, []FieldError{{Field: "phone", Message: "is not a phone number"}}, apiErr.Fields)
+}
+
+func TestRetries(t *testing.T) {
+	t.Parallel()
+	c, sv, rec := newTestClient(t)
+	sv.On("CreateUser", mock.Anything, mock.Anything).Return(nil).Once()
+	sv.On("DeleteUser", mock.Anything, "42").Return(nil).Once()
+
+	rec.fail = 2
+	require.NoError(t, c.CreateUser(context.Background(), UserPost{Name: "An", Email: "an@example.com"}))
+	require.Len(t, rec.headers, 3)
+	key := rec.headers[0].Get(idempotencyKeyHeader)
+	require.NotEmpty(t, key)
+	for _, h := range rec.headers {
+		require.Equal(t, key, h.Get(idempotencyKeyHeader))
+		require.Equal(t, rec.headers[0].Get(requestIDHeader), h.Get(requestIDHeader))
+	}
+
+	sv.On("RestoreUser", mock.Anything, "42").Return(nil).Once()
+	rec.fail = 1
+	require.NoError(t, c.RestoreUser(context.Background(), "42"))
+	require.Len(t, rec.headers, 5)
+
+	rec.fail = 3
+	err := c.DeleteUser(context.Background(), "42")
+	require.ErrorIs(t, err, ErrInternal)
+	require.Len(t, rec.headers, 8)
+	require.NoError(t, c.DeleteUser(context.Background(), "42"))
+}
+
+func TestUsersIteratesPages(t *testing.T) {
+	t.Parallel()
+	c, sv, _ := newTestClient(t)
+	sv.On("ListUsers", mock.Anything, 0, 2).Return([]*entity.User{{ID: "1", Email: "u1@example.com"}, {ID: "2", Email: "u2@example.com"}}, nil)
+	sv.On("ListUsers", mock.Anything, 2, 2).Return([]*entity.User{{ID: "3", Email: "u3@example.com"}, {ID: "4", Email: "u4@example.com"}}, nil)
+	sv.On("ListUsers", mock.Anything, 4, 2).Return([]*entity.User{{ID: "5", Email: "u5@example.com"}}, nil)
+
+	var ids []string
+	for u, err := range c.Users(context.Background(), 2) {
+		require.NoError(t, err)
+		ids = append(ids, u.Id)
+	}
+	require.Equal(t, []string{"1", "2", "3", "4", "5"}, ids)
+}
+
+func TestTimeout(t *testing.T) {
+	t.Parallel()
+	c, sv, _ := newTestClient(t, WithTimeout(20*time.Millisecond))
+	sv.On("GetUserByID", mock.Anything, "42").Return(func(ctx context.Context, _ string) (*entity.User, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}).Maybe()
+
+	_, err := c.GetUser(context.Background(), "42")
+	require.True(t, errors.Is(err, context.DeadlineExceeded), err)
+}
+
+func TestPersonalData(t *testing.T) {
+	t.Parallel()
+	c, sv, _ := newTestClient(t)
+	sv.On("ExportPersonalData", mock.Anything, "42").Return(&entity.PersonalData{
+		User:     entity.User{ID: "42", Name: "An", Email: "an@example.com"},
+		Requests: []entity.PersonalDataRequest{{ID: "1", UserID: "42", Kind: entity.PersonalDataExport, RequestedAt: time.Now()}},
+	}, nil)
+	sv.On("ErasePersonalData", mock.Anything, "42").Return(nil)
+
+	data, err := c.ExportPersonalData(context.Background(), "42")
+	require.NoError(t, err)
+	require.Equal(t, "an@example.com", string(data.User.Email))
+	require.Len(t, data.Requests, 1)
+	require.NoError(t, c.ErasePersonalData(context.Background(), "42"))
+}
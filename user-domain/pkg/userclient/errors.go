@@ -0,0 +1,127 @@
+package userclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	domainerror "user-domain/internal/domain/error"
+)
+
+// Code is the stable error code the API returns, see the constants below.
+type Code = domainerror.Code
+
+// FieldError describes one invalid input field.
+type FieldError = domainerror.FieldError
+
+const (
+	CodeNotFound         = domainerror.CodeNotFound
+	CodeInvalidInput     = domainerror.CodeInvalidInput
+	CodeConflict         = domainerror.CodeConflict
+	CodeForbidden        = domainerror.CodeForbidden
+	CodeInternal         = domainerror.CodeInternal
+	CodeMalformedRequest = domainerror.CodeMalformedRequest
+	CodeValidation       = domainerror.CodeValidation
+	CodeRateLimited      = domainerror.CodeRateLimited
+	CodeIdempotencyReuse = domainerror.CodeIdempotencyReuse
+	CodeIdempotencyBusy  = domainerror.CodeIdempotencyBusy
+	CodeUserNotFound     = domainerror.CodeUserNotFound
+	CodeEmailTaken       = domainerror.CodeEmailTaken
+)
+
+// The kinds of failure, match them with errors.Is. They are the service's
+// own, so an *Error matches exactly when the server failed for that reason.
+var (
+	ErrNotFound     = domainerror.ErrCodeNotFound
+	ErrInvalidInput = domainerror.ErrCodeInvalidInput
+	ErrConflict     = domainerror.ErrCodeConflict
+	ErrForbidden    = domainerror.ErrCodeForbidden
+	ErrInternal     = domainerror.ErrCodeInternal
+)
+
+// Error is a request the API answered with a problem; it carries the
+// RFC 7807 body.
+type Error struct {
+	StatusCode int
+	Code       Code
+	Title      string
+	Detail     string
+	// Instance is the request ID the server logged the failure under.
+	Instance string
+	Fields   []FieldError
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("userclient: %d %s", e.StatusCode, e.Code)
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	return msg
+}
+
+// Unwrap returns the kind of failure the status stands for, the same way
+// the server chose the status from the kind.
+func (e *Error) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusConflict:
+		return ErrConflict
+	case e.StatusCode == http.StatusBadRequest, e.StatusCode == http.StatusUnprocessableEntity:
+		return ErrInvalidInput
+	case e.StatusCode == http.StatusForbidden:
+		return ErrForbidden
+	case e.StatusCode >= http.StatusInternalServerError:
+		return ErrInternal
+	}
+	return nil
+}
+
+// CodeOf returns the code of the *Error in err's chain, or "" if the
+// request failed before the API answered.
+func CodeOf(err error) Code {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return ""
+}
+
+type problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail"`
+	Instance string       `json:"instance"`
+	Code     Code         `json:"code"`
+	Errors   []FieldError `json:"errors"`
+}
+
+// newError reads the problem in body. Responses that are not problems,
+// e.g. from a proxy in front of the API, get the generic code of their
+// status.
+func newError(rsp *http.Response, body []byte) *Error {
+	e := &Error{StatusCode: rsp.StatusCode}
+	var p problem
+	if mt, _, _ := mime.ParseMediaType(rsp.Header.Get("Content-Type")); mt == "application/problem+json" && json.Unmarshal(body, &p) == nil {
+		e.Code, e.Title, e.Detail, e.Instance, e.Fields = p.Code, p.Title, p.Detail, p.Instance, p.Errors
+		return e
+	}
+	switch {
+	case rsp.StatusCode == http.StatusNotFound:
+		e.Code = CodeNotFound
+	case rsp.StatusCode == http.StatusConflict:
+		e.Code = CodeConflict
+	case rsp.StatusCode == http.StatusForbidden:
+		e.Code = CodeForbidden
+	case rsp.StatusCode == http.StatusTooManyRequests:
+		e.Code = CodeRateLimited
+	case rsp.StatusCode < http.StatusInternalServerError:
+		e.Code = CodeInvalidInput
+	default:
+		e.Code = CodeInternal
+	}
+	e.Title = http.StatusText(rsp.StatusCode)
+	return e
+}
@@ -0,0 +1,113 @@
+package userclient
+
+import (
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy decides how often and how patiently failed requests are
+// retried. Only requests that are safe to repeat are retried: GET, PUT and
+// DELETE, and POST when it carries an Idempotency-Key.
+type RetryPolicy struct {
+	// MaxAttempts counts the first try, 1 disables retries.
+	MaxAttempts int
+	// BaseDelay is the backoff before the second attempt; it doubles with
+	// every attempt up to MaxDelay. Each wait is drawn uniformly from
+	// [0, backoff) so that clients failing together do not retry together.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// DefaultRetryPolicy is used unless WithRetryPolicy says otherwise.
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 3, BaseDelay: 100 * time.Millisecond, MaxDelay: 2 * time.Second}
+
+// retryDoer retries the requests of next according to policy.
+type retryDoer struct {
+	next   HttpRequestDoer
+	policy RetryPolicy
+}
+
+func (d *retryDoer) Do(req *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		rsp, err := d.next.Do(req)
+		if attempt >= d.policy.MaxAttempts || !d.retryable(req, rsp, err) {
+			return rsp, err
+		}
+		wait := d.backoff(attempt)
+		if rsp != nil {
+			if after, ok := retryAfter(rsp); ok {
+				// Waiting longer than the policy allows is the caller's call.
+				if after > d.policy.MaxDelay {
+					return rsp, err
+				}
+				wait = after
+			}
+			_, _ = io.Copy(io.Discard, rsp.Body)
+			_ = rsp.Body.Close()
+		}
+
+		t := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			t.Stop()
+			return nil, req.Context().Err()
+		case <-t.C:
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+func (d *retryDoer) retryable(req *http.Request, rsp *http.Response, err error) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+	case http.MethodPost:
+		if req.Header.Get(idempotencyKeyHeader) == "" {
+			return false
+		}
+	default:
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	if err != nil {
+		return req.Context().Err() == nil
+	}
+	switch rsp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func (d *retryDoer) backoff(attempt int) time.Duration {
+	ceiling := d.policy.MaxDelay
+	if shift := attempt - 1; shift < 32 {
+		ceiling = min(ceiling, d.policy.BaseDelay<<shift)
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling)
+}
+
+// retryAfter reads the delay-seconds form of Retry-After, which is what
+// the rate limiter sends.
+func retryAfter(rsp *http.Response) (time.Duration, bool) {
+	secs, err := strconv.Atoi(rsp.Header.Get("Retry-After"))
+	if err != nil || secs < 0 {
+		return 0, false
+	}
+	return time.Duration(secs) * time.Second, true
+}
@@ -0,0 +1,11 @@
+// Package schemas embeds the bundled OpenAPI documents so that the service
+// can validate traffic against the same spec the handlers are generated from.
+package schemas
+
+import _ "embed"
+
+// User is build-bundle/user/index.yaml, regenerate it with
+// `make bundle_schemas TAG=user`.
+//
+//go:embed build-bundle/user/index.yaml
+var User []byte